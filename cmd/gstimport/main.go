@@ -0,0 +1,60 @@
+package main
+
+import (
+	"api-iras/internal/config"
+	"api-iras/internal/models"
+	"api-iras/internal/services"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// gstimport loads a GST registered business listing file into the GST
+// registrations, the same way as the admin import endpoint
+func main() {
+	file := flag.String("file", "", "GST listing file to import (required)")
+	format := flag.String("format", "", "Listing format, csv or fixed (defaults from the file extension)")
+	clientID := flag.String("client", "", "Client the registrations belong to (defaults to IBM_CLIENT_ID)")
+	listingDate := flag.String("listing-date", "", "Date of the listing, YYYY-MM-DD (defaults to today)")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Initialize configuration
+	config.InitConfig()
+	db := config.AppConfig.DB
+
+	if err := db.AutoMigrate(&models.GSTRegistration{}, &models.GSTRegistrationPeriod{}, &models.GSTImportRun{}); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	if *format == "" {
+		*format = services.GSTListingFormatForFile(*file)
+	}
+	if *clientID == "" {
+		*clientID = config.AppConfig.IBMClientID
+	}
+
+	listing, err := os.Open(*file)
+	if err != nil {
+		log.Fatal("Failed to open listing file:", err)
+	}
+	defer listing.Close()
+
+	run, err := services.NewGSTImportService(db).ImportListing(*clientID, filepath.Base(*file), *format, *listingDate, listing)
+	if run != nil {
+		log.Printf("Import run %d %s: %d rows, %d inserted, %d updated, %d unchanged, %d deregistered, %d rejected",
+			run.ID, run.Status, run.TotalRows, run.Inserted, run.Updated, run.Unchanged, run.Deregistered, run.Rejected)
+		if run.LineErrors != "" {
+			log.Printf("Rejected rows: %s", run.LineErrors)
+		}
+	}
+	if err != nil {
+		listing.Close()
+		log.Fatal("Failed to import GST listing:", err)
+	}
+}
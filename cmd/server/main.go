@@ -50,23 +50,47 @@ func autoMigrate() error {
 		}
 	}
 
+	// CorpPass access tokens used to be predictable and repeated for each
+	// CorpPass ID. They are removed so tokens can be unique.
+	if db.Migrator().HasTable(&models.CorpPassTokenRecord{}) {
+		if err := db.Unscoped().Where("access_token LIKE ?", "corppass_access_token_%").Delete(&models.CorpPassTokenRecord{}).Error; err != nil {
+			log.Printf("Warning: Failed to remove predictable CorpPass tokens: %v", err)
+		}
+	}
+
 	// Auto-migrate all models (this will create tables with correct schema)
 	log.Println("Running auto-migration...")
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.GSTRegistration{},
+		&models.GSTRegistrationPeriod{},
+		&models.GSTImportRun{},
+		&models.GSTReturnRecord{},
+		&models.GSTTransListRecord{},
 		&models.PropertyConsolidatedStatementRecord{},
 		&models.PropertyTaxBalanceRecord{},
 		&models.RentalSubmissionRecord{},
 		&models.CITConversionRecord{},
 		&models.SingPassAuthRecord{},
 		&models.SingPassTokenRecord{},
+		&models.CorpPassAuthRecord{},
+		&models.CorpPassTokenRecord{},
+		&models.AbsdRefundClaim{},
+		&models.EStampRecord{},
 	)
 
 	if err != nil {
 		return err
 	}
 
+	// Trigram matching ranks fuzzy GST name searches; without it they fall
+	// back to ILIKE ranking
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Printf("Warning: pg_trgm is unavailable, GST name search will use ILIKE ranking: %v", err)
+	} else if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_gst_registrations_name_trgm ON gst_registrations USING gin (name gin_trgm_ops)").Error; err != nil {
+		log.Printf("Warning: Failed to create GST name trigram index: %v", err)
+	}
+
 	log.Println("Database migration completed successfully")
 	return nil
 }
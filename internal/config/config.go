@@ -18,6 +18,8 @@ type Config struct {
 	Env             string
 	IBMClientID     string
 	IBMClientSecret string
+	ABSDRates       string
+	StampCertKey    string
 }
 
 var AppConfig *Config
@@ -34,6 +36,8 @@ func InitConfig() {
 		Env:             getEnv("ENV", "development"),
 		IBMClientID:     getEnv("IBM_CLIENT_ID", "demo-client-id-12345"),
 		IBMClientSecret: getEnv("IBM_CLIENT_SECRET", "demo-client-secret-67890"),
+		ABSDRates:       getEnv("ABSD_RATES", ""),
+		StampCertKey:    getEnv("STAMP_CERT_SIGNING_KEY", ""),
 	}
 
 	// Initialize database
@@ -64,8 +68,11 @@ func initDB() (*gorm.DB, error) {
 		logLevel = logger.Error
 	}
 
+	// TranslateError reports unique violations as gorm.ErrDuplicatedKey, so
+	// callers can retry when a generated reference is already taken
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger:         logger.Default.LogMode(logLevel),
+		TranslateError: true,
 	})
 
 	if err != nil {
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"api-iras/internal/config"
+	"api-iras/internal/models"
+	"api-iras/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AbsdRefundController struct {
+	absdRefundService *services.AbsdRefundService
+}
+
+func NewAbsdRefundController(absdRefundService *services.AbsdRefundService) *AbsdRefundController {
+	return &AbsdRefundController{absdRefundService: absdRefundService}
+}
+
+// @Summary Submit ABSD Refund Claim
+// @Description Claim the ABSD refund for a replacement-home purchase after the previous home is sold
+// @Tags eStamp
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass Access Token"
+// @Param body body models.AbsdRefundClaimRequest true "ABSD Refund Claim Request"
+// @Success 200 {object} models.AbsdRefundClaimResponse
+// @Router /iras/sb/eStamp/AbsdRefundClaim [post]
+func (ctrl *AbsdRefundController) SubmitAbsdRefundClaim(c *gin.Context) {
+	// Validate headers
+	clientID := c.GetHeader("X-IBM-Client-Id")
+	clientSecret := c.GetHeader("X-IBM-Client-Secret")
+	accessToken := c.GetHeader("access_token")
+
+	// For development, accept demo credentials
+	if config.AppConfig.Env == "development" {
+		if clientID == "" {
+			clientID = config.AppConfig.IBMClientID
+		}
+		if clientSecret == "" {
+			clientSecret = config.AppConfig.IBMClientSecret
+		}
+		if accessToken == "" {
+			accessToken = "demo_access_token_123456"
+		}
+	}
+
+	if clientID == "" || clientSecret == "" {
+		c.JSON(http.StatusUnauthorized, models.AbsdRefundClaimResponse{
+			ReturnCode: 40,
+			Info: &models.EStampInfo{
+				MessageCode: 40003,
+				Message:     "Missing required headers",
+				FieldInfoList: []models.EStampFieldError{
+					{
+						Field:   "headers",
+						Message: "X-IBM-Client-Id and X-IBM-Client-Secret are required",
+					},
+				},
+			},
+		})
+		return
+	}
+
+	if accessToken == "" {
+		c.JSON(http.StatusUnauthorized, models.AbsdRefundClaimResponse{
+			ReturnCode: 40,
+			Info: &models.EStampInfo{
+				MessageCode: 40004,
+				Message:     "Missing access token",
+				FieldInfoList: []models.EStampFieldError{
+					{
+						Field:   "access_token",
+						Message: "CorpPass access token is required",
+					},
+				},
+			},
+		})
+		return
+	}
+
+	// Parse request body
+	var req models.AbsdRefundClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.AbsdRefundClaimResponse{
+			ReturnCode: 40,
+			Info: &models.EStampInfo{
+				MessageCode: 40005,
+				Message:     "Invalid request format",
+				FieldInfoList: []models.EStampFieldError{
+					{
+						Field:   "body",
+						Message: "Invalid JSON format or missing required fields",
+					},
+				},
+			},
+		})
+		return
+	}
+
+	response, err := ctrl.absdRefundService.SubmitClaim(clientID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.AbsdRefundClaimResponse{
+			ReturnCode: 50,
+			Info: &models.EStampInfo{
+				MessageCode: 50001,
+				Message:     "Internal server error",
+			},
+		})
+		return
+	}
+
+	// Return response based on return code
+	switch response.ReturnCode {
+	case 10:
+		c.JSON(http.StatusOK, response)
+	case 20:
+		c.JSON(http.StatusNotFound, response)
+	default:
+		c.JSON(http.StatusBadRequest, response)
+	}
+}
+
+// Admin endpoints for managing ABSD refund claims
+
+// GetAbsdRefundClaims retrieves ABSD refund claims with pagination and an optional status filter
+func (ctrl *AbsdRefundController) GetAbsdRefundClaims(c *gin.Context) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	claims, total, err := ctrl.absdRefundService.GetAbsdRefundClaims(c.Query("status"), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch ABSD refund claims",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	response := models.PaginationResponse{
+		Data:       claims,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "ABSD refund claims retrieved successfully",
+		Data:    response,
+	})
+}
+
+// GetAbsdRefundClaim retrieves a specific ABSD refund claim by ID
+func (ctrl *AbsdRefundController) GetAbsdRefundClaim(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid ID format",
+		})
+		return
+	}
+
+	claim, err := ctrl.absdRefundService.GetAbsdRefundClaimByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "ABSD refund claim not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "ABSD refund claim retrieved successfully",
+		Data:    claim,
+	})
+}
+
+// ApproveAbsdRefundClaim approves a submitted ABSD refund claim
+func (ctrl *AbsdRefundController) ApproveAbsdRefundClaim(c *gin.Context) {
+	ctrl.decideAbsdRefundClaim(c, true)
+}
+
+// RejectAbsdRefundClaim rejects a submitted ABSD refund claim
+func (ctrl *AbsdRefundController) RejectAbsdRefundClaim(c *gin.Context) {
+	ctrl.decideAbsdRefundClaim(c, false)
+}
+
+func (ctrl *AbsdRefundController) decideAbsdRefundClaim(c *gin.Context, approve bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid ID format",
+		})
+		return
+	}
+
+	var body struct {
+		Remarks string `json:"remarks"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid request format",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	claim, err := ctrl.absdRefundService.DecideAbsdRefundClaim(uint(id), approve, c.GetString("username"), body.Remarks)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update ABSD refund claim"
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+			message = "ABSD refund claim not found"
+		case errors.Is(err, services.ErrInvalidClaimTransition):
+			status = http.StatusConflict
+			message = "Only submitted ABSD refund claims can be approved or rejected"
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "ABSD refund claim " + claim.Status + " successfully",
+		Data:    claim,
+	})
+}
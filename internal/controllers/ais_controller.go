@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 
 	"api-iras/internal/models"
 	"api-iras/internal/services"
@@ -12,13 +11,13 @@ import (
 
 type AISController struct {
 	aisService *services.AISService
-	validator  *validator.Validate
+	validator  *services.RequestValidator
 }
 
 func NewAISController(aisService *services.AISService) *AISController {
 	return &AISController{
 		aisService: aisService,
-		validator:  validator.New(),
+		validator:  services.NewRequestValidator(),
 	}
 }
 
@@ -37,11 +36,11 @@ func (ac *AISController) AISOrgSearch(c *gin.Context) {
 	}
 
 	// Validate request
-	if err := ac.validator.Struct(request); err != nil {
+	if fieldErrors := ac.validator.Validate(&request); len(fieldErrors) > 0 {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "Validation failed",
-			Error:   err.Error(),
+			Error:   fieldErrors.Error(),
 		})
 		return
 	}
@@ -3,16 +3,21 @@ package controllers
 import (
 	"api-iras/internal/config"
 	"api-iras/internal/models"
+	"api-iras/internal/services"
+	"api-iras/pkg/utils"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-type CorpPassController struct{}
+type CorpPassController struct {
+	accessTokenService *services.AccessTokenService
+}
 
-func NewCorpPassController() *CorpPassController {
-	return &CorpPassController{}
+func NewCorpPassController(accessTokenService *services.AccessTokenService) *CorpPassController {
+	return &CorpPassController{accessTokenService: accessTokenService}
 }
 
 // @Summary CorpPass Authentication
@@ -25,6 +30,7 @@ func NewCorpPassController() *CorpPassController {
 // @Param scope query string false "OAuth scope"
 // @Param callback_url query string false "Callback URL"
 // @Param state query string false "State parameter"
+// @Param uen query string true "UEN of the entity the CorpPass user acts for"
 // @Param tax_agent query bool false "Tax agent flag"
 // @Success 200 {object} models.CorpPassAuthResponse
 // @Router /iras/sb/Authentication/CorpPassAuth [get]
@@ -64,8 +70,27 @@ func (ctrl *CorpPassController) CorpPassAuth(c *gin.Context) {
 	scope := c.Query("scope")
 	callbackURL := c.Query("callback_url")
 	state := c.Query("state")
+	entityID := utils.NormalizeTaxID(c.Query("uen"))
 	taxAgent := c.Query("tax_agent") == "true"
 
+	// CorpPass users always act for an entity, which the token is tied to
+	if !utils.IsValidUEN(entityID) {
+		c.JSON(http.StatusBadRequest, models.CorpPassAuthResponse{
+			ReturnCode: 40,
+			Info: &models.CorpPassAuthInfo{
+				MessageCode: "850301",
+				Message:     "Arguments Error",
+				FieldInfoList: []models.CorpPassFieldError{
+					{
+						Field:   "uen",
+						Message: "A valid UEN of the entity is required",
+					},
+				},
+			},
+		})
+		return
+	}
+
 	// Simulate validation - callback_url must be registered
 	if callbackURL != "" && !ctrl.isValidCallbackURL(callbackURL) {
 		c.JSON(http.StatusBadRequest, models.CorpPassAuthResponse{
@@ -84,13 +109,59 @@ func (ctrl *CorpPassController) CorpPassAuth(c *gin.Context) {
 		return
 	}
 
+	// Default to the EmpIncomeSub scope
+	if scope == "" {
+		scope = services.ScopeEmpIncomeSub
+	}
+	if callbackURL == "" {
+		callbackURL = "https://demo.example.com/callback"
+	}
+
+	// Record the request so the token issued for its state gets this scope
+	// and entity
+	authRecord := &models.CorpPassAuthRecord{
+		State:       state,
+		ClientID:    clientID,
+		EntityID:    entityID,
+		Scope:       scope,
+		CallbackURL: callbackURL,
+		TaxAgent:    taxAgent,
+	}
+	if err := ctrl.accessTokenService.RecordCorpPassAuth(authRecord); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusBadRequest, models.CorpPassAuthResponse{
+				ReturnCode: 40,
+				Info: &models.CorpPassAuthInfo{
+					MessageCode: "850301",
+					Message:     "Arguments Error",
+					FieldInfoList: []models.CorpPassFieldError{
+						{
+							Field:   "state",
+							Message: "The state specified has already been used",
+						},
+					},
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.CorpPassAuthResponse{
+			ReturnCode: 50,
+			Info: &models.CorpPassAuthInfo{
+				MessageCode: "50001",
+				Message:     "Internal server error",
+			},
+		})
+		return
+	}
+
 	// Generate mock CorpPass URL
-	corpPassURL := ctrl.generateCorpPassURL(scope, callbackURL, state, taxAgent)
+	corpPassURL := ctrl.generateCorpPassURL(scope, callbackURL, authRecord.State, taxAgent)
 
 	c.JSON(http.StatusOK, models.CorpPassAuthResponse{
 		ReturnCode: 10,
 		Data: &models.CorpPassAuthData{
-			URL: corpPassURL,
+			URL:   corpPassURL,
+			State: authRecord.State,
 		},
 	})
 }
@@ -174,17 +245,38 @@ func (ctrl *CorpPassController) CorpPassToken(c *gin.Context) {
 		return
 	}
 
-	// Generate mock access token
-	accessToken := ctrl.generateAccessToken(req.ID)
+	// Issue a token for the scope and entity the state was authenticated for
+	tokenData, err := ctrl.accessTokenService.IssueCorpPassToken(clientID, req.State, req.ID)
+	if errors.Is(err, services.ErrAuthStateInvalid) {
+		c.JSON(http.StatusBadRequest, models.CorpPassTokenResponse{
+			ReturnCode: 40,
+			Info: &models.CorpPassAuthInfo{
+				MessageCode: "40006",
+				Message:     "Invalid state",
+				FieldInfoList: []models.CorpPassFieldError{
+					{
+						Field:   "state",
+						Message: "State not found or already used",
+					},
+				},
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.CorpPassTokenResponse{
+			ReturnCode: 50,
+			Info: &models.CorpPassAuthInfo{
+				MessageCode: "50001",
+				Message:     "Internal server error",
+			},
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.CorpPassTokenResponse{
 		ReturnCode: 10,
-		Data: &models.CorpPassTokenData{
-			AccessToken:  accessToken,
-			TokenType:    "Bearer",
-			ExpiresIn:    3600, // 1 hour
-			RefreshToken: ctrl.generateRefreshToken(req.ID),
-		},
+		Data:       tokenData,
 	})
 }
 
@@ -209,17 +301,6 @@ func (ctrl *CorpPassController) isValidCallbackURL(callbackURL string) bool {
 func (ctrl *CorpPassController) generateCorpPassURL(scope, callbackURL, state string, taxAgent bool) string {
 	baseURL := "https://stg-saml.corppass.gov.sg/FIM/sps/CorpIDPFed/saml20/logininitial"
 
-	// Default parameters for simulation
-	if scope == "" {
-		scope = "EmpIncomeSub"
-	}
-	if callbackURL == "" {
-		callbackURL = "https://demo.example.com/callback"
-	}
-	if state == "" {
-		state = "1234"
-	}
-
 	// Add tax agent parameter to scope if applicable
 	if taxAgent {
 		scope += ",TaxAgent"
@@ -233,13 +314,3 @@ func (ctrl *CorpPassController) generateCorpPassURL(scope, callbackURL, state st
 		"&state=" + state + "&client_id=iras&scope=" + scope +
 		"&redirect_uri=" + callbackURL
 }
-
-func (ctrl *CorpPassController) generateAccessToken(id int64) string {
-	// Generate mock access token
-	return "corppass_access_token_" + strconv.FormatInt(id, 10) + "_demo_12345"
-}
-
-func (ctrl *CorpPassController) generateRefreshToken(id int64) string {
-	// Generate mock refresh token
-	return "corppass_refresh_token_" + strconv.FormatInt(id, 10) + "_demo_67890"
-}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"api-iras/internal/models"
+	"api-iras/internal/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Quote Tenancy Agreement Duty
+// @Description Price a tenancy agreement without stamping it
+// @Tags eStamp
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass Access Token"
+// @Param body body models.StampTenancyAgreementRequest true "Tenancy Agreement Stamp Request"
+// @Success 200 {object} models.EStampQuoteResponse
+// @Router /iras/sb/eStamp/Quote/StampTenancyAgreement [post]
+func (ctrl *EStampController) QuoteStampTenancyAgreement(c *gin.Context) {
+	var req models.StampTenancyAgreementRequest
+	if _, ok := ctrl.bindEStampRequest(c, &req); !ok {
+		return
+	}
+
+	breakdown, err := ctrl.calculateStampTenancyAgreement(&req)
+	ctrl.quoteResponse(c, breakdown, err, services.TenancySubmission(&req), nil)
+}
+
+// @Summary Quote Share Transfer Duty
+// @Description Price a share transfer document without stamping it
+// @Tags eStamp
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass Access Token"
+// @Param body body models.ShareTransferRequest true "Share Transfer Stamp Request"
+// @Success 200 {object} models.EStampQuoteResponse
+// @Router /iras/sb/eStamp/Quote/ShareTransfer [post]
+func (ctrl *EStampController) QuoteShareTransfer(c *gin.Context) {
+	var req models.ShareTransferRequest
+	if _, ok := ctrl.bindEStampRequest(c, &req); !ok {
+		return
+	}
+
+	breakdown, err := ctrl.calculateShareTransfer(&req)
+	ctrl.quoteResponse(c, breakdown, err, services.ShareTransferSubmission(&req), nil)
+}
+
+// @Summary Quote Mortgage Duty
+// @Description Price a mortgage document without stamping it
+// @Tags eStamp
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass Access Token"
+// @Param body body models.StampMortgageRequest true "Mortgage Stamp Request"
+// @Success 200 {object} models.EStampQuoteResponse
+// @Router /iras/sb/eStamp/Quote/StampMortgage [post]
+func (ctrl *EStampController) QuoteStampMortgage(c *gin.Context) {
+	var req models.StampMortgageRequest
+	if _, ok := ctrl.bindEStampRequest(c, &req); !ok {
+		return
+	}
+
+	breakdown, err := ctrl.calculateStampMortgage(&req)
+	ctrl.quoteResponse(c, breakdown, err, services.MortgageSubmission(&req), nil)
+}
+
+// @Summary Quote Sale and Purchase Duty for Buyers
+// @Description Price a sale and purchase document for buyers without stamping it
+// @Tags eStamp
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass Access Token"
+// @Param body body models.SalePurchaseBuyersRequest true "Sale Purchase Buyers Stamp Request"
+// @Success 200 {object} models.EStampQuoteResponse
+// @Router /iras/sb/eStamp/Quote/SalePurchaseBuyers [post]
+func (ctrl *EStampController) QuoteSalePurchaseBuyers(c *gin.Context) {
+	var req models.SalePurchaseBuyersRequest
+	if _, ok := ctrl.bindEStampRequest(c, &req); !ok {
+		return
+	}
+
+	var warnings []string
+	if req.IntentToClaimAbsdRefund == 1 {
+		warnings = append(warnings, "The ABSD refund intent is only recorded when the document is stamped")
+	}
+
+	breakdown, err := ctrl.calculateSalePurchaseBuyers(&req)
+	ctrl.quoteResponse(c, breakdown, err, services.BuyersSubmission(&req), warnings)
+}
+
+// @Summary Quote Sale and Purchase Duty for Sellers
+// @Description Price a sale and purchase document for sellers without stamping it
+// @Tags eStamp
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass Access Token"
+// @Param body body models.SalePurchaseSellersRequest true "Sale Purchase Sellers Stamp Request"
+// @Success 200 {object} models.EStampQuoteResponse
+// @Router /iras/sb/eStamp/Quote/SalePurchaseSellers [post]
+func (ctrl *EStampController) QuoteSalePurchaseSellers(c *gin.Context) {
+	var req models.SalePurchaseSellersRequest
+	if _, ok := ctrl.bindEStampRequest(c, &req); !ok {
+		return
+	}
+
+	breakdown, err := ctrl.calculateSalePurchaseSellers(&req)
+	ctrl.quoteResponse(c, breakdown, err, services.SellersSubmission(&req), nil)
+}
+
+// quoteResponse writes the duty quote for a calculated breakdown. The late
+// stamping penalty and payment due date assume the document is stamped
+// today. Nothing is recorded and no document reference is issued.
+func (ctrl *EStampController) quoteResponse(c *gin.Context, breakdown *models.EStampDutyBreakdown, err error, submission *services.EStampSubmission, warnings []string) {
+	if err == nil {
+		var paymentDue time.Time
+		paymentDue, err = ctrl.stampDutyService.ApplyLatePenalty(breakdown, submission.IsSignedInSingapore, submission.ReceivingDateOfDocument, time.Now())
+		if err == nil {
+			c.JSON(http.StatusOK, models.EStampQuoteResponse{
+				ReturnCode: 10,
+				Data:       ctrl.quoteData(breakdown, paymentDue, warnings),
+			})
+			return
+		}
+	}
+
+	response := ctrl.dutyErrorResponse(err)
+	c.JSON(http.StatusBadRequest, models.EStampQuoteResponse{
+		ReturnCode: response.ReturnCode,
+		Info:       response.Info,
+	})
+}
+
+// quoteData formats the amounts payable on a quoted breakdown and warns of
+// anything that would change them when the document is stamped
+func (ctrl *EStampController) quoteData(breakdown *models.EStampDutyBreakdown, paymentDue time.Time, warnings []string) *models.EStampQuoteData {
+	stampDuty := breakdown.TotalDuty
+	penalty := breakdown.Penalty.Amount
+
+	if breakdown.Penalty.DaysLate > 0 {
+		warnings = append(warnings, fmt.Sprintf("The stamping deadline of %s has passed; the penalty grows the longer stamping is delayed", breakdown.Penalty.StampingDeadline))
+	} else {
+		warnings = append(warnings, fmt.Sprintf("Stamp by %s to avoid a late stamping penalty", breakdown.Penalty.StampingDeadline))
+	}
+	if stampDuty == 0 {
+		warnings = append(warnings, "No duty is payable on this document as submitted")
+	}
+
+	return &models.EStampQuoteData{
+		SDAmount:        fmt.Sprintf("%.2f", stampDuty),
+		SDRemission:     ctrl.remissionAmount(breakdown),
+		SDPenalty:       fmt.Sprintf("%.2f", penalty),
+		TotalAmtPayable: fmt.Sprintf("%.2f", stampDuty+penalty),
+		PaymentDueDate:  paymentDue.Format("2006-01-02"),
+		DutyBreakdown:   breakdown,
+		Warnings:        warnings,
+	}
+}
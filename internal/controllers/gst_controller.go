@@ -4,18 +4,27 @@ import (
 	"api-iras/internal/config"
 	"api-iras/internal/models"
 	"api-iras/internal/services"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type GSTController struct {
-	gstService *services.GSTService
+	gstService       *services.GSTService
+	gstImportService *services.GSTImportService
 }
 
-func NewGSTController(gstService *services.GSTService) *GSTController {
-	return &GSTController{gstService: gstService}
+func NewGSTController(gstService *services.GSTService, gstImportService *services.GSTImportService) *GSTController {
+	return &GSTController{
+		gstService:       gstService,
+		gstImportService: gstImportService,
+	}
 }
 
 // @Summary Search GST Registered
@@ -30,29 +39,96 @@ func NewGSTController(gstService *services.GSTService) *GSTController {
 // @Router /iras/prod/GSTListing/SearchGSTRegistered [post]
 func (ctrl *GSTController) SearchGSTRegistered(c *gin.Context) {
 	// Validate headers
-	clientID := c.GetHeader("X-IBM-Client-Id")
-	clientSecret := c.GetHeader("X-IBM-Client-Secret")
+	clientID, ok := ctrl.clientIDFromHeaders(c)
+	if !ok {
+		return
+	}
 
-	// For development, accept demo credentials
-	if config.AppConfig.Env == "development" {
-		if clientID == "" {
-			clientID = config.AppConfig.IBMClientID
-		}
-		if clientSecret == "" {
-			clientSecret = config.AppConfig.IBMClientSecret
-		}
+	// Parse request body
+	var req models.GSTRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid request format",
+				MessageCode: 40004,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "body",
+						Message: "Invalid JSON format",
+					},
+				},
+			},
+		})
+		return
 	}
 
-	if clientID == "" || clientSecret == "" {
-		c.JSON(http.StatusUnauthorized, models.GSTResponse{
+	// Override clientID from header if provided
+	if req.ClientID == "" {
+		req.ClientID = clientID
+	}
+
+	// Perform GST search
+	response, err := ctrl.gstService.SearchGSTRegistered(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.GSTResponse{
+			ReturnCode: 50,
+			Info: &models.GSTInfo{
+				Message:     "Internal server error",
+				MessageCode: 50001,
+			},
+		})
+		return
+	}
+
+	// Return response based on return code
+	switch response.ReturnCode {
+	case 10:
+		c.JSON(http.StatusOK, response)
+	case 20:
+		c.JSON(http.StatusNotFound, response)
+	case 40:
+		c.JSON(http.StatusBadRequest, response)
+	default:
+		c.JSON(http.StatusInternalServerError, response)
+	}
+}
+
+// @Summary Bulk Search GST Registered
+// @Description Check many GST registration numbers, UENs or NRICs in one call. Accepts a JSON body, a CSV body or an uploaded CSV file with one registration ID per row, and returns JSON or, with format=csv, a CSV download.
+// @Tags GST
+// @Accept json
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Produce text/csv
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param body body models.GSTBulkRequest false "GST Bulk Search Request"
+// @Param file formData file false "CSV file of registration IDs"
+// @Param format query string false "Response format (json or csv)"
+// @Param asOfDate query string false "Date to check registration on, for a CSV body (YYYY-MM-DD)"
+// @Success 200 {object} models.GSTBulkResponse
+// @Router /iras/prod/GSTListing/SearchGSTRegisteredBulk [post]
+func (ctrl *GSTController) SearchGSTRegisteredBulk(c *gin.Context) {
+	// Validate headers
+	clientID, ok := ctrl.clientIDFromHeaders(c)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	req, err := ctrl.bindBulkRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GSTBulkResponse{
 			ReturnCode: 40,
 			Info: &models.GSTInfo{
-				Message:     "Missing required headers",
-				MessageCode: 40003,
+				Message:     "Invalid request format",
+				MessageCode: 40004,
 				FieldInfoList: []models.GSTFieldError{
 					{
-						Field:   "headers",
-						Message: "X-IBM-Client-Id and X-IBM-Client-Secret are required",
+						Field:   "body",
+						Message: err.Error(),
 					},
 				},
 			},
@@ -60,10 +136,60 @@ func (ctrl *GSTController) SearchGSTRegistered(c *gin.Context) {
 		return
 	}
 
+	// Override clientID from header if provided
+	if req.ClientID == "" {
+		req.ClientID = clientID
+	}
+
+	// Perform GST search
+	response, err := ctrl.gstService.SearchGSTRegisteredBulk(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.GSTBulkResponse{
+			ReturnCode: 50,
+			Info: &models.GSTInfo{
+				Message:     "Internal server error",
+				MessageCode: 50001,
+			},
+		})
+		return
+	}
+
+	// Return response based on return code
+	switch response.ReturnCode {
+	case 10:
+		if wantsCSV(c) {
+			ctrl.writeBulkCSV(c, response.Data)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	case 40:
+		c.JSON(http.StatusBadRequest, response)
+	default:
+		c.JSON(http.StatusInternalServerError, response)
+	}
+}
+
+// @Summary Search GST Registration Candidates
+// @Description Find GST registrations by GST registration number, UEN or entity name, ranked by match score
+// @Tags GST
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param body body models.GSTCandidateSearchRequest true "GST Candidate Search Request"
+// @Success 200 {object} models.GSTCandidateSearchResponse
+// @Router /iras/prod/GSTListing/SearchGSTCandidates [post]
+func (ctrl *GSTController) SearchGSTCandidates(c *gin.Context) {
+	// Validate headers
+	clientID, ok := ctrl.clientIDFromHeaders(c)
+	if !ok {
+		return
+	}
+
 	// Parse request body
-	var req models.GSTRequest
+	var req models.GSTCandidateSearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.GSTResponse{
+		c.JSON(http.StatusBadRequest, models.GSTCandidateSearchResponse{
 			ReturnCode: 40,
 			Info: &models.GSTInfo{
 				Message:     "Invalid request format",
@@ -84,10 +210,10 @@ func (ctrl *GSTController) SearchGSTRegistered(c *gin.Context) {
 		req.ClientID = clientID
 	}
 
-	// Perform GST search
-	response, err := ctrl.gstService.SearchGSTRegistered(&req)
+	// Perform candidate search
+	response, err := ctrl.gstService.SearchGSTCandidates(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.GSTResponse{
+		c.JSON(http.StatusInternalServerError, models.GSTCandidateSearchResponse{
 			ReturnCode: 50,
 			Info: &models.GSTInfo{
 				Message:     "Internal server error",
@@ -110,6 +236,167 @@ func (ctrl *GSTController) SearchGSTRegistered(c *gin.Context) {
 	}
 }
 
+// clientIDFromHeaders reads the IBM client credentials, writing the error
+// response when they are missing
+func (ctrl *GSTController) clientIDFromHeaders(c *gin.Context) (string, bool) {
+	clientID := c.GetHeader("X-IBM-Client-Id")
+	clientSecret := c.GetHeader("X-IBM-Client-Secret")
+
+	// For development, accept demo credentials
+	if config.AppConfig.Env == "development" {
+		if clientID == "" {
+			clientID = config.AppConfig.IBMClientID
+		}
+		if clientSecret == "" {
+			clientSecret = config.AppConfig.IBMClientSecret
+		}
+	}
+
+	if clientID == "" || clientSecret == "" {
+		c.JSON(http.StatusUnauthorized, models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Missing required headers",
+				MessageCode: 40003,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "headers",
+						Message: "X-IBM-Client-Id and X-IBM-Client-Secret are required",
+					},
+				},
+			},
+		})
+		return "", false
+	}
+
+	return clientID, true
+}
+
+// bindBulkRequest reads the registration IDs of a bulk search from an
+// uploaded CSV file, a CSV body or a JSON body. With CSV the as-of date
+// comes from the form or the query string.
+func (ctrl *GSTController) bindBulkRequest(c *gin.Context) (*models.GSTBulkRequest, error) {
+	var req models.GSTBulkRequest
+
+	switch c.ContentType() {
+	case "multipart/form-data":
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("CSV file is required in the file field")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, errors.New("CSV file could not be read")
+		}
+		defer file.Close()
+
+		req.ClientID = c.PostForm("clientID")
+		req.AsOfDate = c.PostForm("asOfDate")
+		req.RegIDs, err = readRegIDsCSV(file)
+		if err != nil {
+			return nil, err
+		}
+	case "text/csv":
+		regIDs, err := readRegIDsCSV(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.RegIDs = regIDs
+		req.AsOfDate = c.Query("asOfDate")
+	default:
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, errors.New("Invalid JSON format")
+		}
+	}
+
+	return &req, nil
+}
+
+// readRegIDsCSV takes the registration IDs from the first column of a CSV,
+// skipping blank rows and an optional header row
+func readRegIDsCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var regIDs []string
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV format: %v", err)
+		}
+
+		regID := strings.TrimSpace(record[0])
+		if regID == "" {
+			continue
+		}
+		if row == 0 && isRegIDHeader(regID) {
+			continue
+		}
+		regIDs = append(regIDs, regID)
+	}
+
+	return regIDs, nil
+}
+
+// isRegIDHeader reports whether a first CSV cell is a column heading rather
+// than a registration ID
+func isRegIDHeader(cell string) bool {
+	switch strings.ToLower(strings.NewReplacer(" ", "", "_", "").Replace(cell)) {
+	case "regid", "registrationid", "gstregistrationnumber", "uen":
+		return true
+	}
+	return false
+}
+
+// isGSTPeriodError reports whether a registration was refused for dates that
+// cannot be read or that overlap its earlier periods
+func isGSTPeriodError(err error) bool {
+	return errors.Is(err, services.ErrInvalidGSTDate) || errors.Is(err, services.ErrGSTPeriodOverlap)
+}
+
+// wantsCSV reports whether the caller asked for a CSV download
+func wantsCSV(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(c.GetHeader("Accept"), "text/csv")
+}
+
+// writeBulkCSV writes the results of a bulk search as a CSV download, one
+// row per registration ID in the order requested
+func (ctrl *GSTController) writeBulkCSV(c *gin.Context, data *models.GSTBulkData) {
+	c.Header("Content-Disposition", `attachment; filename="gst-registrations.csv"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"regID", "returnCode", "name", "gstRegistrationNumber", "registrationId", "registeredFrom", "registeredTo", "status", "remarks", "message"})
+	for _, result := range data.Results {
+		row := []string{result.RegID, strconv.Itoa(result.ReturnCode), "", "", "", "", "", "", "", ""}
+		if result.Data != nil {
+			row[2] = result.Data.Name
+			row[3] = result.Data.GSTRegistrationNumber
+			row[4] = result.Data.RegistrationID
+			row[5] = result.Data.RegisteredFrom
+			row[6] = result.Data.RegisteredTo
+			row[7] = result.Data.Status
+			row[8] = result.Data.Remarks
+		}
+		if result.Info != nil {
+			row[9] = result.Info.Message
+			if len(result.Info.FieldInfoList) > 0 {
+				row[9] = result.Info.FieldInfoList[0].Message
+			}
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+}
+
 // Admin endpoints for managing GST registrations (for setup/maintenance)
 
 // @Summary Create GST Registration
@@ -131,6 +418,13 @@ func (ctrl *GSTController) CreateGSTRegistration(c *gin.Context) {
 	}
 
 	if err := ctrl.gstService.CreateGSTRegistration(&gstReg); err != nil {
+		if isGSTPeriodError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid registration dates",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create GST registration",
 			"details": err.Error(),
@@ -209,6 +503,176 @@ func (ctrl *GSTController) GetGSTRegistration(c *gin.Context) {
 	c.JSON(http.StatusOK, gstReg)
 }
 
+// @Summary Get GST Registration History
+// @Description Get the registration periods of a GST registration, earliest first (Admin only)
+// @Tags GST Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "GST Registration ID"
+// @Success 200 {array} models.GSTRegistrationPeriod
+// @Router /admin/gst-registrations/{id}/history [get]
+func (ctrl *GSTController) GetGSTRegistrationHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid GST registration ID",
+		})
+		return
+	}
+
+	gstReg, err := ctrl.gstService.GetGSTRegistrationByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "GST registration not found",
+		})
+		return
+	}
+
+	periods, err := ctrl.gstService.GetGSTRegistrationHistory(gstReg.RegistrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get GST registration history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"registration_id": gstReg.RegistrationID,
+		"periods":         periods,
+	})
+}
+
+// @Summary Import GST Listing
+// @Description Import a GST registered business listing file (CSV or fixed-width), upserting registrations by registration ID and deregistering those no longer listed (Admin only)
+// @Tags GST Admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "GST listing file"
+// @Param format formData string false "Listing format (csv or fixed), defaults from the file extension"
+// @Param clientID formData string false "Client the registrations belong to"
+// @Param listingDate formData string false "Date of the listing (YYYY-MM-DD), defaults to today"
+// @Success 201 {object} models.GSTImportRun
+// @Router /admin/gst-registrations/import [post]
+func (ctrl *GSTController) ImportGSTListing(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Listing file is required",
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read listing file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = services.GSTListingFormatForFile(fileHeader.Filename)
+	}
+	clientID := c.PostForm("clientID")
+	if clientID == "" {
+		clientID = config.AppConfig.IBMClientID
+	}
+
+	run, err := ctrl.gstImportService.ImportListing(clientID, fileHeader.Filename, format, c.PostForm("listingDate"), file)
+	if err != nil {
+		// Problems with the listing are the caller's to fix; anything else
+		// is a failure storing it
+		status := http.StatusInternalServerError
+		var listingErr *services.GSTListingError
+		if errors.As(err, &listingErr) {
+			status = http.StatusUnprocessableEntity
+		}
+
+		response := gin.H{
+			"error":   "Failed to import GST listing",
+			"details": err.Error(),
+		}
+		if run != nil {
+			response["run"] = run
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// @Summary Get GST Import Runs
+// @Description Get GST listing import runs, most recent first, with pagination (Admin only)
+// @Tags GST Admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {array} models.GSTImportRun
+// @Router /admin/gst-registrations/imports [get]
+func (ctrl *GSTController) GetGSTImportRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	runs, total, err := ctrl.gstImportService.GetImportRuns(page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get GST import runs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        runs,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": totalPages,
+	})
+}
+
+// @Summary Get GST Import Run by ID
+// @Description Get a single GST listing import run by ID (Admin only)
+// @Tags GST Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "GST Import Run ID"
+// @Success 200 {object} models.GSTImportRun
+// @Router /admin/gst-registrations/imports/{id} [get]
+func (ctrl *GSTController) GetGSTImportRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid GST import run ID",
+		})
+		return
+	}
+
+	run, err := ctrl.gstImportService.GetImportRunByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "GST import run not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
 // @Summary Update GST Registration
 // @Description Update GST registration by ID (Admin only)
 // @Tags GST Admin
@@ -236,6 +700,13 @@ func (ctrl *GSTController) UpdateGSTRegistration(c *gin.Context) {
 	}
 
 	if err := ctrl.gstService.UpdateGSTRegistration(uint(id), updates); err != nil {
+		if isGSTPeriodError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid registration dates",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update GST registration",
 		})
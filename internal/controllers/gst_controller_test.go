@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRegIDsCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []string
+		wantErr bool
+	}{
+		{"plain list", "201912345R\n53312345C\n", []string{"201912345R", "53312345C"}, false},
+		{"header row skipped", "regID\n201912345R\n", []string{"201912345R"}, false},
+		{"header with other columns", "Registration ID,Name\n201912345R,Alpha Pte Ltd\n", []string{"201912345R"}, false},
+		{"blank rows and spaces", "\n  201912345R \n,\n53312345C", []string{"201912345R", "53312345C"}, false},
+		{"header only on the first row", "201912345R\nUEN\n", []string{"201912345R", "UEN"}, false},
+		{"invalid IDs kept for the search to report", "not-an-id\n", []string{"not-an-id"}, false},
+		{"empty file", "", nil, false},
+		{"malformed CSV", "\"201912345R\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRegIDsCSV(strings.NewReader(tt.csv))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readRegIDsCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRegIDHeader(t *testing.T) {
+	tests := map[string]bool{
+		"regID":                   true,
+		"REG_ID":                  true,
+		"Registration ID":         true,
+		"gst_registration_number": true,
+		"uen":                     true,
+		"201912345R":              false,
+		"name":                    false,
+	}
+	for cell, want := range tests {
+		if got := isRegIDHeader(cell); got != want {
+			t.Errorf("isRegIDHeader(%q) = %v, want %v", cell, got, want)
+		}
+	}
+}
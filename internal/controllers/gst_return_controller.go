@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"api-iras/internal/config"
+	"api-iras/internal/models"
+	"api-iras/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GSTReturnController struct {
+	gstReturnService   *services.GSTReturnService
+	accessTokenService *services.AccessTokenService
+	validator          *services.RequestValidator
+}
+
+func NewGSTReturnController(gstReturnService *services.GSTReturnService, accessTokenService *services.AccessTokenService) *GSTReturnController {
+	return &GSTReturnController{
+		gstReturnService:   gstReturnService,
+		accessTokenService: accessTokenService,
+		validator:          services.NewRequestValidator(),
+	}
+}
+
+// @Summary Submit GST F5 Return
+// @Description File a GST F5 return for an accounting period. Requires a CorpPass or SingPass access token carrying the GSTReturnsSub scope.
+// @Tags GST Returns
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass or SingPass Access Token"
+// @Param body body models.GSTReturnRequest true "GST F5 Return"
+// @Success 200 {object} models.GSTReturnResponse
+// @Router /iras/sb/gst/submitF5Return [post]
+func (ctrl *GSTReturnController) SubmitF5Return(c *gin.Context) {
+	ctrl.submitReturn(c, services.GSTFormTypeF5)
+}
+
+// @Summary Submit GST F8 Return
+// @Description File the final GST F8 return of a deregistered business, for the period ending on the deregistration date. Requires a CorpPass or SingPass access token carrying the GSTReturnsSub scope.
+// @Tags GST Returns
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass or SingPass Access Token"
+// @Param body body models.GSTReturnRequest true "GST F8 Return"
+// @Success 200 {object} models.GSTReturnResponse
+// @Router /iras/sb/gst/submitF8Return [post]
+func (ctrl *GSTReturnController) SubmitF8Return(c *gin.Context) {
+	ctrl.submitReturn(c, services.GSTFormTypeF8)
+}
+
+// submitReturn checks the headers and token, then validates and files a
+// return of the given form type
+func (ctrl *GSTReturnController) submitReturn(c *gin.Context, formType string) {
+	clientID, entityID, ok := authorizeGSTSubmission(c, ctrl.accessTokenService, services.ScopeGSTReturnsSub)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req models.GSTReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.GSTReturnResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid request format",
+				MessageCode: 40004,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "body",
+						Message: "Invalid JSON format",
+					},
+				},
+			},
+		})
+		return
+	}
+
+	// Validate the request structure
+	if fieldErrors := ctrl.validator.Validate(&req); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, models.GSTReturnResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:       "Invalid GST return",
+				MessageCode:   40006,
+				FieldInfoList: gstFieldErrors(fieldErrors),
+			},
+		})
+		return
+	}
+
+	// The return is filed for the authenticated client and token entity,
+	// whatever the body says
+	req.FilingInfo.ClientID = clientID
+	req.FilingInfo.EntityID = entityID
+
+	response, err := ctrl.gstReturnService.SubmitReturn(formType, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	switch response.ReturnCode {
+	case 10:
+		c.JSON(http.StatusOK, response)
+	case 40:
+		c.JSON(http.StatusBadRequest, response)
+	default:
+		c.JSON(http.StatusInternalServerError, response)
+	}
+}
+
+// authorizeGSTSubmission checks the client headers and that the access
+// token carries the scope a GST submission requires, writing the error
+// response when it does not. It returns the client ID and the UEN a
+// CorpPass token acts for, empty for SingPass tokens and the demo token,
+// which is accepted in development.
+func authorizeGSTSubmission(c *gin.Context, accessTokenService *services.AccessTokenService, scope string) (string, string, bool) {
+	clientID := c.GetHeader("X-IBM-Client-Id")
+	clientSecret := c.GetHeader("X-IBM-Client-Secret")
+	accessToken := c.GetHeader("access_token")
+
+	// For development, accept demo credentials
+	demoToken := false
+	if config.AppConfig.Env == "development" {
+		if clientID == "" {
+			clientID = config.AppConfig.IBMClientID
+		}
+		if clientSecret == "" {
+			clientSecret = config.AppConfig.IBMClientSecret
+		}
+		if accessToken == "" {
+			accessToken = "demo_access_token_123456"
+		}
+		demoToken = accessToken == "demo_access_token_123456"
+	}
+
+	if clientID == "" || clientSecret == "" {
+		c.JSON(http.StatusUnauthorized, models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Missing required headers",
+				MessageCode: 40003,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "headers",
+						Message: "X-IBM-Client-Id and X-IBM-Client-Secret are required",
+					},
+				},
+			},
+		})
+		return "", "", false
+	}
+
+	if accessToken == "" {
+		c.JSON(http.StatusUnauthorized, models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Missing access token",
+				MessageCode: 40003,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "access_token",
+						Message: "CorpPass or SingPass access token is required",
+					},
+				},
+			},
+		})
+		return "", "", false
+	}
+
+	if demoToken {
+		return clientID, "", true
+	}
+
+	entityID, err := accessTokenService.CheckScope(clientID, accessToken, scope)
+	switch {
+	case err == nil:
+		return clientID, entityID, true
+	case errors.Is(err, services.ErrAccessTokenInvalid), errors.Is(err, services.ErrAccessTokenExpired):
+		c.JSON(http.StatusUnauthorized, models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid access token",
+				MessageCode: 40003,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "access_token",
+						Message: err.Error(),
+					},
+				},
+			},
+		})
+	case errors.Is(err, services.ErrScopeNotGranted):
+		c.JSON(http.StatusForbidden, models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Insufficient scope",
+				MessageCode: 40003,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "access_token",
+						Message: "Access token does not carry the " + scope + " scope",
+					},
+				},
+			},
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.GSTResponse{
+			ReturnCode: 50,
+			Info: &models.GSTInfo{
+				Message:     "Internal server error",
+				MessageCode: 50001,
+			},
+		})
+	}
+	return "", "", false
+}
+
+// gstFieldErrors reports request validation errors in the GST response
+// format
+func gstFieldErrors(fieldErrors services.DutyFieldErrors) []models.GSTFieldError {
+	result := make([]models.GSTFieldError, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		result = append(result, models.GSTFieldError{Field: fieldError.Field, Message: fieldError.Message})
+	}
+	return result
+}
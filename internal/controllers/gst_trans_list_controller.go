@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"api-iras/internal/models"
+	"api-iras/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GSTTransListController struct {
+	gstTransListService *services.GSTTransListService
+	accessTokenService  *services.AccessTokenService
+	validator           *services.RequestValidator
+}
+
+func NewGSTTransListController(gstTransListService *services.GSTTransListService, accessTokenService *services.AccessTokenService) *GSTTransListController {
+	return &GSTTransListController{
+		gstTransListService: gstTransListService,
+		accessTokenService:  accessTokenService,
+		validator:           services.NewRequestValidator(),
+	}
+}
+
+// @Summary Submit GST Transaction Listing
+// @Description Submit the supply and purchase listings behind a GST return for an accounting period. Each line's GST number is checked against the stored GST registrations and reported with its own errors. Requires a CorpPass or SingPass access token carrying the GSTTransListSub scope.
+// @Tags GST Returns
+// @Accept json
+// @Produce json
+// @Param X-IBM-Client-Id header string true "Client ID"
+// @Param X-IBM-Client-Secret header string true "Client Secret"
+// @Param access_token header string true "CorpPass or SingPass Access Token"
+// @Param body body models.GSTTransListRequest true "GST Transaction Listing"
+// @Success 200 {object} models.GSTTransListResponse
+// @Router /iras/sb/gst/submitTransactionListing [post]
+func (ctrl *GSTTransListController) SubmitTransactionListing(c *gin.Context) {
+	clientID, entityID, ok := authorizeGSTSubmission(c, ctrl.accessTokenService, services.ScopeGSTTransListSub)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req models.GSTTransListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.GSTTransListResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid request format",
+				MessageCode: 40004,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "body",
+						Message: "Invalid JSON format",
+					},
+				},
+			},
+		})
+		return
+	}
+
+	// Validate the request structure
+	if fieldErrors := ctrl.validator.Validate(&req); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, models.GSTTransListResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:       "Invalid transaction listing",
+				MessageCode:   40006,
+				FieldInfoList: gstFieldErrors(fieldErrors),
+			},
+		})
+		return
+	}
+
+	// The listing is filed for the authenticated client and token entity,
+	// whatever the body says
+	req.FilingInfo.ClientID = clientID
+	req.FilingInfo.EntityID = entityID
+
+	response, err := ctrl.gstTransListService.SubmitTransactionListing(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	switch response.ReturnCode {
+	case 10:
+		c.JSON(http.StatusOK, response)
+	case 40:
+		c.JSON(http.StatusBadRequest, response)
+	default:
+		c.JSON(http.StatusInternalServerError, response)
+	}
+}
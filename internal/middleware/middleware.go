@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"api-iras/internal/config"
+	"api-iras/internal/services"
 	"api-iras/pkg/utils"
 	"fmt"
 	"net/http"
@@ -9,14 +10,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
-var validate *validator.Validate
-
-func init() {
-	validate = validator.New()
-}
+var requestValidator = services.NewRequestValidator()
 
 // CORS middleware
 func CORS() gin.HandlerFunc {
@@ -114,10 +110,10 @@ func ValidateJSON(obj interface{}) gin.HandlerFunc {
 		}
 
 		// Validate struct
-		if err := validate.Struct(obj); err != nil {
+		if fieldErrors := requestValidator.Validate(obj); len(fieldErrors) > 0 {
 			var errors []string
-			for _, err := range err.(validator.ValidationErrors) {
-				errors = append(errors, err.Error())
+			for _, fieldError := range fieldErrors {
+				errors = append(errors, fieldError.Field+": "+fieldError.Message)
 			}
 			c.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
@@ -27,6 +27,49 @@ type GSTRegistration struct {
 	Remarks               string `json:"remarks" gorm:"type:text"`
 }
 
+// GSTRegistrationPeriod is one period a registration was in force. Periods
+// are kept when a registration is deregistered or registered again, so a
+// registration can be checked as of any date. Dates are YYYY-MM-DD and an
+// open period has no RegisteredTo.
+type GSTRegistrationPeriod struct {
+	BaseModel
+	RegistrationID        string `json:"registration_id" gorm:"not null;index:idx_gst_period_registration_from,priority:1"`
+	GSTRegistrationNumber string `json:"gst_registration_number"`
+	RegisteredFrom        string `json:"registered_from" gorm:"not null;index:idx_gst_period_registration_from,priority:2"`
+	RegisteredTo          string `json:"registered_to"`
+	Status                string `json:"status"`
+	Remarks               string `json:"remarks" gorm:"type:text"`
+}
+
+// GSTImportRun records one import of the GST registered business listing,
+// showing when the registrations were last refreshed and what changed
+type GSTImportRun struct {
+	BaseModel
+	ClientID              string     `json:"client_id" gorm:"not null;index"`
+	Source                string     `json:"source"`
+	Format                string     `json:"format"`
+	ListingDate           string     `json:"listing_date"`
+	Status                string     `json:"status" gorm:"index"`
+	TotalRows             int        `json:"total_rows"`
+	Inserted              int        `json:"inserted"`
+	Updated               int        `json:"updated"`
+	Unchanged             int        `json:"unchanged"`
+	Deregistered          int        `json:"deregistered"`
+	Rejected              int        `json:"rejected"`
+	DeregistrationSkipped bool       `json:"deregistration_skipped"` // too many rows rejected to deregister unlisted registrations
+	ErrorMessage          string     `json:"error_message,omitempty" gorm:"type:text"`
+	LineErrors            string     `json:"line_errors,omitempty" gorm:"type:text"` // JSON array of GSTImportLineError
+	StartedAt             time.Time  `json:"started_at"`
+	FinishedAt            *time.Time `json:"finished_at"`
+}
+
+// GSTImportLineError is a listing row that could not be imported
+type GSTImportLineError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // GST Response models based on IRAS API spec
 type GSTResponse struct {
 	ReturnCode int      `json:"returnCode"`
@@ -59,6 +102,70 @@ type GSTFieldError struct {
 type GSTRequest struct {
 	ClientID string `json:"clientID" validate:"required"`
 	RegID    string `json:"regID" validate:"required"`
+	AsOfDate string `json:"asOfDate,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// GST candidate search models for finding registrations by GST number, UEN
+// or entity name
+type GSTCandidateSearchRequest struct {
+	ClientID string `json:"clientID" validate:"required"`
+	Query    string `json:"query" validate:"required"`
+	SearchBy string `json:"searchBy,omitempty" validate:"omitempty,oneof=gstNumber uen name"`
+	Page     int    `json:"page,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+type GSTCandidateSearchResponse struct {
+	ReturnCode int                     `json:"returnCode"`
+	Data       *GSTCandidateSearchData `json:"data,omitempty"`
+	Info       *GSTInfo                `json:"info,omitempty"`
+}
+
+type GSTCandidateSearchData struct {
+	SearchBy   string         `json:"searchBy"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"totalPages"`
+	Candidates []GSTCandidate `json:"candidates"`
+}
+
+// GSTCandidate is a registration matching a candidate search, scored from
+// 0 to 1 by how closely it matches
+type GSTCandidate struct {
+	GSTData
+	Score     float64 `json:"score"`
+	MatchedOn string  `json:"matchedOn"`
+}
+
+// GST bulk search models for checking many registration IDs in one call
+type GSTBulkRequest struct {
+	ClientID string   `json:"clientID" validate:"required"`
+	RegIDs   []string `json:"regIDs" validate:"required,min=1"`
+	AsOfDate string   `json:"asOfDate,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type GSTBulkResponse struct {
+	ReturnCode int          `json:"returnCode"`
+	Data       *GSTBulkData `json:"data,omitempty"`
+	Info       *GSTInfo     `json:"info,omitempty"`
+}
+
+type GSTBulkData struct {
+	Total    int             `json:"total"`
+	Found    int             `json:"found"`
+	NotFound int             `json:"notFound"`
+	Invalid  int             `json:"invalid"`
+	Results  []GSTBulkResult `json:"results"`
+}
+
+// GSTBulkResult is the outcome for one registration ID, carrying the same
+// return code, data and info as a single GST search
+type GSTBulkResult struct {
+	RegID      string   `json:"regID"`
+	ReturnCode int      `json:"returnCode"`
+	Data       *GSTData `json:"data,omitempty"`
+	Info       *GSTInfo `json:"info,omitempty"`
 }
 
 // User model for authentication
@@ -124,7 +231,8 @@ type CorpPassAuthResponse struct {
 }
 
 type CorpPassAuthData struct {
-	URL string `json:"url"`
+	URL   string `json:"url"`
+	State string `json:"state"`
 }
 
 type CorpPassAuthInfo struct {
@@ -138,9 +246,11 @@ type CorpPassFieldError struct {
 	Message string `json:"message"`
 }
 
-// CorpPass Token Request/Response models
+// CorpPass Token Request/Response models. The token takes its scope and
+// entity from the authentication request the state was issued for.
 type CorpPassTokenRequest struct {
-	ID int64 `json:"id" validate:"required"`
+	ID    int64  `json:"id" validate:"required"`
+	State string `json:"state" validate:"required"`
 }
 
 type CorpPassTokenResponse struct {
@@ -154,6 +264,35 @@ type CorpPassTokenData struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// CorpPassAuthRecord stores a CorpPass authentication request until its
+// state is exchanged for a token
+type CorpPassAuthRecord struct {
+	BaseModel
+	State       string `json:"state" gorm:"not null;uniqueIndex"`
+	ClientID    string `json:"client_id" gorm:"not null;index"`
+	EntityID    string `json:"entity_id" gorm:"not null"` // UEN of the entity the CorpPass user acts for
+	Scope       string `json:"scope"`
+	CallbackURL string `json:"callback_url" gorm:"type:text"`
+	TaxAgent    bool   `json:"tax_agent"`
+	Status      string `json:"status" gorm:"default:pending"`
+}
+
+// CorpPassTokenRecord stores an issued CorpPass access token with the
+// scopes it grants and the client and entity it was issued to
+type CorpPassTokenRecord struct {
+	BaseModel
+	CorpPassID   int64  `json:"corppass_id" gorm:"not null;index"`
+	ClientID     string `json:"client_id" gorm:"index"`
+	EntityID     string `json:"entity_id"`
+	AccessToken  string `json:"access_token" gorm:"not null;uniqueIndex"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token" gorm:"type:text"`
+	Scope        string `json:"scope"`
+	Status       string `json:"status" gorm:"default:active"`
 }
 
 // eStamp models based on IRAS API spec
@@ -164,12 +303,75 @@ type EStampResponse struct {
 }
 
 type EStampData struct {
-	DocRefNo        string `json:"docRefNo"`
-	SDAmount        string `json:"sdAmount"`
-	SDPenalty       string `json:"sdPenalty"`
-	TotalAmtPayable string `json:"totalAmtPayable"`
-	PaymentDueDate  string `json:"paymentDueDate"`
-	PDFBase64       string `json:"pdfBase64"`
+	DocRefNo        string               `json:"docRefNo"`
+	StampCertRef    string               `json:"stampCertRef,omitempty"`
+	QRPayload       string               `json:"qrPayload,omitempty"`
+	SDAmount        string               `json:"sdAmount"`
+	SDRemission     string               `json:"sdRemission,omitempty"`
+	SDPenalty       string               `json:"sdPenalty"`
+	TotalAmtPayable string               `json:"totalAmtPayable"`
+	PaymentDueDate  string               `json:"paymentDueDate"`
+	PDFBase64       string               `json:"pdfBase64"`
+	DutyBreakdown   *EStampDutyBreakdown `json:"dutyBreakdown,omitempty"`
+}
+
+// EStampQuoteResponse prices an eStamp document without stamping it
+type EStampQuoteResponse struct {
+	ReturnCode int              `json:"returnCode"`
+	Data       *EStampQuoteData `json:"data,omitempty"`
+	Info       *EStampInfo      `json:"info,omitempty"`
+}
+
+type EStampQuoteData struct {
+	SDAmount        string               `json:"sdAmount"`
+	SDRemission     string               `json:"sdRemission,omitempty"`
+	SDPenalty       string               `json:"sdPenalty"`
+	TotalAmtPayable string               `json:"totalAmtPayable"`
+	PaymentDueDate  string               `json:"paymentDueDate"`
+	DutyBreakdown   *EStampDutyBreakdown `json:"dutyBreakdown"`
+	Warnings        []string             `json:"warnings,omitempty"`
+}
+
+// Stamp duty breakdown returned alongside eStamp results
+type EStampDutyBreakdown struct {
+	RateVersion    string                `json:"rateVersion"`
+	DocumentDate   string                `json:"documentDate"`
+	DutiableAmount float64               `json:"dutiableAmount"`
+	Components     []EStampDutyComponent `json:"components"`
+	TotalDuty      float64               `json:"totalDuty"`
+	Penalty        *EStampPenalty        `json:"penalty,omitempty"`
+}
+
+// Late stamping penalty on an eStamp document
+type EStampPenalty struct {
+	StampingDeadline string  `json:"stampingDeadline"`
+	DaysLate         int     `json:"daysLate"`
+	Tier             string  `json:"tier,omitempty"`
+	Amount           float64 `json:"amount"`
+}
+
+type EStampDutyComponent struct {
+	DutyType       string           `json:"dutyType"`
+	Schedule       string           `json:"schedule,omitempty"`
+	DutiableAmount float64          `json:"dutiableAmount"`
+	Rate           float64          `json:"rate,omitempty"`
+	Bands          []EStampDutyBand `json:"bands,omitempty"`
+	Duty           float64          `json:"duty"`
+	Profile        string           `json:"profile,omitempty"`
+	AssetType      string           `json:"assetType,omitempty"`
+	AssetSequence  int              `json:"assetSequence,omitempty"`
+	PartySequence  int              `json:"partySequence,omitempty"`
+	PartyName      string           `json:"partyName,omitempty"`
+	RemissionType  string           `json:"remissionType,omitempty"`
+	Remarks        string           `json:"remarks,omitempty"`
+}
+
+type EStampDutyBand struct {
+	From          float64 `json:"from"`
+	To            float64 `json:"to,omitempty"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxableAmount"`
+	Duty          float64 `json:"duty"`
 }
 
 type EStampInfo struct {
@@ -183,6 +385,43 @@ type EStampFieldError struct {
 	Message string `json:"message"`
 }
 
+// EStampRecord is a stamped eStamp document and its stamp certificate
+type EStampRecord struct {
+	BaseModel
+	DocRefNo           string     `json:"doc_ref_no" gorm:"uniqueIndex;not null"`
+	DocRefNumber       int64      `json:"doc_ref_number" gorm:"index;not null"` // numeric part of DocRefNo, as quoted on the certificate
+	StampCertRef       string     `json:"stamp_cert_ref" gorm:"uniqueIndex;not null"`
+	DocumentType       string     `json:"document_type" gorm:"not null;index"`
+	DocDescription     string     `json:"doc_description"`
+	AssignID           string     `json:"assign_id"`
+	DateOfDocument     string     `json:"date_of_document"`
+	SDAmount           float64    `json:"sd_amount"`
+	SDRemission        float64    `json:"sd_remission"`
+	SDPenalty          float64    `json:"sd_penalty"`
+	TotalAmtPayable    float64    `json:"total_amt_payable"`
+	PaymentDueDate     string     `json:"payment_due_date"`
+	StampCertIssueDate time.Time  `json:"stamp_cert_issue_date"`
+	QRPayload          string     `json:"qr_payload" gorm:"type:text"` // signed certificate payload embedded in the certificate
+	RevokedAt          *time.Time `json:"revoked_at"`
+	RevokedBy          string     `json:"revoked_by"`
+	RevocationReason   string     `json:"revocation_reason" gorm:"type:text"`
+	RequestData        string     `json:"request_data" gorm:"type:text"`        // JSON serialized eStamp request
+	DutyBreakdownData  string     `json:"duty_breakdown_data" gorm:"type:text"` // JSON serialized EStampDutyBreakdown
+	PartiesData        string     `json:"parties_data" gorm:"type:text"`        // JSON serialized []EStampRecordParty
+	AssetsData         string     `json:"assets_data" gorm:"type:text"`         // JSON serialized AssetsData
+	TrustDetailsData   string     `json:"trust_details_data" gorm:"type:text"`  // JSON serialized TrusteeBeneficiaryData, for property bought on trust
+}
+
+// EStampRecordParty is a party to a stamped document
+type EStampRecordParty struct {
+	Role            string `json:"role"`
+	Sequence        int    `json:"sequence"`
+	TypeOfProfile   int    `json:"typeOfProfile"`
+	TaxEntityIDType int    `json:"taxEntityIdType"`
+	TaxEntityIDNo   string `json:"taxEntityIdNo"`
+	Name            string `json:"name"`
+}
+
 // Simplified eStamp request models (for demo purposes)
 type StampTenancyAgreementRequest struct {
 	AssignID                string                    `json:"assignId" validate:"required"`
@@ -193,14 +432,14 @@ type StampTenancyAgreementRequest struct {
 	ModeOfOffer             int                       `json:"modeOfOffer"`
 	ModeOfAcceptance        int                       `json:"modeOfAcceptance"`
 	IsSignedInSingapore     bool                      `json:"isSignedInSingapore"`
-	DateOfDocument          string                    `json:"dateOfDocument"`
-	ReceivingDateOfDocument string                    `json:"receivingDateOfDocument"`
+	DateOfDocument          string                    `json:"dateOfDocument" validate:"omitempty,isodate"`
+	ReceivingDateOfDocument string                    `json:"receivingDateOfDocument" validate:"omitempty,isodate"`
 	Submission              SubmissionData            `json:"submission"`
 	Assets                  AssetsData                `json:"assets"`
-	AssessmentRental        []AssessmentRentalData    `json:"assessmentRental"`
-	LandlordLessor          []PartyData               `json:"landlordLessor"`
-	TenantLessee            []PartyData               `json:"tenantLessee"`
-	AssessmentRemissions    []AssessmentRemissionData `json:"assessmentRemissions"`
+	AssessmentRental        []AssessmentRentalData    `json:"assessmentRental" validate:"dive"`
+	LandlordLessor          []PartyData               `json:"landlordLessor" validate:"dive"`
+	TenantLessee            []PartyData               `json:"tenantLessee" validate:"dive"`
+	AssessmentRemissions    []AssessmentRemissionData `json:"assessmentRemissions" validate:"dive"`
 }
 
 type ShareTransferRequest struct {
@@ -212,53 +451,54 @@ type ShareTransferRequest struct {
 	ModeOfOffer                                     int                       `json:"modeOfOffer"`
 	ModeOfAcceptance                                int                       `json:"modeOfAcceptance"`
 	IsSignedInSingapore                             bool                      `json:"isSignedInSingapore"`
-	DateOfDocument                                  string                    `json:"dateOfDocument"`
-	ReceivingDateOfDocument                         string                    `json:"receivingDateOfDocument"`
+	DateOfDocument                                  string                    `json:"dateOfDocument" validate:"omitempty,isodate"`
+	ReceivingDateOfDocument                         string                    `json:"receivingDateOfDocument" validate:"omitempty,isodate"`
 	Submission                                      SubmissionData            `json:"submission"`
 	Assets                                          AssetsData                `json:"assets"`
-	ConsiderationAmount                             float64                   `json:"considerationAmount"`
+	ConsiderationAmount                             float64                   `json:"considerationAmount" validate:"gte=0"`
 	HasIntentToHoldTheSharesTrustForBeneficialOwner bool                      `json:"hasIntentToHoldTheSharesTrustForBeneficialOwner"`
-	Transferor                                      []PartyData               `json:"transferor"`
-	Transferee                                      []PartyData               `json:"transferee"`
+	Transferor                                      []PartyData               `json:"transferor" validate:"dive"`
+	Transferee                                      []PartyData               `json:"transferee" validate:"dive"`
 	TargetCompany                                   TargetCompanyData         `json:"targetCompany"`
-	AssessmentRemissions                            []AssessmentRemissionData `json:"assessmentRemissions"`
+	AssessmentRemissions                            []AssessmentRemissionData `json:"assessmentRemissions" validate:"dive"`
 }
 
 // Supporting data structures (simplified for demo)
 type SubmissionData struct {
-	Declaration bool `json:"declaration"`
+	Declaration bool `json:"declaration" validate:"eq=true"`
 }
 
 type AssetsData struct {
-	Properties   []PropertyData   `json:"properties"`
-	Lands        []LandData       `json:"lands"`
-	StocksShares []StockShareData `json:"stocksShares"`
-	Security     []SecurityData   `json:"security"`
+	Properties   []PropertyData   `json:"properties" validate:"dive"`
+	Lands        []LandData       `json:"lands" validate:"dive"`
+	StocksShares []StockShareData `json:"stocksShares" validate:"dive"`
+	Security     []SecurityData   `json:"security" validate:"dive"`
 }
 
 type PropertyData struct {
 	Sequence                              int                     `json:"sequence"`
-	PostalCode                            string                  `json:"postalCode"`
+	PostalCode                            string                  `json:"postalCode" validate:"omitempty,sgpostal"`
 	StreetName                            string                  `json:"streetName"`
 	BlockNo                               string                  `json:"blockNo"`
-	LevelUnits                            []LevelUnitData         `json:"levelUnits,omitempty"`
+	LevelUnits                            []LevelUnitData         `json:"levelUnits,omitempty" validate:"dive"`
 	ShareOfPropertyTransferred            int                     `json:"shareOfPropertyTransferred,omitempty"`
 	MannerOfHolding                       int                     `json:"mannerOfHolding,omitempty"`
-	FractionNumerator                     int                     `json:"fractionNumerator,omitempty"`
-	FractionDenominator                   int                     `json:"fractionDenominator,omitempty"`
-	PropertyOwnerships                    []PropertyOwnershipData `json:"propertyOwnerships,omitempty"`
+	FractionNumerator                     int                     `json:"fractionNumerator,omitempty" validate:"gte=0"`
+	FractionDenominator                   int                     `json:"fractionDenominator,omitempty" validate:"gte=0"`
+	PropertyOwnerships                    []PropertyOwnershipData `json:"propertyOwnerships,omitempty" validate:"dive"`
 	PropertyType                          int                     `json:"propertyType"`
-	BuyingPriceMarketValueResidential     float64                 `json:"buyingPriceMarketValueResidential"`
-	BuyingPriceMarketValueNonResidential  float64                 `json:"buyingPriceMarketValueNonResidential"`
-	SellingPriceMarketValueResidential    float64                 `json:"sellingPriceMarketValueResidential,omitempty"`
-	SellingPriceMarketValueNonResidential float64                 `json:"sellingPriceMarketValueNonResidential,omitempty"`
+	BuyingPriceMarketValueResidential     float64                 `json:"buyingPriceMarketValueResidential" validate:"gte=0"`
+	BuyingPriceMarketValueNonResidential  float64                 `json:"buyingPriceMarketValueNonResidential" validate:"gte=0"`
+	SellingPriceMarketValueResidential    float64                 `json:"sellingPriceMarketValueResidential,omitempty" validate:"gte=0"`
+	SellingPriceMarketValueNonResidential float64                 `json:"sellingPriceMarketValueNonResidential,omitempty" validate:"gte=0"`
 	IsWhollyRented                        bool                    `json:"isWhollyRented,omitempty"`
-	TotalFloorArea                        float64                 `json:"totalFloorArea"`
+	TotalFloorArea                        float64                 `json:"totalFloorArea" validate:"gte=0"`
 	FloorAreaMeasurementType              int                     `json:"floorAreaMeasurementType,omitempty"`
 	TotalFloorAreaNotAvailable            bool                    `json:"totalFloorAreaNotAvailable,omitempty"`
 	ValuationType                         int                     `json:"valuationType"`
-	ValuationValue                        float64                 `json:"valuationValue"`
-	AbsdRate                              float64                 `json:"absdRate,omitempty"`
+	ValuationValue                        float64                 `json:"valuationValue" validate:"gte=0"`
+	AbsdRate                              float64                 `json:"absdRate,omitempty" validate:"gte=0"`
+	DateOfAcquisition                     string                  `json:"dateOfAcquisition,omitempty" validate:"omitempty,isodate"`
 }
 
 type LandData struct {
@@ -269,18 +509,19 @@ type LandData struct {
 	FTLotNo                               string              `json:"ftLotNo,omitempty"`
 	FTPlOrPtParcelNo                      string              `json:"ftPlOrPtParcelNo,omitempty"`
 	ShareOfLandTransferred                int                 `json:"shareOfLandTransferred,omitempty"`
-	FractionNumerator                     int                 `json:"fractionNumerator,omitempty"`
-	FractionDenominator                   int                 `json:"fractionDenominator,omitempty"`
+	FractionNumerator                     int                 `json:"fractionNumerator,omitempty" validate:"gte=0"`
+	FractionDenominator                   int                 `json:"fractionDenominator,omitempty" validate:"gte=0"`
 	MannerOfHolding                       int                 `json:"mannerOfHolding,omitempty"`
 	MasterPlanZoning                      int                 `json:"masterPlanZoning,omitempty"`
-	BuyingPriceMarketValueResidential     float64             `json:"buyingPriceMarketValueResidential"`
-	BuyingPriceMarketValueNonResidential  float64             `json:"buyingPriceMarketValueNonResidential"`
-	SellingPriceMarketValueResidential    float64             `json:"sellingPriceMarketValueResidential,omitempty"`
-	SellingPriceMarketValueNonResidential float64             `json:"sellingPriceMarketValueNonResidential,omitempty"`
-	LandOwnership                         []LandOwnershipData `json:"landOwnership,omitempty"`
+	BuyingPriceMarketValueResidential     float64             `json:"buyingPriceMarketValueResidential" validate:"gte=0"`
+	BuyingPriceMarketValueNonResidential  float64             `json:"buyingPriceMarketValueNonResidential" validate:"gte=0"`
+	SellingPriceMarketValueResidential    float64             `json:"sellingPriceMarketValueResidential,omitempty" validate:"gte=0"`
+	SellingPriceMarketValueNonResidential float64             `json:"sellingPriceMarketValueNonResidential,omitempty" validate:"gte=0"`
+	LandOwnership                         []LandOwnershipData `json:"landOwnership,omitempty" validate:"dive"`
 	ValuationType                         int                 `json:"valuationType"`
-	ValuationValue                        float64             `json:"valuationValue"`
-	AbsdRate                              float64             `json:"absdRate,omitempty"`
+	ValuationValue                        float64             `json:"valuationValue" validate:"gte=0"`
+	AbsdRate                              float64             `json:"absdRate,omitempty" validate:"gte=0"`
+	DateOfAcquisition                     string              `json:"dateOfAcquisition,omitempty" validate:"omitempty,isodate"`
 }
 
 type StockShareData struct {
@@ -288,7 +529,7 @@ type StockShareData struct {
 	EntityType    int    `json:"entityType"`
 	TaxEntityID   string `json:"taxEntityId"`
 	FTCompanyName string `json:"ftCompanyName"`
-	NoStockShares int    `json:"noStockShares"`
+	NoStockShares int    `json:"noStockShares" validate:"gte=0"`
 }
 
 type SecurityData struct {
@@ -298,20 +539,20 @@ type SecurityData struct {
 
 type AssessmentRentalData struct {
 	IsPremiumConsiderationMade    bool               `json:"isPremiumConsiderationMade"`
-	PremiumConsiderationAmount    float64            `json:"premiumConsiderationAmount"`
-	ResidentialComponentAmount    float64            `json:"residentialComponentAmount"`
-	NonResidentialComponentAmount float64            `json:"nonResidentialComponentAmount"`
+	PremiumConsiderationAmount    float64            `json:"premiumConsiderationAmount" validate:"gte=0"`
+	ResidentialComponentAmount    float64            `json:"residentialComponentAmount" validate:"gte=0"`
+	NonResidentialComponentAmount float64            `json:"nonResidentialComponentAmount" validate:"gte=0"`
 	IsMonthlyRentPayable          bool               `json:"isMonthlyRentPayable"`
-	RentalDetails                 []RentalDetailData `json:"rentalDetails"`
-	TotalGrossRentAmount          float64            `json:"totalGrossRentAmount"`
-	AverageRentAmount             float64            `json:"averageRentAmount"`
+	RentalDetails                 []RentalDetailData `json:"rentalDetails" validate:"dive"`
+	TotalGrossRentAmount          float64            `json:"totalGrossRentAmount" validate:"gte=0"`
+	AverageRentAmount             float64            `json:"averageRentAmount" validate:"gte=0"`
 }
 
 type RentalDetailData struct {
-	StartPeriodOfLease string  `json:"startPeriodOfLease"`
-	EndPeriodOfLease   string  `json:"endPeriodOfLease"`
-	RentAmount         float64 `json:"rentAmount"`
-	MarketRentalValue  float64 `json:"marketRentalValue"`
+	StartPeriodOfLease string  `json:"startPeriodOfLease" validate:"required,isodate"`
+	EndPeriodOfLease   string  `json:"endPeriodOfLease" validate:"required,isodate"`
+	RentAmount         float64 `json:"rentAmount" validate:"gte=0"`
+	MarketRentalValue  float64 `json:"marketRentalValue" validate:"gte=0"`
 }
 
 type PartyData struct {
@@ -321,7 +562,7 @@ type PartyData struct {
 	TaxEntityIDNo        string             `json:"taxEntityIdNo"`
 	FTTaxEntityName      string             `json:"ftTaxEntityName"`
 	Gender               int                `json:"gender"`
-	DateOfBirth          string             `json:"dateOfBirth"`
+	DateOfBirth          string             `json:"dateOfBirth" validate:"omitempty,isodate"`
 	MailingAddress       MailingAddressData `json:"mailingAddress"`
 	PartyType            string             `json:"partyType"`
 	CountryOfNationality string             `json:"countryOfNationality"`
@@ -340,16 +581,23 @@ type MailingAddressData struct {
 }
 
 type TargetCompanyData struct {
-	EntityType            int     `json:"entityType"`
-	TaxEntityIDNo         string  `json:"taxEntityIdNo"`
-	FTCompanyName         string  `json:"ftCompanyName"`
-	DateOfIncorporation   string  `json:"dateOfIncorporation"`
-	CompanyType           string  `json:"companyType"`
-	MarketPricePerShare   float64 `json:"marketPricePerShare"`
-	NoOfSharesTransferred int     `json:"noOfSharesTransferred"`
-	TotalMarketPrice      float64 `json:"totalMarketPrice"`
-	NetAssetValue         float64 `json:"netAssetValue"`
-	HasOneClassOfShares   bool    `json:"hasOneClassOfShares"`
+	EntityType                       int     `json:"entityType"`
+	TaxEntityIDNo                    string  `json:"taxEntityIdNo"`
+	FTCompanyName                    string  `json:"ftCompanyName"`
+	DateOfIncorporation              string  `json:"dateOfIncorporation" validate:"omitempty,isodate"`
+	CompanyType                      string  `json:"companyType"`
+	MarketPricePerShare              float64 `json:"marketPricePerShare" validate:"gte=0"`
+	NoOfSharesTransferred            int     `json:"noOfSharesTransferred" validate:"gte=0"`
+	TotalIssuedShares                int     `json:"totalIssuedShares" validate:"gte=0"`
+	TotalMarketPrice                 float64 `json:"totalMarketPrice" validate:"gte=0"`
+	NetAssetValue                    float64 `json:"netAssetValue"`
+	HasOneClassOfShares              bool    `json:"hasOneClassOfShares"`
+	NetAssetValueOfSharesTransferred float64 `json:"netAssetValueOfSharesTransferred" validate:"gte=0"` // required when there is more than one class of shares
+	ResidentialPropertyValue         float64 `json:"residentialPropertyValue" validate:"gte=0"`         // Singapore residential property held directly or indirectly
+	TotalTangibleAssets              float64 `json:"totalTangibleAssets" validate:"gte=0"`
+	TransferorEquityInterest         float64 `json:"transferorEquityInterest" validate:"gte=0,lte=1"` // fraction held before the transfer
+	TransfereeEquityInterest         float64 `json:"transfereeEquityInterest" validate:"gte=0,lte=1"` // fraction held after the transfer
+	TransferorDateOfAcquisition      string  `json:"transferorDateOfAcquisition" validate:"omitempty,isodate"`
 }
 
 type AssessmentRemissionData struct {
@@ -360,30 +608,30 @@ type AssessmentRemissionData struct {
 
 // Stamp Mortgage Models
 type StampMortgageRequest struct {
-	AssignID                  string              `json:"assignId" binding:"required"`
+	AssignID                  string              `json:"assignId" validate:"required"`
 	FTReferenceNo             string              `json:"ftReferenceNo"`
-	DocumentDescription       string              `json:"documentDescription" binding:"required"`
+	DocumentDescription       string              `json:"documentDescription" validate:"required"`
 	DocumentReferenceNo       string              `json:"documentReferenceNo"`
 	FormatOfDocument          int                 `json:"formatOfDocument"`
 	ModeOfOffer               int                 `json:"modeOfOffer"`
 	ModeOfAcceptance          int                 `json:"modeOfAcceptance"`
 	IsSignedInSingapore       bool                `json:"isSignedInSingapore"`
-	DateOfDocument            string              `json:"dateOfDocument"`
-	ReceivingDateOfDocument   string              `json:"receivingDateOfDocument"`
+	DateOfDocument            string              `json:"dateOfDocument" validate:"omitempty,isodate"`
+	ReceivingDateOfDocument   string              `json:"receivingDateOfDocument" validate:"omitempty,isodate"`
 	IsDocumentDateUnavailable bool                `json:"isDocumentDateUnavailable"`
 	Submission                SubmissionData      `json:"submission"`
 	Assets                    MortgageAssetsData  `json:"assets"`
 	TypeOfMortgage            int                 `json:"typeOfMortgage"`
-	AmountOfLoan              float64             `json:"amountOfLoan" binding:"required"`
-	Mortgagors                []MortgagePartyData `json:"mortgagors"`
-	Mortgagees                []MortgagePartyData `json:"mortgagees"`
+	AmountOfLoan              float64             `json:"amountOfLoan" validate:"required,gt=0"`
+	Mortgagors                []MortgagePartyData `json:"mortgagors" validate:"dive"`
+	Mortgagees                []MortgagePartyData `json:"mortgagees" validate:"dive"`
 }
 
 type MortgageAssetsData struct {
-	Properties   []PropertyData   `json:"properties"`
-	Lands        []LandData       `json:"lands"`
-	StocksShares []StockShareData `json:"stocksShares"`
-	Security     []SecurityData   `json:"security"`
+	Properties   []PropertyData   `json:"properties" validate:"dive"`
+	Lands        []LandData       `json:"lands" validate:"dive"`
+	StocksShares []StockShareData `json:"stocksShares" validate:"dive"`
+	Security     []SecurityData   `json:"security" validate:"dive"`
 }
 
 type MortgagePartyData struct {
@@ -393,7 +641,7 @@ type MortgagePartyData struct {
 	TaxEntityIDNo    string             `json:"taxEntityIdNo"`
 	FTTaxEntityName  string             `json:"ftTaxEntityName"`
 	Gender           int                `json:"gender"`
-	DateOfBirth      string             `json:"dateOfBirth"`
+	DateOfBirth      string             `json:"dateOfBirth" validate:"omitempty,isodate"`
 	MailingAddress   MailingAddressData `json:"mailingAddress"`
 	PartyType        string             `json:"partyType"`
 	IsVerifiedEntity bool               `json:"isVerifiedEntity"`
@@ -402,63 +650,63 @@ type MortgagePartyData struct {
 
 // Sale Purchase Buyers Models
 type SalePurchaseBuyersRequest struct {
-	AssignID                                        string                    `json:"assignId" binding:"required"`
+	AssignID                                        string                    `json:"assignId" validate:"required"`
 	FTReferenceNo                                   string                    `json:"ftReferenceNo"`
-	DocumentDescription                             string                    `json:"documentDescription" binding:"required"`
+	DocumentDescription                             string                    `json:"documentDescription" validate:"required"`
 	DocumentReferenceNo                             string                    `json:"documentReferenceNo"`
 	FormatOfDocument                                int                       `json:"formatOfDocument"`
 	ModeOfOffer                                     int                       `json:"modeOfOffer"`
 	ModeOfAcceptance                                int                       `json:"modeOfAcceptance"`
 	IsSignedInSingapore                             bool                      `json:"isSignedInSingapore"`
-	DateOfDocument                                  string                    `json:"dateOfDocument"`
-	ReceivingDateOfDocument                         string                    `json:"receivingDateOfDocument"`
+	DateOfDocument                                  string                    `json:"dateOfDocument" validate:"omitempty,isodate"`
+	ReceivingDateOfDocument                         string                    `json:"receivingDateOfDocument" validate:"omitempty,isodate"`
 	IsDocumentDateUnavailable                       bool                      `json:"isDocumentDateUnavailable"`
 	Submission                                      SubmissionData            `json:"submission"`
 	Assets                                          SalePurchaseAssetsData    `json:"assets"`
 	RetrievedStampingRecord                         string                    `json:"retrievedStampingRecord"`
 	HasIntentToHoldThePropInTrustForBeneficialOwner bool                      `json:"hasIntentToHoldThePropInTrustForBeneficialOwner"`
 	HasIntentToTransferPropViaConveyanceDirection   bool                      `json:"hasIntentToTransferPropViaConveyanceDirection"`
-	PurchasePrice                                   float64                   `json:"purchasePrice" binding:"required"`
-	AnyConsiderationPaid                            float64                   `json:"anyConsiderationPaid"`
-	ConsiderationAmount                             float64                   `json:"considerationAmount"`
-	ConservancyCharges                              float64                   `json:"conservancyCharges"`
+	PurchasePrice                                   float64                   `json:"purchasePrice" validate:"required,gte=0"`
+	AnyConsiderationPaid                            float64                   `json:"anyConsiderationPaid" validate:"gte=0"`
+	ConsiderationAmount                             float64                   `json:"considerationAmount" validate:"gte=0"`
+	ConservancyCharges                              float64                   `json:"conservancyCharges" validate:"gte=0"`
 	ConservancyChargesUnitOfMeasure                 int                       `json:"conservancyChargesUnitOfMeasure"`
 	TrusteeBeneficiaryDetails                       TrusteeBeneficiaryData    `json:"trusteeBeneficiaryDetails"`
-	SellerTransferor                                []SalePurchasePartyData   `json:"sellerTransferor"`
-	BuyerTransferee                                 []SalePurchasePartyData   `json:"buyerTransferee"`
-	Trustee                                         []SalePurchasePartyData   `json:"trustee"`
-	Beneficiary                                     []SalePurchasePartyData   `json:"beneficiary"`
-	AssessmentRemissions                            []AssessmentRemissionData `json:"assessmentRemissions"`
-	PropertyBuyers                                  []PropertyBuyerData       `json:"propertyBuyers"`
+	SellerTransferor                                []SalePurchasePartyData   `json:"sellerTransferor" validate:"dive"`
+	BuyerTransferee                                 []SalePurchasePartyData   `json:"buyerTransferee" validate:"dive"`
+	Trustee                                         []SalePurchasePartyData   `json:"trustee" validate:"dive"`
+	Beneficiary                                     []SalePurchasePartyData   `json:"beneficiary" validate:"dive"`
+	AssessmentRemissions                            []AssessmentRemissionData `json:"assessmentRemissions" validate:"dive"`
+	PropertyBuyers                                  []PropertyBuyerData       `json:"propertyBuyers" validate:"dive"`
 	IntentToClaimAbsdRefund                         int                       `json:"intentToClaimAbsdRefund"`
 	AbsdRefundAssets                                AbsdRefundAssetsData      `json:"absdRefundAssets"`
 	MaritalStatus                                   MaritalStatusData         `json:"maritalStatus"`
 }
 
 type SalePurchaseAssetsData struct {
-	Properties   []PropertyData   `json:"properties"`
-	Lands        []LandData       `json:"lands"`
-	StocksShares []StockShareData `json:"stocksShares"`
-	Security     []SecurityData   `json:"security"`
+	Properties   []PropertyData   `json:"properties" validate:"dive"`
+	Lands        []LandData       `json:"lands" validate:"dive"`
+	StocksShares []StockShareData `json:"stocksShares" validate:"dive"`
+	Security     []SecurityData   `json:"security" validate:"dive"`
 }
 
 type LevelUnitData struct {
 	FloorNo                           string  `json:"floorNo"`
 	UnitNo                            string  `json:"unitNo"`
 	AbsdRate                          float64 `json:"absdRate"`
-	BuyingPriceMarketValueResidential float64 `json:"buyingPriceMarketValueResidential"`
+	BuyingPriceMarketValueResidential float64 `json:"buyingPriceMarketValueResidential" validate:"gte=0"`
 }
 
 type PropertyOwnershipData struct {
 	Sequence         int `json:"sequence"`
-	ShareNumerator   int `json:"shareNumerator"`
-	ShareDenominator int `json:"shareDenominator"`
+	ShareNumerator   int `json:"shareNumerator" validate:"gte=0"`
+	ShareDenominator int `json:"shareDenominator" validate:"gte=0"`
 }
 
 type LandOwnershipData struct {
 	Sequence         int `json:"sequence"`
-	ShareNumerator   int `json:"shareNumerator"`
-	ShareDenominator int `json:"shareDenominator"`
+	ShareNumerator   int `json:"shareNumerator" validate:"gte=0"`
+	ShareDenominator int `json:"shareDenominator" validate:"gte=0"`
 }
 
 type SalePurchasePartyData struct {
@@ -468,7 +716,7 @@ type SalePurchasePartyData struct {
 	TaxEntityIDNo               string             `json:"taxEntityIdNo"`
 	FTTaxEntityName             string             `json:"ftTaxEntityName"`
 	Gender                      int                `json:"gender"`
-	DateOfBirth                 string             `json:"dateOfBirth"`
+	DateOfBirth                 string             `json:"dateOfBirth" validate:"omitempty,isodate"`
 	IsSubFundOwnerLessee        bool               `json:"isSubFundOwnerLessee"`
 	MailingAddress              MailingAddressData `json:"mailingAddress"`
 	PartyType                   string             `json:"partyType"`
@@ -499,7 +747,7 @@ type PropertyBuyerData struct {
 	TaxEntityIDType           int    `json:"taxEntityIdType"`
 	TaxEntityIDNo             string `json:"taxEntityIdNo"`
 	PartyType                 string `json:"partyType"`
-	NoOfProperties            int    `json:"noOfProperties"`
+	NoOfProperties            int    `json:"noOfProperties" validate:"gte=0"`
 	BuyerName                 string `json:"buyerName"`
 	Sequence                  int    `json:"sequence"`
 	AssessmentPartiesSequence int    `json:"assessmentPartiesSequence"`
@@ -533,11 +781,60 @@ type MaritalStatusData struct {
 	MarriedCouple int `json:"marriedCouple"`
 }
 
+// ABSD refund claim models for replacement-home purchases
+type AbsdRefundClaimRequest struct {
+	DocRefNo         string `json:"docRefNo" validate:"required"`
+	DateOfCompletion string `json:"dateOfCompletion"`
+	DateOfDisposal   string `json:"dateOfDisposal" validate:"required"`
+	DisposalDocRefNo string `json:"disposalDocRefNo"`
+	Declaration      bool   `json:"declaration"`
+}
+
+type AbsdRefundClaimResponse struct {
+	ReturnCode int                  `json:"returnCode"`
+	Data       *AbsdRefundClaimData `json:"data,omitempty"`
+	Info       *EStampInfo          `json:"info,omitempty"`
+}
+
+type AbsdRefundClaimData struct {
+	DocRefNo         string  `json:"docRefNo"`
+	Status           string  `json:"status"`
+	DeadlineDate     string  `json:"deadlineDate"`
+	AbsdPaid         float64 `json:"absdPaid"`
+	RefundableAmount float64 `json:"refundableAmount"`
+	CpfRefundAmount  float64 `json:"cpfRefundAmount"`
+	CashRefundAmount float64 `json:"cashRefundAmount"`
+}
+
+// ABSD refund claim storage model for database
+type AbsdRefundClaim struct {
+	BaseModel
+	DocRefNo          string     `json:"doc_ref_no" gorm:"not null;uniqueIndex" validate:"required"`
+	ClientID          string     `json:"client_id" gorm:"index"`
+	AssignID          string     `json:"assign_id" gorm:"index"`
+	Status            string     `json:"status" gorm:"default:intended;index"`
+	DateOfDocument    string     `json:"date_of_document"`
+	CompletedProperty bool       `json:"completed_property"`
+	DateOfCompletion  string     `json:"date_of_completion"`
+	DeadlineDate      string     `json:"deadline_date" gorm:"index"`
+	DateOfDisposal    string     `json:"date_of_disposal"`
+	DisposalDocRefNo  string     `json:"disposal_doc_ref_no"`
+	AbsdPaid          float64    `json:"absd_paid"`
+	RefundableAmount  float64    `json:"refundable_amount"`
+	CpfRefundAmount   float64    `json:"cpf_refund_amount"`
+	CashRefundAmount  float64    `json:"cash_refund_amount"`
+	RefundAssetsData  string     `json:"refund_assets_data" gorm:"type:text"` // JSON serialized AbsdRefundAssetsData
+	SubmittedAt       *time.Time `json:"submitted_at"`
+	DecidedAt         *time.Time `json:"decided_at"`
+	DecidedBy         string     `json:"decided_by"`
+	Remarks           string     `json:"remarks" gorm:"type:text"`
+}
+
 // Sale Purchase Sellers Models
 type SalePurchaseSellersRequest struct {
-	AssignID                        string                        `json:"assignId" binding:"required"`
+	AssignID                        string                        `json:"assignId" validate:"required"`
 	FTReferenceNo                   string                        `json:"ftReferenceNo"`
-	DocumentDescription             string                        `json:"documentDescription" binding:"required"`
+	DocumentDescription             string                        `json:"documentDescription" validate:"required"`
 	DocumentReferenceNo             string                        `json:"documentReferenceNo"`
 	FormatOfDocument                int                           `json:"formatOfDocument"`
 	ModeOfOffer                     int                           `json:"modeOfOffer"`
@@ -545,29 +842,29 @@ type SalePurchaseSellersRequest struct {
 	ModeOfAcceptance                int                           `json:"modeOfAcceptance"`
 	FTModeOfAcceptanceOthersValue   string                        `json:"ftModeOfAcceptanceOthersValue"`
 	IsSignedInSingapore             bool                          `json:"isSignedInSingapore"`
-	DateOfDocument                  string                        `json:"dateOfDocument"`
-	ReceivingDateOfDocument         string                        `json:"receivingDateOfDocument"`
+	DateOfDocument                  string                        `json:"dateOfDocument" validate:"omitempty,isodate"`
+	ReceivingDateOfDocument         string                        `json:"receivingDateOfDocument" validate:"omitempty,isodate"`
 	Submission                      SubmissionData                `json:"submission"`
 	IsDocumentDateUnavailable       bool                          `json:"isDocumentDateUnavailable"`
 	Assets                          SalePurchaseAssetsData        `json:"assets"`
 	RetrievedStampingRecord         string                        `json:"retrievedStampingRecord"`
-	PurchasePrice                   float64                       `json:"purchasePrice"`
+	PurchasePrice                   float64                       `json:"purchasePrice" validate:"gte=0"`
 	AnyConsiderationPaid            bool                          `json:"anyConsiderationPaid"`
-	ConsiderationAmount             float64                       `json:"considerationAmount"`
-	ConservancyCharge               float64                       `json:"conservancyCharge"`
-	SellingPrice                    float64                       `json:"sellingPrice"`
+	ConsiderationAmount             float64                       `json:"considerationAmount" validate:"gte=0"`
+	ConservancyCharge               float64                       `json:"conservancyCharge" validate:"gte=0"`
+	SellingPrice                    float64                       `json:"sellingPrice" validate:"gte=0"`
 	ConservancyChargesUnitOfMeasure int                           `json:"conservancyChargesUnitOfMeasure"`
 	TrusteeBeneficiaryDetails       TrusteeBeneficiaryData        `json:"trusteeBeneficiaryDetails"`
-	SellerTransferor                []SalePurchaseAdvancedParty   `json:"sellerTransferor"`
-	BuyerTransferee                 []SalePurchaseAdvancedParty   `json:"buyerTransferee"`
-	Trustee                         []SalePurchaseAdvancedParty   `json:"trustee"`
-	Beneficiary                     []SalePurchaseAdvancedParty   `json:"beneficiary"`
-	TransferorInitialPurchaser      []SalePurchaseAdvancedParty   `json:"transferorInitialPurchaser"`
-	Transferee                      []SalePurchaseAdvancedParty   `json:"transferee"`
-	AssessmentRemissions            []AdvancedAssessmentRemission `json:"assessmentRemissions"`
-	PropertyBuyers                  []PropertyBuyerData           `json:"propertyBuyers"`
+	SellerTransferor                []SalePurchaseAdvancedParty   `json:"sellerTransferor" validate:"dive"`
+	BuyerTransferee                 []SalePurchaseAdvancedParty   `json:"buyerTransferee" validate:"dive"`
+	Trustee                         []SalePurchaseAdvancedParty   `json:"trustee" validate:"dive"`
+	Beneficiary                     []SalePurchaseAdvancedParty   `json:"beneficiary" validate:"dive"`
+	TransferorInitialPurchaser      []SalePurchaseAdvancedParty   `json:"transferorInitialPurchaser" validate:"dive"`
+	Transferee                      []SalePurchaseAdvancedParty   `json:"transferee" validate:"dive"`
+	AssessmentRemissions            []AdvancedAssessmentRemission `json:"assessmentRemissions" validate:"dive"`
+	PropertyBuyers                  []PropertyBuyerData           `json:"propertyBuyers" validate:"dive"`
 	IntentToClaimAbsdRefund         int                           `json:"intentToClaimAbsdRefund"`
-	DateOfAcquisition               string                        `json:"dateOfAcquisition"`
+	DateOfAcquisition               string                        `json:"dateOfAcquisition" validate:"omitempty,isodate"`
 }
 
 // Advanced party structure for SalePurchaseSellers with additional fields
@@ -578,7 +875,7 @@ type SalePurchaseAdvancedParty struct {
 	TaxEntityIDNo               string                     `json:"taxEntityIdNo"`
 	FTTaxEntityName             string                     `json:"ftTaxEntityName"`
 	Gender                      int                        `json:"gender"`
-	DateOfBirth                 string                     `json:"dateOfBirth"`
+	DateOfBirth                 string                     `json:"dateOfBirth" validate:"omitempty,isodate"`
 	IsSubFundOwnerLessee        bool                       `json:"isSubFundOwnerLessee"`
 	SubFunds                    []SubFundData              `json:"subFunds"`
 	MailingAddress              AdvancedMailingAddressData `json:"mailingAddress"`
@@ -589,7 +886,7 @@ type SalePurchaseAdvancedParty struct {
 	BeneficiaryIsUnidentifiable bool                       `json:"beneficiaryIsUnidentifiable"`
 	FTDescription               string                     `json:"ftDescription"`
 	IsLiableParty               bool                       `json:"isLiableParty"`
-	Lawyer                      []LawyerData               `json:"lawyer"`
+	Lawyer                      []LawyerData               `json:"lawyer" validate:"dive"`
 }
 
 // SubFund data structure
@@ -652,7 +949,7 @@ type RemissionOptionData struct {
 // AIS Organization Search models based on IRAS API spec
 type AISorgSearchRequest struct {
 	ClientID       string `json:"clientID" validate:"required"`
-	OrganizationID string `json:"organizationID" validate:"required"`
+	OrganizationID string `json:"organizationID" validate:"required,taxrefid"`
 	BasisYear      int    `json:"basisYear" validate:"required"`
 }
 
@@ -991,6 +1288,35 @@ type SCAuthenticityRequest struct {
 	StampCertRef string `json:"stampCertRef" validate:"required"`
 }
 
+// Stamp certificate verification models
+type VerifyStampCertificateRequest struct {
+	QRPayload string `json:"qrPayload" binding:"required"`
+}
+
+// StampCertificatePayload is the signed content of a stamp certificate's QR code
+type StampCertificatePayload struct {
+	DocRefNo        string  `json:"docRefNo"`
+	StampCertRef    string  `json:"stampCertRef"`
+	DocumentType    string  `json:"documentType"`
+	DateOfDocument  string  `json:"dateOfDocument"`
+	IssueDate       string  `json:"issueDate"`
+	SDAmount        float64 `json:"sdAmount"`
+	SDPenalty       float64 `json:"sdPenalty"`
+	TotalAmtPayable float64 `json:"totalAmtPayable"`
+}
+
+// StampCertificateKeyResponse publishes the key that verifies stamp
+// certificate QR payloads
+type StampCertificateKeyResponse struct {
+	ReturnCode int                      `json:"returnCode"`
+	Data       *StampCertificateKeyData `json:"data,omitempty"`
+}
+
+type StampCertificateKeyData struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"publicKey"`
+}
+
 type SCAuthenticityResponse struct {
 	ReturnCode int                 `json:"returnCode"`
 	Data       *SCAuthenticityData `json:"data,omitempty"`
@@ -1101,9 +1427,11 @@ type CalIndustrialSSDResponse struct {
 }
 
 type CalIndustrialSSDData struct {
-	StampDuty     float64 `json:"stampDuty"`
-	HoldingPeriod int     `json:"holdingPeriod"`
-	DutyRate      float64 `json:"dutyRate"`
+	StampDuty           float64          `json:"stampDuty"`
+	HoldingPeriod       int              `json:"holdingPeriod"`
+	HoldingPeriodDetail SSDHoldingPeriod `json:"holdingPeriodDetail"`
+	DutyRate            float64          `json:"dutyRate"`
+	RuleVersion         string           `json:"ruleVersion"`
 }
 
 type CalIndustrialSSDInfo struct {
@@ -1116,3 +1444,211 @@ type CalIndustrialSSDFieldInfo struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+// Holding period expressed in whole years, months and days
+type SSDHoldingPeriod struct {
+	Years  int `json:"years"`
+	Months int `json:"months"`
+	Days   int `json:"days"`
+}
+
+// Seller Stamp Duty Calculation for Residential Property models
+type CalResidentialSSDRequest struct {
+	Value           float64 `json:"value" validate:"required,gt=0"`
+	AcquisitionDate string  `json:"acquisitionDate" validate:"required"`
+	DisposalDate    string  `json:"disposalDate" validate:"required"`
+	ClientID        string  `json:"clientID" validate:"required"`
+}
+
+type CalResidentialSSDResponse struct {
+	ReturnCode int                    `json:"returnCode"`
+	Data       *CalResidentialSSDData `json:"data,omitempty"`
+	Info       *CalResidentialSSDInfo `json:"info,omitempty"`
+}
+
+type CalResidentialSSDData struct {
+	StampDuty           float64          `json:"stampDuty"`
+	HoldingPeriod       int              `json:"holdingPeriod"`
+	HoldingPeriodDetail SSDHoldingPeriod `json:"holdingPeriodDetail"`
+	DutyRate            float64          `json:"dutyRate"`
+	RuleVersion         string           `json:"ruleVersion"`
+}
+
+type CalResidentialSSDInfo struct {
+	Message       string                       `json:"message"`
+	MessageCode   int                          `json:"messageCode"`
+	FieldInfoList []CalResidentialSSDFieldInfo `json:"fieldInfoList,omitempty"`
+}
+
+type CalResidentialSSDFieldInfo struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// GST return (GST F5/F8) submission models based on IRAS GST Returns API
+type GSTReturnRequest struct {
+	FilingInfo  GSTReturnFilingInfo  `json:"filingInfo" validate:"required"`
+	Supplies    GSTReturnSupplies    `json:"supplies" validate:"required"`
+	Purchases   GSTReturnPurchases   `json:"purchases" validate:"required"`
+	Taxes       GSTReturnTaxes       `json:"taxes" validate:"required"`
+	Declaration GSTReturnDeclaration `json:"declaration" validate:"required"`
+}
+
+type GSTReturnFilingInfo struct {
+	ClientID      string `json:"clientID"`
+	TaxRefNo      string `json:"taxRefNo" validate:"required,gstregid"`
+	DtPeriodStart string `json:"dtPeriodStart" validate:"required,datetime=2006-01-02"`
+	DtPeriodEnd   string `json:"dtPeriodEnd" validate:"required,datetime=2006-01-02"`
+	EntityID      string `json:"-"` // UEN the access token acts for, set from the token; empty when it is not tied to one
+}
+
+// GSTReturnSupplies are boxes 1 to 4 of the return
+type GSTReturnSupplies struct {
+	TotStdSupply    float64 `json:"totStdSupply" validate:"gte=0"`
+	TotZeroSupply   float64 `json:"totZeroSupply" validate:"gte=0"`
+	TotExemptSupply float64 `json:"totExemptSupply" validate:"gte=0"`
+	TotValueSupply  float64 `json:"totValueSupply" validate:"gte=0"`
+}
+
+// GSTReturnPurchases are boxes 5 and 9 of the return
+type GSTReturnPurchases struct {
+	TotTaxPurchase float64 `json:"totTaxPurchase" validate:"gte=0"`
+	TotValueScheme float64 `json:"totValueScheme" validate:"gte=0"`
+}
+
+// GSTReturnTaxes are boxes 6 to 8 of the return. Net GST is negative when
+// GST is claimed.
+type GSTReturnTaxes struct {
+	OutputTaxDue   float64 `json:"outputTaxDue" validate:"gte=0"`
+	InputTaxRefund float64 `json:"inputTaxRefund" validate:"gte=0"`
+	NetGSTPaid     float64 `json:"netGSTPaid"`
+}
+
+type GSTReturnDeclaration struct {
+	DeclarantID            string `json:"declarantID" validate:"required,nricfin"`
+	DeclarantName          string `json:"declarantName" validate:"required"`
+	DeclarantDesignation   string `json:"declarantDesignation" validate:"required"`
+	ContactNumber          string `json:"contactNumber" validate:"required"`
+	ContactEmail           string `json:"contactEmail" validate:"required,email"`
+	DeclareTrueCompleteInd bool   `json:"declareTrueCompleteInd" validate:"eq=true"`
+}
+
+type GSTReturnResponse struct {
+	ReturnCode int            `json:"returnCode"`
+	Data       *GSTReturnData `json:"data,omitempty"`
+	Info       *GSTInfo       `json:"info,omitempty"`
+}
+
+type GSTReturnData struct {
+	AckNo         string  `json:"ackNo"`
+	FormType      string  `json:"formType"`
+	TaxRefNo      string  `json:"taxRefNo"`
+	DtPeriodStart string  `json:"dtPeriodStart"`
+	DtPeriodEnd   string  `json:"dtPeriodEnd"`
+	NetGSTPaid    float64 `json:"netGSTPaid"`
+	FilingDueDate string  `json:"filingDueDate"`
+	LateFiling    bool    `json:"lateFiling"`
+	SubmittedAt   string  `json:"submittedAt"`
+}
+
+// GSTReturnRecord stores a filed GST return against its registration and
+// accounting period
+type GSTReturnRecord struct {
+	BaseModel
+	AckNo                 string    `json:"ack_no" gorm:"not null;uniqueIndex"`
+	ClientID              string    `json:"client_id" gorm:"index"`
+	FormType              string    `json:"form_type"`
+	RegistrationID        string    `json:"registration_id" gorm:"not null;index:idx_gst_return_period,priority:1"`
+	GSTRegistrationNumber string    `json:"gst_registration_number"`
+	PeriodStart           string    `json:"period_start" gorm:"not null;index:idx_gst_return_period,priority:2"`
+	PeriodEnd             string    `json:"period_end" gorm:"not null"`
+	TotStdSupply          float64   `json:"tot_std_supply"`
+	TotZeroSupply         float64   `json:"tot_zero_supply"`
+	TotExemptSupply       float64   `json:"tot_exempt_supply"`
+	TotValueSupply        float64   `json:"tot_value_supply"`
+	TotTaxPurchase        float64   `json:"tot_tax_purchase"`
+	OutputTaxDue          float64   `json:"output_tax_due"`
+	InputTaxRefund        float64   `json:"input_tax_refund"`
+	NetGSTPaid            float64   `json:"net_gst_paid"`
+	TotValueScheme        float64   `json:"tot_value_scheme"`
+	DeclarantID           string    `json:"declarant_id"`
+	DeclarantName         string    `json:"declarant_name"`
+	ReturnData            string    `json:"return_data" gorm:"type:text"`
+	SubmittedAt           time.Time `json:"submitted_at"`
+}
+
+// GST transaction listing submission models. A listing reports the invoices
+// behind a return: supplies made to and purchases received from other
+// GST-registered businesses in the accounting period.
+type GSTTransListRequest struct {
+	FilingInfo GSTReturnFilingInfo `json:"filingInfo" validate:"required"`
+	Supplies   []GSTTransListLine  `json:"supplies" validate:"dive"`
+	Purchases  []GSTTransListLine  `json:"purchases" validate:"dive"`
+}
+
+// GSTTransListLine is one invoice on a listing. GSTNo is the GST number of
+// the customer on a supply or the supplier on a purchase; credit notes carry
+// negative amounts.
+type GSTTransListLine struct {
+	InvoiceNo    string  `json:"invoiceNo" validate:"required"`
+	InvoiceDate  string  `json:"invoiceDate" validate:"required,datetime=2006-01-02"`
+	GSTNo        string  `json:"gstNo" validate:"required"`
+	Name         string  `json:"name"`
+	ValueExclGST float64 `json:"valueExclGST"`
+	GSTAmount    float64 `json:"gstAmount"`
+}
+
+type GSTTransListResponse struct {
+	ReturnCode int               `json:"returnCode"`
+	Data       *GSTTransListData `json:"data,omitempty"`
+	Info       *GSTInfo          `json:"info,omitempty"`
+}
+
+type GSTTransListData struct {
+	SubmissionRef      string                  `json:"submissionRef"`
+	Status             string                  `json:"status"`
+	TaxRefNo           string                  `json:"taxRefNo"`
+	DtPeriodStart      string                  `json:"dtPeriodStart"`
+	DtPeriodEnd        string                  `json:"dtPeriodEnd"`
+	SupplyLines        int                     `json:"supplyLines"`
+	PurchaseLines      int                     `json:"purchaseLines"`
+	RejectedLines      int                     `json:"rejectedLines"`
+	TotalSupplyValue   float64                 `json:"totalSupplyValue"`
+	TotalSupplyGST     float64                 `json:"totalSupplyGST"`
+	TotalPurchaseValue float64                 `json:"totalPurchaseValue"`
+	TotalPurchaseGST   float64                 `json:"totalPurchaseGST"`
+	LineErrors         []GSTTransListLineError `json:"lineErrors,omitempty"`
+	SubmittedAt        string                  `json:"submittedAt"`
+}
+
+// GSTTransListLineError is a problem with one line of a listing. Line
+// counts from 1 within the listing named.
+type GSTTransListLineError struct {
+	Listing   string `json:"listing"`
+	Line      int    `json:"line"`
+	InvoiceNo string `json:"invoiceNo"`
+	Field     string `json:"field"`
+	Message   string `json:"message"`
+}
+
+// GSTTransListRecord stores a submitted transaction listing against its
+// registration and accounting period
+type GSTTransListRecord struct {
+	BaseModel
+	SubmissionRef      string    `json:"submission_ref" gorm:"not null;uniqueIndex"`
+	ClientID           string    `json:"client_id" gorm:"index"`
+	RegistrationID     string    `json:"registration_id" gorm:"not null;index:idx_gst_trans_list_period,priority:1"`
+	PeriodStart        string    `json:"period_start" gorm:"not null;index:idx_gst_trans_list_period,priority:2"`
+	PeriodEnd          string    `json:"period_end" gorm:"not null"`
+	Status             string    `json:"status" gorm:"index"`
+	SupplyLines        int       `json:"supply_lines"`
+	PurchaseLines      int       `json:"purchase_lines"`
+	RejectedLines      int       `json:"rejected_lines"`
+	TotalSupplyValue   float64   `json:"total_supply_value"`
+	TotalSupplyGST     float64   `json:"total_supply_gst"`
+	TotalPurchaseValue float64   `json:"total_purchase_value"`
+	TotalPurchaseGST   float64   `json:"total_purchase_gst"`
+	LineErrors         string    `json:"line_errors,omitempty" gorm:"type:text"` // JSON array of GSTTransListLineError
+	ListingData        string    `json:"listing_data" gorm:"type:text"`
+	SubmittedAt        time.Time `json:"submitted_at"`
+}
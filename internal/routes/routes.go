@@ -1,9 +1,11 @@
 package routes
 
 import (
+	"api-iras/internal/config"
 	"api-iras/internal/controllers"
 	"api-iras/internal/middleware"
 	"api-iras/internal/services"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,29 +32,54 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 
 	// Initialize services
 	gstService := services.NewGSTService(db)
+	gstImportService := services.NewGSTImportService(db)
+	gstReturnService := services.NewGSTReturnService(db, gstService)
+	gstTransListService := services.NewGSTTransListService(db, gstService)
+	accessTokenService := services.NewAccessTokenService(db)
 	authService := services.NewAuthService(db)
 	aisService := services.NewAISService()
 	propertyService := services.NewPropertyService(db)
 	rentalService := services.NewRentalService(db)
 	citService := services.NewCITService(db)
 	singpassService := services.NewSingPassService(db)
+	stampDutyService := services.NewStampDutyService(config.AppConfig.ABSDRates)
+	absdRefundService := services.NewAbsdRefundService(db, stampDutyService)
+	remissionService := services.NewRemissionService()
+	stampCertificateService, err := services.NewStampCertificateService(config.AppConfig.StampCertKey, config.AppConfig.Env == "development")
+	if err != nil {
+		log.Fatal("Failed to set up stamp certificates:", err)
+	}
+	eStampRecordService := services.NewEStampRecordService(db, stampCertificateService)
 
 	// Initialize controllers
-	gstController := controllers.NewGSTController(gstService)
+	gstController := controllers.NewGSTController(gstService, gstImportService)
+	gstReturnController := controllers.NewGSTReturnController(gstReturnService, accessTokenService)
+	gstTransListController := controllers.NewGSTTransListController(gstTransListService, accessTokenService)
 	authController := controllers.NewAuthController(authService)
-	corpPassController := controllers.NewCorpPassController()
-	eStampController := controllers.NewEStampController()
+	corpPassController := controllers.NewCorpPassController(accessTokenService)
+	eStampController := controllers.NewEStampController(services.NewEStampValidator(), stampDutyService, absdRefundService, remissionService, eStampRecordService, stampCertificateService)
 	aisController := controllers.NewAISController(aisService)
 	propertyController := controllers.NewPropertyController(propertyService)
 	rentalController := controllers.NewRentalController(rentalService)
 	citController := controllers.NewCITController(citService)
 	singpassController := controllers.NewSingPassController(singpassService)
+	absdRefundController := controllers.NewAbsdRefundController(absdRefundService)
 
 	// IRAS GST API routes (following the swagger spec basePath)
 	irasGroup := router.Group("/iras/prod/GSTListing")
 	{
 		// Main GST search endpoint as per IRAS API spec
 		irasGroup.POST("/SearchGSTRegistered", gstController.SearchGSTRegistered)
+		irasGroup.POST("/SearchGSTRegisteredBulk", gstController.SearchGSTRegisteredBulk)
+		irasGroup.POST("/SearchGSTCandidates", gstController.SearchGSTCandidates)
+	}
+
+	// IRAS GST return routes
+	gstReturnGroup := router.Group("/iras/sb/gst")
+	{
+		gstReturnGroup.POST("/submitF5Return", gstReturnController.SubmitF5Return)
+		gstReturnGroup.POST("/submitF8Return", gstReturnController.SubmitF8Return)
+		gstReturnGroup.POST("/submitTransactionListing", gstTransListController.SubmitTransactionListing)
 	}
 
 	// IRAS CorpPass Authentication routes
@@ -70,14 +97,26 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		eStampGroup.POST("/StampMortgage", eStampController.StampMortgage)
 		eStampGroup.POST("/SalePurchaseBuyers", eStampController.SalePurchaseBuyers)
 		eStampGroup.POST("/SalePurchaseSellers", eStampController.SalePurchaseSellers)
+		eStampGroup.POST("/AbsdRefundClaim", absdRefundController.SubmitAbsdRefundClaim)
+		eStampGroup.GET("/StampCertificate/:docRefNo", eStampController.DownloadStampCertificate)
+
+		// Duty quotes run the same calculations without stamping the document
+		eStampGroup.POST("/Quote/StampTenancyAgreement", eStampController.QuoteStampTenancyAgreement)
+		eStampGroup.POST("/Quote/ShareTransfer", eStampController.QuoteShareTransfer)
+		eStampGroup.POST("/Quote/StampMortgage", eStampController.QuoteStampMortgage)
+		eStampGroup.POST("/Quote/SalePurchaseBuyers", eStampController.QuoteSalePurchaseBuyers)
+		eStampGroup.POST("/Quote/SalePurchaseSellers", eStampController.QuoteSalePurchaseSellers)
 	}
 
 	// IRAS Stamp Duty routes (Production)
 	stampDutyGroup := router.Group("/iras/prod/SD")
 	{
 		stampDutyGroup.POST("/SCAuthenticity", eStampController.SCAuthenticity)
+		stampDutyGroup.POST("/VerifyStampCertificate", eStampController.VerifyStampCertificate)
+		stampDutyGroup.GET("/StampCertificateKey", eStampController.StampCertificateKey)
 		stampDutyGroup.POST("/CalPubListedCompanyShares", eStampController.CalPubListedCompanyShares)
 		stampDutyGroup.POST("/CalIndustrialSSD", eStampController.CalIndustrialSSD)
+		stampDutyGroup.POST("/CalResidentialSSD", eStampController.CalResidentialSSD)
 	}
 
 	// IRAS AIS routes
@@ -138,8 +177,12 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		adminGroup.POST("/gst-registrations", gstController.CreateGSTRegistration)
 		adminGroup.GET("/gst-registrations", gstController.GetGSTRegistrations)
 		adminGroup.GET("/gst-registrations/:id", gstController.GetGSTRegistration)
+		adminGroup.GET("/gst-registrations/:id/history", gstController.GetGSTRegistrationHistory)
 		adminGroup.PUT("/gst-registrations/:id", gstController.UpdateGSTRegistration)
 		adminGroup.DELETE("/gst-registrations/:id", gstController.DeleteGSTRegistration)
+		adminGroup.POST("/gst-registrations/import", gstController.ImportGSTListing)
+		adminGroup.GET("/gst-registrations/imports", gstController.GetGSTImportRuns)
+		adminGroup.GET("/gst-registrations/imports/:id", gstController.GetGSTImportRun)
 
 		// Property Consolidated Statement management endpoints
 		adminGroup.POST("/property-statements", propertyController.CreateConsolidatedStatementRecord)
@@ -172,6 +215,15 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		adminGroup.PUT("/cit-conversions/:id", citController.UpdateCITConversionRecord)
 		adminGroup.DELETE("/cit-conversions/:id", citController.DeleteCITConversionRecord)
 
+		// ABSD refund claim management endpoints
+		adminGroup.GET("/absd-refund-claims", absdRefundController.GetAbsdRefundClaims)
+		adminGroup.GET("/absd-refund-claims/:id", absdRefundController.GetAbsdRefundClaim)
+		adminGroup.PUT("/absd-refund-claims/:id/approve", absdRefundController.ApproveAbsdRefundClaim)
+		adminGroup.PUT("/absd-refund-claims/:id/reject", absdRefundController.RejectAbsdRefundClaim)
+
+		// Stamp certificate management endpoints
+		adminGroup.PUT("/stamp-certificates/:docRefNo/revoke", eStampController.RevokeStampCertificate)
+
 		// User management endpoints (admin only)
 		adminGroup.GET("/users", authController.GetAllUsers)
 		adminGroup.PUT("/users/:id/deactivate", authController.DeactivateUser)
@@ -189,7 +241,14 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 			"produces":    []string{"application/json"},
 			"endpoints": gin.H{
 				"gst": gin.H{
-					"search": "/iras/prod/GSTListing/SearchGSTRegistered",
+					"search":           "/iras/prod/GSTListing/SearchGSTRegistered",
+					"bulk_search":      "/iras/prod/GSTListing/SearchGSTRegisteredBulk",
+					"candidate_search": "/iras/prod/GSTListing/SearchGSTCandidates",
+				},
+				"gst_returns": gin.H{
+					"submit_f5":                  "/iras/sb/gst/submitF5Return",
+					"submit_f8":                  "/iras/sb/gst/submitF8Return",
+					"submit_transaction_listing": "/iras/sb/gst/submitTransactionListing",
 				},
 				"eStamp": gin.H{
 					"tenancy_agreement":     "/iras/sb/eStamp/StampTenancyAgreement",
@@ -197,11 +256,17 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 					"stamp_mortgage":        "/iras/sb/eStamp/StampMortgage",
 					"sale_purchase_buyers":  "/iras/sb/eStamp/SalePurchaseBuyers",
 					"sale_purchase_sellers": "/iras/sb/eStamp/SalePurchaseSellers",
+					"absd_refund_claim":     "/iras/sb/eStamp/AbsdRefundClaim",
+					"stamp_certificate":     "/iras/sb/eStamp/StampCertificate/{docRefNo}",
+					"quote":                 "/iras/sb/eStamp/Quote/{document}",
 				},
 				"stamp_duty": gin.H{
 					"authenticity_check":              "/iras/prod/SD/SCAuthenticity",
+					"verify_stamp_certificate":        "/iras/prod/SD/VerifyStampCertificate",
+					"stamp_certificate_key":           "/iras/prod/SD/StampCertificateKey",
 					"calc_pub_listed_company_shares":  "/iras/prod/SD/CalPubListedCompanyShares",
 					"calc_industrial_ssd":             "/iras/prod/SD/CalIndustrialSSD",
+					"calc_residential_ssd":            "/iras/prod/SD/CalResidentialSSD",
 				},
 				"corppass": gin.H{
 					"auth":  "/iras/sb/Authentication/CorpPassAuth",
@@ -226,11 +291,14 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 				},
 				"admin": gin.H{
 					"gst_registrations": gin.H{
-						"create": "/admin/gst-registrations",
-						"list":   "/admin/gst-registrations",
-						"get":    "/admin/gst-registrations/{id}",
-						"update": "/admin/gst-registrations/{id}",
-						"delete": "/admin/gst-registrations/{id}",
+						"create":  "/admin/gst-registrations",
+						"list":    "/admin/gst-registrations",
+						"get":     "/admin/gst-registrations/{id}",
+						"history": "/admin/gst-registrations/{id}/history",
+						"update":  "/admin/gst-registrations/{id}",
+						"delete":  "/admin/gst-registrations/{id}",
+						"import":  "/admin/gst-registrations/import",
+						"imports": "/admin/gst-registrations/imports",
 					},
 					"property_statements": gin.H{
 						"create": "/admin/property-statements",
@@ -263,6 +331,15 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 						"update":               "/admin/cit-conversions/{id}",
 						"delete":               "/admin/cit-conversions/{id}",
 					},
+					"absd_refund_claims": gin.H{
+						"list":    "/admin/absd-refund-claims",
+						"get":     "/admin/absd-refund-claims/{id}",
+						"approve": "/admin/absd-refund-claims/{id}/approve",
+						"reject":  "/admin/absd-refund-claims/{id}/reject",
+					},
+					"stamp_certificates": gin.H{
+						"revoke": "/admin/stamp-certificates/{docRefNo}/revoke",
+					},
 				},
 			},
 		})
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ABSD refund claim states
+const (
+	AbsdRefundStatusIntended  = "intended"
+	AbsdRefundStatusSubmitted = "submitted"
+	AbsdRefundStatusApproved  = "approved"
+	AbsdRefundStatusRejected  = "rejected"
+	AbsdRefundStatusExpired   = "expired"
+)
+
+// The previous home must be sold within this many months of the purchase of
+// a completed replacement home, or of TOP/CSC for an uncompleted one
+const absdRefundDisposalMonths = 6
+
+// ErrInvalidClaimTransition is returned when a claim is not in a state that
+// allows the requested change
+var ErrInvalidClaimTransition = errors.New("refund claim cannot move to the requested status")
+
+type AbsdRefundService struct {
+	db               *gorm.DB
+	stampDutyService *StampDutyService
+}
+
+func NewAbsdRefundService(db *gorm.DB, stampDutyService *StampDutyService) *AbsdRefundService {
+	return &AbsdRefundService{db: db, stampDutyService: stampDutyService}
+}
+
+// RecordIntent stores the refund intent declared when a replacement home is
+// stamped. The ABSD charged is taken from the computed duty breakdown, net
+// of any ABSD remission. The claim is written with tx, in the transaction
+// that records the stamped document, for the client that stamped it.
+func (s *AbsdRefundService) RecordIntent(tx *gorm.DB, clientID string, req *models.SalePurchaseBuyersRequest, docRefNo string, breakdown *models.EStampDutyBreakdown) (*models.AbsdRefundClaim, error) {
+	var absdPaid float64
+	for _, component := range breakdown.Components {
+		if component.DutyType == "ABSD" {
+			absdPaid += component.Duty
+		}
+	}
+	absdPaid -= remittedDuty(breakdown, "ABSD")
+
+	refundAssetsJSON, err := json.Marshal(req.AbsdRefundAssets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize refund assets: %w", err)
+	}
+
+	claim := &models.AbsdRefundClaim{
+		DocRefNo:          docRefNo,
+		ClientID:          clientID,
+		AssignID:          req.AssignID,
+		Status:            AbsdRefundStatusIntended,
+		DateOfDocument:    breakdown.DocumentDate,
+		CompletedProperty: req.AbsdRefundAssets.CompletedProperty,
+		AbsdPaid:          absdPaid,
+		RefundAssetsData:  string(refundAssetsJSON),
+	}
+
+	if s.isEligible(req) {
+		claim.RefundableAmount = absdPaid
+		claim.CpfRefundAmount, claim.CashRefundAmount = s.splitRefund(absdPaid, req.AbsdRefundAssets)
+	} else {
+		claim.Remarks = "Only married couples with at least one Singapore Citizen spouse may claim an ABSD refund"
+	}
+
+	// For completed homes the deadline runs from the document date; for
+	// uncompleted homes it is set once the TOP/CSC date is known
+	if claim.CompletedProperty {
+		documentDate, err := time.Parse("2006-01-02", breakdown.DocumentDate)
+		if err == nil {
+			claim.DeadlineDate = utils.AddMonths(documentDate, absdRefundDisposalMonths).Format("2006-01-02")
+		}
+	}
+
+	if err := tx.Create(claim).Error; err != nil {
+		return nil, fmt.Errorf("failed to store ABSD refund intent: %w", err)
+	}
+
+	return claim, nil
+}
+
+// SubmitClaim handles a follow-up refund claim for a previously stamped
+// replacement-home purchase. Only the client that stamped the purchase can
+// claim its refund.
+func (s *AbsdRefundService) SubmitClaim(clientID string, req *models.AbsdRefundClaimRequest) (*models.AbsdRefundClaimResponse, error) {
+	if strings.TrimSpace(req.DocRefNo) == "" {
+		return s.claimError(40001, "Missing required field", "docRefNo", "Document reference number is required"), nil
+	}
+
+	if !req.Declaration {
+		return s.claimError(40002, "Declaration required", "declaration", "Declaration must be accepted"), nil
+	}
+
+	dateOfDisposal, err := time.Parse("2006-01-02", req.DateOfDisposal)
+	if err != nil {
+		return s.claimError(40003, "Validation failed", "dateOfDisposal", "Date of disposal must be in YYYY-MM-DD format"), nil
+	}
+
+	// The claim is read and updated with its row locked, so concurrent
+	// submissions for a document are handled one at a time
+	var response *models.AbsdRefundClaimResponse
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if _, err := s.ExpireOverdueClaims(tx, now); err != nil {
+			return err
+		}
+
+		var claim models.AbsdRefundClaim
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("doc_ref_no = ? AND client_id = ?", req.DocRefNo, clientID).
+			First(&claim).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				response = &models.AbsdRefundClaimResponse{
+					ReturnCode: 20,
+					Info: &models.EStampInfo{
+						MessageCode: 20001,
+						Message:     "No ABSD refund intent recorded for this document",
+					},
+				}
+				return nil
+			}
+			return fmt.Errorf("failed to find ABSD refund claim: %w", err)
+		}
+
+		if claim.Status != AbsdRefundStatusIntended {
+			response = s.claimError(40004, "Refund claim already "+claim.Status, "docRefNo", "Only claims with a recorded intent can be submitted")
+			return nil
+		}
+
+		// The refund is for selling the previous home after buying the
+		// replacement; a home sold before the purchase attracted no ABSD
+		if req.DateOfDisposal < claim.DateOfDocument {
+			response = s.claimError(40003, "Validation failed", "dateOfDisposal", "Date of disposal cannot be before the purchase date "+claim.DateOfDocument)
+			return nil
+		}
+
+		if !claim.CompletedProperty && claim.DeadlineDate == "" {
+			dateOfCompletion, err := time.Parse("2006-01-02", req.DateOfCompletion)
+			if err != nil {
+				response = s.claimError(40005, "Validation failed", "dateOfCompletion", "TOP/CSC date in YYYY-MM-DD format is required for uncompleted properties")
+				return nil
+			}
+			if req.DateOfCompletion < claim.DateOfDocument {
+				response = s.claimError(40005, "Validation failed", "dateOfCompletion", "TOP/CSC date cannot be before the purchase date "+claim.DateOfDocument)
+				return nil
+			}
+			claim.DateOfCompletion = req.DateOfCompletion
+			claim.DeadlineDate = utils.AddMonths(dateOfCompletion, absdRefundDisposalMonths).Format("2006-01-02")
+		}
+
+		claim.DateOfDisposal = req.DateOfDisposal
+		claim.DisposalDocRefNo = req.DisposalDocRefNo
+		claim.SubmittedAt = &now
+
+		switch {
+		case claim.DeadlineDate != "" && dateOfDisposal.Format("2006-01-02") > claim.DeadlineDate:
+			claim.Status = AbsdRefundStatusExpired
+			claim.Remarks = "Previous home was not sold by " + claim.DeadlineDate
+		case claim.RefundableAmount == 0:
+			claim.Status = AbsdRefundStatusRejected
+			if claim.Remarks == "" {
+				claim.Remarks = "No refundable ABSD was paid on this document"
+			}
+		default:
+			claim.Status = AbsdRefundStatusSubmitted
+		}
+
+		if err := tx.Save(&claim).Error; err != nil {
+			return fmt.Errorf("failed to update ABSD refund claim: %w", err)
+		}
+
+		response = &models.AbsdRefundClaimResponse{
+			ReturnCode: 10,
+			Data:       s.claimData(&claim),
+		}
+		if claim.Status != AbsdRefundStatusSubmitted {
+			response.ReturnCode = 40
+			response.Info = &models.EStampInfo{
+				MessageCode: 40006,
+				Message:     "Refund claim " + claim.Status,
+				FieldInfoList: []models.EStampFieldError{
+					{
+						Field:   "docRefNo",
+						Message: claim.Remarks,
+					},
+				},
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ExpireOverdueClaims moves intended claims whose disposal deadline has
+// passed to the expired state. It runs with tx on the submit and decide
+// paths, so listing claims never writes.
+func (s *AbsdRefundService) ExpireOverdueClaims(tx *gorm.DB, asOf time.Time) (int64, error) {
+	result := tx.Model(&models.AbsdRefundClaim{}).
+		Where("status = ? AND deadline_date <> '' AND deadline_date < ?", AbsdRefundStatusIntended, asOf.Format("2006-01-02")).
+		Updates(map[string]interface{}{
+			"status":  AbsdRefundStatusExpired,
+			"remarks": "Refund deadline passed without a claim",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// isEligible reports whether the buyers qualify for the replacement-home
+// refund: a married couple with at least one Singapore Citizen spouse
+func (s *AbsdRefundService) isEligible(req *models.SalePurchaseBuyersRequest) bool {
+	if req.MaritalStatus.MarriedCouple != 1 {
+		return false
+	}
+	for _, buyer := range req.BuyerTransferee {
+		if s.stampDutyService.ABSDProfile(buyer) == ABSDProfileCitizen {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRefund refunds CPF monies used to pay ABSD first, with any balance
+// paid in cash
+func (s *AbsdRefundService) splitRefund(refundable float64, assets models.AbsdRefundAssetsData) (float64, float64) {
+	var cpfUsed float64
+	for _, recipient := range assets.CpfRecipient {
+		cpfUsed += recipient.CpfAmountUsed
+	}
+
+	cpfRefund := cpfUsed
+	if cpfRefund > refundable {
+		cpfRefund = refundable
+	}
+	return cpfRefund, refundable - cpfRefund
+}
+
+func (s *AbsdRefundService) claimData(claim *models.AbsdRefundClaim) *models.AbsdRefundClaimData {
+	return &models.AbsdRefundClaimData{
+		DocRefNo:         claim.DocRefNo,
+		Status:           claim.Status,
+		DeadlineDate:     claim.DeadlineDate,
+		AbsdPaid:         claim.AbsdPaid,
+		RefundableAmount: claim.RefundableAmount,
+		CpfRefundAmount:  claim.CpfRefundAmount,
+		CashRefundAmount: claim.CashRefundAmount,
+	}
+}
+
+func (s *AbsdRefundService) claimError(messageCode int, message, field, fieldMessage string) *models.AbsdRefundClaimResponse {
+	return &models.AbsdRefundClaimResponse{
+		ReturnCode: 40,
+		Info: &models.EStampInfo{
+			MessageCode: messageCode,
+			Message:     message,
+			FieldInfoList: []models.EStampFieldError{
+				{
+					Field:   field,
+					Message: fieldMessage,
+				},
+			},
+		},
+	}
+}
+
+// Admin operations for ABSD refund claims
+
+// GetAbsdRefundClaims retrieves refund claims with pagination, optionally
+// filtered by status
+func (s *AbsdRefundService) GetAbsdRefundClaims(status string, offset, limit int) ([]models.AbsdRefundClaim, int64, error) {
+	var claims []models.AbsdRefundClaim
+	var total int64
+
+	query := s.db.Model(&models.AbsdRefundClaim{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get records with pagination
+	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&claims).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return claims, total, nil
+}
+
+// GetAbsdRefundClaimByID retrieves a refund claim by ID
+func (s *AbsdRefundService) GetAbsdRefundClaimByID(id uint) (*models.AbsdRefundClaim, error) {
+	var claim models.AbsdRefundClaim
+	if err := s.db.First(&claim, id).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// DecideAbsdRefundClaim approves or rejects a submitted refund claim
+func (s *AbsdRefundService) DecideAbsdRefundClaim(id uint, approve bool, decidedBy, remarks string) (*models.AbsdRefundClaim, error) {
+	var claim models.AbsdRefundClaim
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if _, err := s.ExpireOverdueClaims(tx, now); err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, id).Error; err != nil {
+			return err
+		}
+
+		if claim.Status != AbsdRefundStatusSubmitted {
+			return ErrInvalidClaimTransition
+		}
+
+		claim.Status = AbsdRefundStatusRejected
+		if approve {
+			claim.Status = AbsdRefundStatusApproved
+		}
+		claim.DecidedAt = &now
+		claim.DecidedBy = decidedBy
+		if remarks != "" {
+			claim.Remarks = remarks
+		}
+
+		if err := tx.Save(&claim).Error; err != nil {
+			return fmt.Errorf("failed to update ABSD refund claim: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &claim, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func seedRefundIntent(t *testing.T, db *gorm.DB, claim models.AbsdRefundClaim) models.AbsdRefundClaim {
+	t.Helper()
+	claim.Status = AbsdRefundStatusIntended
+	if err := db.Create(&claim).Error; err != nil {
+		t.Fatalf("failed to seed refund intent: %v", err)
+	}
+	return claim
+}
+
+func TestSubmitClaim(t *testing.T) {
+	db := newTestDB(t)
+	service := NewAbsdRefundService(db, nil)
+
+	today := time.Now()
+	purchased := today.AddDate(0, -2, 0).Format("2006-01-02")
+	deadline := today.AddDate(0, 4, 0).Format("2006-01-02")
+	seedRefundIntent(t, db, models.AbsdRefundClaim{DocRefNo: "SP1", ClientID: "client", DateOfDocument: purchased, CompletedProperty: true, DeadlineDate: deadline, AbsdPaid: 80000, RefundableAmount: 80000})
+	seedRefundIntent(t, db, models.AbsdRefundClaim{DocRefNo: "SP2", ClientID: "client", DateOfDocument: purchased, AbsdPaid: 80000, RefundableAmount: 80000})
+
+	tests := []struct {
+		name            string
+		clientID        string
+		req             models.AbsdRefundClaimRequest
+		wantReturnCode  int
+		wantMessageCode int
+		wantField       string
+	}{
+		{
+			name:            "another client's document",
+			clientID:        "other-client",
+			req:             models.AbsdRefundClaimRequest{DocRefNo: "SP1", DateOfDisposal: today.Format("2006-01-02"), Declaration: true},
+			wantReturnCode:  20,
+			wantMessageCode: 20001,
+		},
+		{
+			name:            "disposal before the purchase",
+			clientID:        "client",
+			req:             models.AbsdRefundClaimRequest{DocRefNo: "SP1", DateOfDisposal: today.AddDate(0, -3, 0).Format("2006-01-02"), Declaration: true},
+			wantReturnCode:  40,
+			wantMessageCode: 40003,
+			wantField:       "dateOfDisposal",
+		},
+		{
+			name:            "TOP before the purchase",
+			clientID:        "client",
+			req:             models.AbsdRefundClaimRequest{DocRefNo: "SP2", DateOfCompletion: today.AddDate(0, -3, 0).Format("2006-01-02"), DateOfDisposal: today.Format("2006-01-02"), Declaration: true},
+			wantReturnCode:  40,
+			wantMessageCode: 40005,
+			wantField:       "dateOfCompletion",
+		},
+		{
+			name:           "claim submitted",
+			clientID:       "client",
+			req:            models.AbsdRefundClaimRequest{DocRefNo: "SP1", DateOfDisposal: today.Format("2006-01-02"), Declaration: true},
+			wantReturnCode: 10,
+		},
+		{
+			name:            "claim already submitted",
+			clientID:        "client",
+			req:             models.AbsdRefundClaimRequest{DocRefNo: "SP1", DateOfDisposal: today.Format("2006-01-02"), Declaration: true},
+			wantReturnCode:  40,
+			wantMessageCode: 40004,
+			wantField:       "docRefNo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := service.SubmitClaim(tt.clientID, &tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.ReturnCode != tt.wantReturnCode {
+				t.Fatalf("ReturnCode = %d, want %d (%+v)", response.ReturnCode, tt.wantReturnCode, response.Info)
+			}
+			if tt.wantMessageCode == 0 {
+				return
+			}
+			if response.Info == nil || response.Info.MessageCode != tt.wantMessageCode {
+				t.Fatalf("Info = %+v, want MessageCode %d", response.Info, tt.wantMessageCode)
+			}
+			if tt.wantField != "" && (len(response.Info.FieldInfoList) == 0 || response.Info.FieldInfoList[0].Field != tt.wantField) {
+				t.Errorf("FieldInfoList = %+v, want field %q", response.Info.FieldInfoList, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestOverdueClaimsExpireOnSubmitOnly(t *testing.T) {
+	db := newTestDB(t)
+	service := NewAbsdRefundService(db, nil)
+
+	today := time.Now()
+	overdue := seedRefundIntent(t, db, models.AbsdRefundClaim{DocRefNo: "SP1", ClientID: "client", DateOfDocument: today.AddDate(-1, 0, 0).Format("2006-01-02"), CompletedProperty: true, DeadlineDate: today.AddDate(0, -6, 0).Format("2006-01-02"), RefundableAmount: 80000})
+	seedRefundIntent(t, db, models.AbsdRefundClaim{DocRefNo: "SP2", ClientID: "client", DateOfDocument: today.AddDate(0, -1, 0).Format("2006-01-02"), CompletedProperty: true, DeadlineDate: today.AddDate(0, 5, 0).Format("2006-01-02"), RefundableAmount: 80000})
+
+	// Listing claims leaves their status alone
+	if _, _, err := service.GetAbsdRefundClaims("", 0, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claim, err := service.GetAbsdRefundClaimByID(overdue.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claim.Status != AbsdRefundStatusIntended {
+		t.Fatalf("listing changed the status to %q", claim.Status)
+	}
+
+	// Submitting any claim expires the overdue intents first
+	response, err := service.SubmitClaim("client", &models.AbsdRefundClaimRequest{DocRefNo: "SP2", DateOfDisposal: today.Format("2006-01-02"), Declaration: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.ReturnCode != 10 {
+		t.Fatalf("ReturnCode = %d, want 10 (%+v)", response.ReturnCode, response.Info)
+	}
+	claim, err = service.GetAbsdRefundClaimByID(overdue.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claim.Status != AbsdRefundStatusExpired {
+		t.Errorf("overdue claim status = %q, want %q", claim.Status, AbsdRefundStatusExpired)
+	}
+}
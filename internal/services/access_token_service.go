@@ -0,0 +1,190 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Access token scopes granted by CorpPass and SingPass
+const (
+	ScopeEmpIncomeSub    = "EmpIncomeSub"
+	ScopeGSTReturnsSub   = "GSTReturnsSub"
+	ScopeGSTTransListSub = "GSTTransListSub"
+)
+
+var (
+	// ErrAccessTokenInvalid is returned for a token that was never issued or
+	// is no longer active
+	ErrAccessTokenInvalid = errors.New("access token is not valid")
+	// ErrAccessTokenExpired is returned for a token past its expiry
+	ErrAccessTokenExpired = errors.New("access token has expired")
+	// ErrScopeNotGranted is returned when a token does not carry the scope an
+	// API requires
+	ErrScopeNotGranted = errors.New("access token does not carry the required scope")
+	// ErrAuthStateInvalid is returned when a token is requested for an
+	// authentication state that was never issued to the client or has
+	// already been exchanged
+	ErrAuthStateInvalid = errors.New("authentication state is not valid")
+)
+
+// corpPassTokenLifetime is how long a CorpPass access token is valid, in
+// seconds
+const corpPassTokenLifetime = 3600
+
+// AccessTokenService records the CorpPass tokens issued by the simulated
+// CorpPass flow and checks the scopes of CorpPass and SingPass tokens
+// presented to submission APIs
+type AccessTokenService struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenService(db *gorm.DB) *AccessTokenService {
+	return &AccessTokenService{db: db}
+}
+
+// RecordCorpPassAuth stores a CorpPass authentication request, generating
+// its state when none is given. The token later issued for the state gets
+// the request's scope and entity.
+func (s *AccessTokenService) RecordCorpPassAuth(record *models.CorpPassAuthRecord) error {
+	if record.State == "" {
+		state, err := randomToken(16)
+		if err != nil {
+			return err
+		}
+		record.State = state
+	}
+	record.Status = "pending"
+	return s.db.Create(record).Error
+}
+
+// IssueCorpPassToken exchanges a pending authentication state for an access
+// token carrying the scope and entity that were authenticated. Each state
+// can be exchanged once, and only by the client it was issued to.
+func (s *AccessTokenService) IssueCorpPassToken(clientID, state string, corpPassID int64) (*models.CorpPassTokenData, error) {
+	accessToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.CorpPassTokenData{
+		AccessToken:  "CP_AT_" + accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    corpPassTokenLifetime,
+		RefreshToken: "CP_RT_" + refreshToken,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var auth models.CorpPassAuthRecord
+		err := tx.Where("state = ? AND client_id = ? AND status = ?", state, clientID, "pending").First(&auth).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAuthStateInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		// Claiming the state only succeeds once, however many requests race
+		claim := tx.Model(&models.CorpPassAuthRecord{}).Where("id = ? AND status = ?", auth.ID, "pending").Update("status", "completed")
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return ErrAuthStateInvalid
+		}
+
+		data.Scope = auth.Scope
+		return tx.Create(&models.CorpPassTokenRecord{
+			CorpPassID:   corpPassID,
+			ClientID:     clientID,
+			EntityID:     auth.EntityID,
+			AccessToken:  data.AccessToken,
+			TokenType:    data.TokenType,
+			ExpiresIn:    data.ExpiresIn,
+			RefreshToken: data.RefreshToken,
+			Scope:        data.Scope,
+			Status:       "active",
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CheckScope checks that an access token was issued by CorpPass or SingPass,
+// is still active and carries the scope. A CorpPass token must also have
+// been issued to the client presenting it; the UEN of the entity it acts for
+// is returned. SingPass tokens are not tied to an entity and return an empty
+// UEN.
+func (s *AccessTokenService) CheckScope(clientID, accessToken, scope string) (string, error) {
+	var corpPassToken models.CorpPassTokenRecord
+	err := s.db.Where("access_token = ?", accessToken).First(&corpPassToken).Error
+	if err == nil {
+		if corpPassToken.ClientID != clientID || corpPassToken.EntityID == "" {
+			return "", ErrAccessTokenInvalid
+		}
+		if err := checkTokenGrant(corpPassToken.Status, corpPassToken.CreatedAt, corpPassToken.ExpiresIn, corpPassToken.Scope, scope); err != nil {
+			return "", err
+		}
+		return corpPassToken.EntityID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	var singPassToken models.SingPassTokenRecord
+	err = s.db.Where("access_token = ?", accessToken).Order("created_at DESC").First(&singPassToken).Error
+	if err == nil {
+		return "", checkTokenGrant(singPassToken.Status, singPassToken.CreatedAt, singPassToken.ExpiresIn, singPassToken.Scope, scope)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrAccessTokenInvalid
+	}
+	return "", err
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// checkTokenGrant checks an issued token's status, expiry and scopes
+func checkTokenGrant(status string, issuedAt time.Time, expiresIn int, granted, scope string) error {
+	if status != "active" {
+		return ErrAccessTokenInvalid
+	}
+	if time.Now().After(issuedAt.Add(time.Duration(expiresIn) * time.Second)) {
+		return ErrAccessTokenExpired
+	}
+	if !hasScope(granted, scope) {
+		return ErrScopeNotGranted
+	}
+	return nil
+}
+
+// hasScope reports whether a granted scope list includes a scope. Scopes
+// are separated by +, commas or spaces, as issued by SingPass and CorpPass.
+func hasScope(granted, scope string) bool {
+	for _, grantedScope := range strings.FieldsFunc(granted, func(r rune) bool {
+		return r == '+' || r == ',' || r == ' '
+	}) {
+		if grantedScope == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestIssueCorpPassToken(t *testing.T) {
+	service := NewAccessTokenService(newTestDB(t))
+
+	auth := &models.CorpPassAuthRecord{ClientID: "client", EntityID: "201912345R", Scope: ScopeGSTReturnsSub}
+	if err := service.RecordCorpPassAuth(auth); err != nil {
+		t.Fatalf("failed to record authentication: %v", err)
+	}
+	if auth.State == "" {
+		t.Fatal("no state was generated")
+	}
+	other := &models.CorpPassAuthRecord{ClientID: "client", EntityID: "201912345R", Scope: ScopeGSTReturnsSub}
+	if err := service.RecordCorpPassAuth(other); err != nil {
+		t.Fatalf("failed to record authentication: %v", err)
+	}
+
+	if _, err := service.IssueCorpPassToken("other-client", auth.State, 1); !errors.Is(err, ErrAuthStateInvalid) {
+		t.Errorf("another client's state: got %v, want ErrAuthStateInvalid", err)
+	}
+	if _, err := service.IssueCorpPassToken("client", "unknown", 1); !errors.Is(err, ErrAuthStateInvalid) {
+		t.Errorf("unknown state: got %v, want ErrAuthStateInvalid", err)
+	}
+
+	token, err := service.IssueCorpPassToken("client", auth.State, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token.Scope != ScopeGSTReturnsSub {
+		t.Errorf("scope = %q, want the authenticated %q", token.Scope, ScopeGSTReturnsSub)
+	}
+	if _, err := service.IssueCorpPassToken("client", auth.State, 1); !errors.Is(err, ErrAuthStateInvalid) {
+		t.Errorf("reused state: got %v, want ErrAuthStateInvalid", err)
+	}
+
+	// Tokens for the same CorpPass ID are not predictable from one another
+	second, err := service.IssueCorpPassToken("client", other.State, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.AccessToken == token.AccessToken || second.RefreshToken == token.RefreshToken {
+		t.Errorf("tokens repeat: %+v and %+v", token, second)
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	db := newTestDB(t)
+	service := NewAccessTokenService(db)
+
+	auth := &models.CorpPassAuthRecord{ClientID: "client", EntityID: "201912345R", Scope: ScopeGSTReturnsSub + "," + ScopeGSTTransListSub}
+	if err := service.RecordCorpPassAuth(auth); err != nil {
+		t.Fatalf("failed to record authentication: %v", err)
+	}
+	token, err := service.IssueCorpPassToken("client", auth.State, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	singPass := &models.SingPassTokenRecord{Code: "code", State: "state", AccessToken: "SP_AT_1", ExpiresIn: 3600, Scope: ScopeGSTReturnsSub, Status: "active"}
+	if err := db.Create(singPass).Error; err != nil {
+		t.Fatalf("failed to record SingPass token: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		clientID     string
+		accessToken  string
+		scope        string
+		wantEntityID string
+		wantErr      error
+	}{
+		{"CorpPass token", "client", token.AccessToken, ScopeGSTTransListSub, "201912345R", nil},
+		{"scope not authenticated", "client", token.AccessToken, ScopeEmpIncomeSub, "", ErrScopeNotGranted},
+		{"another client's token", "other-client", token.AccessToken, ScopeGSTReturnsSub, "", ErrAccessTokenInvalid},
+		{"SingPass token", "client", "SP_AT_1", ScopeGSTReturnsSub, "", nil},
+		{"unknown token", "client", "corppass_access_token_1_demo_12345", ScopeGSTReturnsSub, "", ErrAccessTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entityID, err := service.CheckScope(tt.clientID, tt.accessToken, tt.scope)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if entityID != tt.wantEntityID {
+				t.Errorf("entity = %q, want %q", entityID, tt.wantEntityID)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"api-iras/internal/models"
+	"api-iras/pkg/utils"
 )
 
 type AISService struct {
@@ -51,6 +52,22 @@ func (s *AISService) SearchOrganization(ctx context.Context, req models.AISorgSe
 		}, nil
 	}
 
+	if !utils.IsValidTaxRefID(req.OrganizationID) {
+		return &models.AISorgSearchResponse{
+			ReturnCode: 40,
+			Info: &models.AISorgInfo{
+				Message:     "Invalid organization ID",
+				MessageCode: "40002",
+				FieldInfoList: []models.AISorgFieldError{
+					{
+						Field:   "organizationID",
+						Message: "Organization ID must be a valid UEN, NRIC or FIN",
+					},
+				},
+			},
+		}, nil
+	}
+
 	// Validate basis year (should be a valid year)
 	if req.BasisYear < 1900 || req.BasisYear > 2100 {
 		return &models.AISorgSearchResponse{
@@ -70,7 +87,7 @@ func (s *AISService) SearchOrganization(ctx context.Context, req models.AISorgSe
 
 	// For demo purposes, simulate organization search logic
 	// In real implementation, this would call external IRAS API
-	organizationInAIS := s.checkOrganizationInAIS(req.ClientID, req.OrganizationID, req.BasisYear)
+	organizationInAIS := s.checkOrganizationInAIS(req.ClientID, utils.NormalizeTaxID(req.OrganizationID), req.BasisYear)
 
 	// Return successful response
 	return &models.AISorgSearchResponse{
@@ -90,9 +107,9 @@ func (s *AISService) checkOrganizationInAIS(clientID, organizationID string, bas
 
 	// Demo logic: Return "Y" for specific test cases, "N" for others
 	testOrganizations := map[string]bool{
-		"4396029847797760": true,  // Test organization exists
-		"1234567890123456": true,  // Another test organization
-		"9999999999999999": false, // Test organization that doesn't exist
+		"199912345R": true,  // Test organization exists
+		"53000001J":  true,  // Another test organization
+		"201800001D": false, // Test organization that doesn't exist
 	}
 
 	if exists, found := testOrganizations[organizationID]; found && exists {
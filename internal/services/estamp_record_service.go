@@ -0,0 +1,379 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EStampSubmission describes a document being stamped through one of the
+// eStamp endpoints
+type EStampSubmission struct {
+	DocumentType            string
+	DocDescription          string
+	AssignID                string
+	IsSignedInSingapore     bool
+	ReceivingDateOfDocument string
+	Request                 interface{}
+	Parties                 []models.EStampRecordParty
+	Assets                  models.AssetsData
+	TrustDetails            *models.TrusteeBeneficiaryData
+}
+
+// TenancySubmission describes a tenancy agreement for recording
+func TenancySubmission(req *models.StampTenancyAgreementRequest) *EStampSubmission {
+	submission := &EStampSubmission{
+		DocumentType:            DocumentTypeTenancy,
+		DocDescription:          req.DocumentDescription,
+		AssignID:                req.AssignID,
+		IsSignedInSingapore:     req.IsSignedInSingapore,
+		ReceivingDateOfDocument: req.ReceivingDateOfDocument,
+		Request:                 req,
+		Assets:                  req.Assets,
+	}
+	submission.Parties = append(submission.Parties, recordParties(PartyRoleLessor, req.LandlordLessor)...)
+	submission.Parties = append(submission.Parties, recordParties(PartyRoleLessee, req.TenantLessee)...)
+	return submission
+}
+
+// ShareTransferSubmission describes a share transfer for recording
+func ShareTransferSubmission(req *models.ShareTransferRequest) *EStampSubmission {
+	submission := &EStampSubmission{
+		DocumentType:            DocumentTypeShareTransfer,
+		DocDescription:          req.DocumentDescription,
+		AssignID:                req.AssignID,
+		IsSignedInSingapore:     req.IsSignedInSingapore,
+		ReceivingDateOfDocument: req.ReceivingDateOfDocument,
+		Request:                 req,
+		Assets:                  req.Assets,
+	}
+	submission.Parties = append(submission.Parties, recordParties(PartyRoleTransferor, req.Transferor)...)
+	submission.Parties = append(submission.Parties, recordParties(PartyRoleTransferee, req.Transferee)...)
+	return submission
+}
+
+// MortgageSubmission describes a mortgage for recording
+func MortgageSubmission(req *models.StampMortgageRequest) *EStampSubmission {
+	submission := &EStampSubmission{
+		DocumentType:            DocumentTypeMortgage,
+		DocDescription:          req.DocumentDescription,
+		AssignID:                req.AssignID,
+		IsSignedInSingapore:     req.IsSignedInSingapore,
+		ReceivingDateOfDocument: req.ReceivingDateOfDocument,
+		Request:                 req,
+		Assets: models.AssetsData{
+			Properties:   req.Assets.Properties,
+			Lands:        req.Assets.Lands,
+			StocksShares: req.Assets.StocksShares,
+			Security:     req.Assets.Security,
+		},
+	}
+	for _, party := range req.Mortgagors {
+		submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleMortgagor, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	for _, party := range req.Mortgagees {
+		submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleMortgagee, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	return submission
+}
+
+// BuyersSubmission describes a sale and purchase document for buyers for
+// recording
+func BuyersSubmission(req *models.SalePurchaseBuyersRequest) *EStampSubmission {
+	submission := &EStampSubmission{
+		DocumentType:            DocumentTypeBuyers,
+		DocDescription:          req.DocumentDescription,
+		AssignID:                req.AssignID,
+		IsSignedInSingapore:     req.IsSignedInSingapore,
+		ReceivingDateOfDocument: req.ReceivingDateOfDocument,
+		Request:                 req,
+		Assets: models.AssetsData{
+			Properties:   req.Assets.Properties,
+			Lands:        req.Assets.Lands,
+			StocksShares: req.Assets.StocksShares,
+			Security:     req.Assets.Security,
+		},
+	}
+	for _, party := range req.SellerTransferor {
+		submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleSeller, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	for _, party := range req.BuyerTransferee {
+		submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleBuyer, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	if req.HasIntentToHoldThePropInTrustForBeneficialOwner {
+		for _, party := range req.Trustee {
+			submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleTrustee, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+		}
+		for _, party := range req.Beneficiary {
+			name := party.FTTaxEntityName
+			if party.BeneficiaryIsUnidentifiable {
+				name = party.FTDescription
+			}
+			submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleBeneficiary, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: name})
+		}
+		submission.TrustDetails = &req.TrusteeBeneficiaryDetails
+	}
+	return submission
+}
+
+// SellersSubmission describes a sale and purchase document for sellers for
+// recording
+func SellersSubmission(req *models.SalePurchaseSellersRequest) *EStampSubmission {
+	submission := &EStampSubmission{
+		DocumentType:            DocumentTypeSellers,
+		DocDescription:          req.DocumentDescription,
+		AssignID:                req.AssignID,
+		IsSignedInSingapore:     req.IsSignedInSingapore,
+		ReceivingDateOfDocument: req.ReceivingDateOfDocument,
+		Request:                 req,
+		Assets: models.AssetsData{
+			Properties:   req.Assets.Properties,
+			Lands:        req.Assets.Lands,
+			StocksShares: req.Assets.StocksShares,
+			Security:     req.Assets.Security,
+		},
+	}
+	for _, party := range req.SellerTransferor {
+		submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleSeller, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	for _, party := range req.BuyerTransferee {
+		submission.Parties = append(submission.Parties, models.EStampRecordParty{Role: PartyRoleBuyer, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	return submission
+}
+
+func recordParties(role string, parties []models.PartyData) []models.EStampRecordParty {
+	result := make([]models.EStampRecordParty, 0, len(parties))
+	for _, party := range parties {
+		result = append(result, models.EStampRecordParty{Role: role, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, TaxEntityIDType: party.TaxEntityIDType, TaxEntityIDNo: party.TaxEntityIDNo, Name: party.FTTaxEntityName})
+	}
+	return result
+}
+
+// ErrCertificateAlreadyRevoked is returned when revoking a revoked certificate
+var ErrCertificateAlreadyRevoked = errors.New("stamp certificate is already revoked")
+
+type EStampRecordService struct {
+	db                 *gorm.DB
+	certificateService *StampCertificateService
+}
+
+func NewEStampRecordService(db *gorm.DB, certificateService *StampCertificateService) *EStampRecordService {
+	return &EStampRecordService{db: db, certificateService: certificateService}
+}
+
+// Transaction runs fn in a database transaction, so a stamping and the
+// records written alongside it are committed together
+func (s *EStampRecordService) Transaction(fn func(tx *gorm.DB) error) error {
+	return s.db.Transaction(fn)
+}
+
+// RecordStamping stores a stamped document together with its computed duty
+// and issues its stamp certificate reference
+func (s *EStampRecordService) RecordStamping(tx *gorm.DB, submission *EStampSubmission, docRefNo string, breakdown *models.EStampDutyBreakdown, paymentDue time.Time) (*models.EStampRecord, error) {
+	docRefNumber, err := strconv.ParseInt(strings.TrimLeft(docRefNo, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document reference %q: %w", docRefNo, err)
+	}
+
+	stampCertRef, err := s.generateStampCertRef()
+	if err != nil {
+		return nil, err
+	}
+
+	requestJSON, err := json.Marshal(submission.Request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize eStamp request: %w", err)
+	}
+	breakdownJSON, err := json.Marshal(breakdown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize duty breakdown: %w", err)
+	}
+	partiesJSON, err := json.Marshal(submission.Parties)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize parties: %w", err)
+	}
+	assetsJSON, err := json.Marshal(submission.Assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize assets: %w", err)
+	}
+
+	record := &models.EStampRecord{
+		DocRefNo:           docRefNo,
+		DocRefNumber:       docRefNumber,
+		StampCertRef:       stampCertRef,
+		DocumentType:       submission.DocumentType,
+		DocDescription:     submission.DocDescription,
+		AssignID:           submission.AssignID,
+		DateOfDocument:     breakdown.DocumentDate,
+		SDAmount:           breakdown.TotalDuty,
+		SDRemission:        remittedDuty(breakdown, ""),
+		TotalAmtPayable:    breakdown.TotalDuty,
+		PaymentDueDate:     paymentDue.Format("2006-01-02"),
+		StampCertIssueDate: time.Now(),
+		RequestData:        string(requestJSON),
+		DutyBreakdownData:  string(breakdownJSON),
+		PartiesData:        string(partiesJSON),
+		AssetsData:         string(assetsJSON),
+	}
+	if submission.TrustDetails != nil {
+		trustJSON, err := json.Marshal(submission.TrustDetails)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize trust details: %w", err)
+		}
+		record.TrustDetailsData = string(trustJSON)
+	}
+	if breakdown.Penalty != nil {
+		record.SDPenalty = breakdown.Penalty.Amount
+		record.TotalAmtPayable += breakdown.Penalty.Amount
+	}
+
+	if err := s.certificateService.Sign(record); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to store eStamp record: %w", err)
+	}
+
+	return record, nil
+}
+
+// FindByCertificate retrieves the stamped document matching both the
+// document reference number and the stamp certificate reference
+func (s *EStampRecordService) FindByCertificate(docRefNumber int64, stampCertRef string) (*models.EStampRecord, error) {
+	var record models.EStampRecord
+	err := s.db.Where("doc_ref_number = ? AND stamp_cert_ref = ?", docRefNumber, strings.ToUpper(strings.TrimSpace(stampCertRef))).
+		First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// FindByDocRefNo retrieves a stamped document by its document reference
+func (s *EStampRecordService) FindByDocRefNo(docRefNo string) (*models.EStampRecord, error) {
+	var record models.EStampRecord
+	if err := s.db.Where("doc_ref_no = ?", strings.TrimSpace(docRefNo)).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// RevokeCertificate revokes the stamp certificate of a stamped document
+func (s *EStampRecordService) RevokeCertificate(docRefNo, revokedBy, reason string) (*models.EStampRecord, error) {
+	record, err := s.FindByDocRefNo(docRefNo)
+	if err != nil {
+		return nil, err
+	}
+	if record.RevokedAt != nil {
+		return nil, ErrCertificateAlreadyRevoked
+	}
+
+	now := time.Now()
+	record.RevokedAt = &now
+	record.RevokedBy = revokedBy
+	record.RevocationReason = reason
+
+	if err := s.db.Save(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke stamp certificate: %w", err)
+	}
+	return record, nil
+}
+
+// AuthenticityData builds the stamp certificate details returned by the
+// authenticity check from a stored record
+func (s *EStampRecordService) AuthenticityData(record *models.EStampRecord) (*models.SCAuthenticityData, error) {
+	var breakdown models.EStampDutyBreakdown
+	if err := json.Unmarshal([]byte(record.DutyBreakdownData), &breakdown); err != nil {
+		return nil, fmt.Errorf("failed to read duty breakdown: %w", err)
+	}
+	var assets models.AssetsData
+	if err := json.Unmarshal([]byte(record.AssetsData), &assets); err != nil {
+		return nil, fmt.Errorf("failed to read assets: %w", err)
+	}
+
+	data := &models.SCAuthenticityData{
+		AppRefNo:           record.DocRefNo,
+		AssmtType:          "eStamping",
+		CertType:           record.DocumentType,
+		DocDescription:     record.DocDescription,
+		DocRefNo:           float64(record.DocRefNumber),
+		DocVerNo:           1,
+		Penalty:            record.SDPenalty,
+		SDAmount:           record.SDAmount,
+		Securities:         []string{},
+		StampCertIssueDate: record.StampCertIssueDate.Format("2/1/2006"),
+		StampCertRef:       record.StampCertRef,
+		TotalAmtPayable:    record.TotalAmtPayable,
+		PropertyList:       []models.SCAuthPropertyData{},
+		StockSharesList:    []models.SCAuthStockSharesData{},
+		VacantLandList:     []models.SCAuthVacantLandData{},
+	}
+
+	if documentDate, err := time.Parse("2006-01-02", record.DateOfDocument); err == nil {
+		data.DateOfDoc = documentDate.Format("2/1/2006")
+	}
+
+	for _, component := range breakdown.Components {
+		switch component.DutyType {
+		case "BSD":
+			data.BuyerSD += component.Duty
+		case "ABSD":
+			data.AddBuyerSD += component.Duty
+		}
+	}
+
+	for _, property := range assets.Properties {
+		unitLevel := ""
+		if len(property.LevelUnits) > 0 {
+			unitLevel = fmt.Sprintf("#%s-%s", property.LevelUnits[0].FloorNo, property.LevelUnits[0].UnitNo)
+		}
+		data.PropertyList = append(data.PropertyList, models.SCAuthPropertyData{
+			BlkHseNo:   property.BlockNo,
+			PostalCode: property.PostalCode,
+			Street:     property.StreetName,
+			UnitLevel:  unitLevel,
+		})
+	}
+	for _, land := range assets.Lands {
+		parcelNo, _ := strconv.ParseFloat(land.FTPlOrPtParcelNo, 64)
+		data.VacantLandList = append(data.VacantLandList, models.SCAuthVacantLandData{
+			LotNo:        land.FTLotNo,
+			MkTSNo:       land.MKOrTSNo,
+			PlPTParcelNo: parcelNo,
+			StreetName:   land.StreetName,
+		})
+	}
+	for _, stockShare := range assets.StocksShares {
+		data.StockSharesList = append(data.StockSharesList, models.SCAuthStockSharesData{
+			EntityID:       stockShare.TaxEntityID,
+			EntityType:     strconv.Itoa(stockShare.EntityType),
+			NameOfCompany:  stockShare.FTCompanyName,
+			NoStocksShares: float64(stockShare.NoStockShares),
+		})
+	}
+	for _, security := range assets.Security {
+		data.Securities = append(data.Securities, security.FTDescription)
+	}
+
+	return data, nil
+}
+
+// generateStampCertRef generates a random, unguessable stamp certificate
+// reference
+func (s *EStampRecordService) generateStampCertRef() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate stamp certificate reference: %w", err)
+	}
+	return "SC" + strings.ToUpper(hex.EncodeToString(buf)), nil
+}
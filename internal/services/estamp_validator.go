@@ -0,0 +1,295 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	postalCodePattern = regexp.MustCompile(`^\d{6}$`)
+
+	// taxEntityIDChecks verify the format and check character of the identity
+	// numbers that can be checked, keyed by TaxEntityIDType
+	taxEntityIDChecks = map[int]struct {
+		Label string
+		Check func(string) bool
+	}{
+		TaxEntityIDTypeNRIC:            {Label: "NRIC", Check: utils.IsValidNRIC},
+		TaxEntityIDTypeFIN:             {Label: "FIN", Check: utils.IsValidFIN},
+		TaxEntityIDTypeUENBusiness:     {Label: "UEN", Check: utils.IsValidBusinessUEN},
+		TaxEntityIDTypeUENLocalCompany: {Label: "UEN", Check: utils.IsValidLocalCompanyUEN},
+		TaxEntityIDTypeUENOthers:       {Label: "UEN", Check: utils.IsValidOtherUEN},
+	}
+)
+
+// requestValidate is the one validator shared by every request check. It
+// is safe for concurrent use once built, and a rule that fails to register
+// stops the program at start-up rather than leaving a tag unchecked.
+var requestValidate *validator.Validate
+
+func init() {
+	validate, err := newTaggedValidator()
+	if err != nil {
+		panic(fmt.Sprintf("failed to register request validations: %v", err))
+	}
+
+	validate.RegisterStructValidation(validateRequest,
+		models.StampTenancyAgreementRequest{}, models.ShareTransferRequest{}, models.StampMortgageRequest{},
+		models.SalePurchaseBuyersRequest{}, models.SalePurchaseSellersRequest{})
+	validate.RegisterStructValidation(validateUniqueSequences,
+		models.AssetsData{}, models.MortgageAssetsData{}, models.SalePurchaseAssetsData{}, models.PropertyData{}, models.LandData{})
+	validate.RegisterStructValidation(validateLeasePeriod, models.RentalDetailData{})
+	validate.RegisterStructValidation(validatePartyID,
+		models.PartyData{}, models.MortgagePartyData{}, models.SalePurchasePartyData{}, models.SalePurchaseAdvancedParty{}, models.LawyerData{})
+	validate.RegisterStructValidation(validateMailingAddress, models.MailingAddressData{}, models.AdvancedMailingAddressData{})
+
+	requestValidate = validate
+}
+
+// EStampValidator checks the structure of eStamp request payloads before any
+// duty is calculated. Field rules come from the validate tags on the request
+// models; rules spanning several fields are registered per struct. Every
+// problem is reported at once, against the JSON path of the field.
+type EStampValidator struct {
+	validate *validator.Validate
+}
+
+func NewEStampValidator() *EStampValidator {
+	return &EStampValidator{validate: requestValidate}
+}
+
+// Validate checks an eStamp request and returns every structural problem
+// found, or nil when the request is well formed
+func (v *EStampValidator) Validate(req interface{}) DutyFieldErrors {
+	return validationFieldErrors(v.validate.Struct(req))
+}
+
+// RequestValidator checks other request payloads against their validate
+// tags alone, reporting problems the same way as EStampValidator
+type RequestValidator struct {
+	validate *validator.Validate
+}
+
+func NewRequestValidator() *RequestValidator {
+	return &RequestValidator{validate: requestValidate}
+}
+
+// Validate checks a request and returns every problem found, or nil when
+// the request is well formed
+func (v *RequestValidator) Validate(req interface{}) DutyFieldErrors {
+	return validationFieldErrors(v.validate.Struct(req))
+}
+
+// newTaggedValidator returns a validator that reports fields by their JSON
+// names and knows the custom date, postal code and tax ID tags
+func newTaggedValidator() (*validator.Validate, error) {
+	validate := validator.New()
+
+	// Report fields by their JSON names so paths match the request payload
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	err := validate.RegisterValidation("isodate", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse("2006-01-02", fl.Field().String())
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = validate.RegisterValidation("sgpostal", func(fl validator.FieldLevel) bool {
+		return postalCodePattern.MatchString(fl.Field().String())
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.RegisterTaxIDValidations(validate); err != nil {
+		return nil, err
+	}
+
+	return validate, nil
+}
+
+// validationFieldErrors converts a validation result to field errors
+func validationFieldErrors(err error) DutyFieldErrors {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return DutyFieldErrors{{Field: "body", Message: err.Error()}}
+	}
+
+	fieldErrors := make(DutyFieldErrors, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{
+			Field:   fieldPath(fieldError.Namespace()),
+			Message: fieldErrorMessage(fieldError),
+		})
+	}
+	return fieldErrors
+}
+
+// fieldPath drops the request type from a validator namespace, leaving the
+// JSON path of the field, e.g. assets.properties[2].postalCode
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldErrorMessage describes a failed rule. Rules reported by the struct
+// validators carry their message in the param.
+func fieldErrorMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "Field is required"
+	case "gt":
+		return "Must be greater than " + fieldError.Param()
+	case "gte":
+		if fieldError.Param() == "0" {
+			return "Must not be negative"
+		}
+		return "Must be at least " + fieldError.Param()
+	case "lte":
+		return "Must be at most " + fieldError.Param()
+	case "eq":
+		if fieldError.Param() == "true" {
+			return "Declaration must be made"
+		}
+		return "Must be " + fieldError.Param()
+	case "isodate", "datetime":
+		return "Date must be in YYYY-MM-DD format"
+	case "email":
+		return "Must be a valid email address"
+	case "sgpostal":
+		return "Postal code must be 6 digits"
+	case "taxentityid", "dateorder", "uniquesequence":
+		return fieldError.Param()
+	default:
+		if label, ok := utils.TaxIDLabel(fieldError.Tag()); ok {
+			return "Not a valid " + label
+		}
+		return fmt.Sprintf("Failed %s validation", fieldError.Tag())
+	}
+}
+
+// validateRequest applies the rules spanning the top-level fields of an
+// eStamp request
+func validateRequest(sl validator.StructLevel) {
+	validateDocumentDates(sl)
+	validateUniqueSequences(sl)
+}
+
+// validateUniqueSequences checks that the items of every list in a struct
+// that carries a Sequence are numbered uniquely. Each repeated number is
+// reported against the item that repeats it.
+func validateUniqueSequences(sl validator.StructLevel) {
+	current := sl.Current()
+	for i := 0; i < current.NumField(); i++ {
+		list := current.Field(i)
+		if list.Kind() != reflect.Slice || list.Type().Elem().Kind() != reflect.Struct {
+			continue
+		}
+		if _, ok := list.Type().Elem().FieldByName("Sequence"); !ok {
+			continue
+		}
+
+		structField := current.Type().Field(i)
+		name := strings.SplitN(structField.Tag.Get("json"), ",", 2)[0]
+		seen := make(map[int64]bool)
+		for j := 0; j < list.Len(); j++ {
+			sequence := list.Index(j).FieldByName("Sequence").Int()
+			if seen[sequence] {
+				sl.ReportError(sequence, fmt.Sprintf("%s[%d].sequence", name, j), structField.Name, "uniquesequence", fmt.Sprintf("Sequence number %d is used more than once", sequence))
+			}
+			seen[sequence] = true
+		}
+	}
+}
+
+// validateDocumentDates checks that a document is not received in Singapore
+// before it is dated
+func validateDocumentDates(sl validator.StructLevel) {
+	request := sl.Current()
+	documentDate, err := time.Parse("2006-01-02", request.FieldByName("DateOfDocument").String())
+	if err != nil {
+		return
+	}
+	receivingDate, err := time.Parse("2006-01-02", request.FieldByName("ReceivingDateOfDocument").String())
+	if err != nil {
+		return
+	}
+	if receivingDate.Before(documentDate) {
+		sl.ReportError(request.FieldByName("ReceivingDateOfDocument").Interface(), "receivingDateOfDocument", "ReceivingDateOfDocument", "dateorder", "Document cannot be received before it is dated")
+	}
+}
+
+// validateLeasePeriod checks that a rental period does not end before it
+// starts
+func validateLeasePeriod(sl validator.StructLevel) {
+	detail := sl.Current().Interface().(models.RentalDetailData)
+	start, err := time.Parse("2006-01-02", detail.StartPeriodOfLease)
+	if err != nil {
+		return
+	}
+	end, err := time.Parse("2006-01-02", detail.EndPeriodOfLease)
+	if err != nil {
+		return
+	}
+	if end.Before(start) {
+		sl.ReportError(detail.EndPeriodOfLease, "endPeriodOfLease", "EndPeriodOfLease", "dateorder", "End of lease period must not be before its start")
+	}
+}
+
+// validatePartyID checks a party's identity number against the format for
+// its TaxEntityIDType. Beneficiaries who cannot be identified carry no
+// identity number.
+func validatePartyID(sl validator.StructLevel) {
+	party := sl.Current()
+	if unidentifiable := party.FieldByName("BeneficiaryIsUnidentifiable"); unidentifiable.IsValid() && unidentifiable.Bool() {
+		return
+	}
+
+	idType := int(party.FieldByName("TaxEntityIDType").Int())
+	idNo := strings.ToUpper(strings.TrimSpace(party.FieldByName("TaxEntityIDNo").String()))
+	if idType == 0 && idNo == "" {
+		return
+	}
+
+	if idNo == "" {
+		sl.ReportError(idNo, "taxEntityIdNo", "TaxEntityIDNo", "taxentityid", "Identity number is required for the identity type given")
+		return
+	}
+	if format, ok := taxEntityIDChecks[idType]; ok && !format.Check(idNo) {
+		sl.ReportError(idNo, "taxEntityIdNo", "TaxEntityIDNo", "taxentityid", "Not a valid "+format.Label)
+	}
+}
+
+// validateMailingAddress checks the postal code of a Singapore address
+func validateMailingAddress(sl validator.StructLevel) {
+	address := sl.Current()
+	country := strings.ToUpper(strings.TrimSpace(address.FieldByName("Country").String()))
+	postalCode := strings.TrimSpace(address.FieldByName("PostalCode").String())
+
+	if postalCode == "" || (country != "" && country != "SG" && country != "SGP" && country != "SINGAPORE") {
+		return
+	}
+	if !postalCodePattern.MatchString(postalCode) {
+		sl.ReportError(postalCode, "postalCode", "PostalCode", "sgpostal", "")
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"testing"
+)
+
+func TestEStampValidatorFieldPaths(t *testing.T) {
+	valid := func() *models.StampTenancyAgreementRequest {
+		return &models.StampTenancyAgreementRequest{
+			AssignID:            "A0001",
+			DocumentDescription: "Tenancy agreement",
+			DateOfDocument:      "2024-01-10",
+			Submission:          models.SubmissionData{Declaration: true},
+			AssessmentRental: []models.AssessmentRentalData{{
+				IsMonthlyRentPayable: true,
+				RentalDetails:        []models.RentalDetailData{{StartPeriodOfLease: "2024-02-01", EndPeriodOfLease: "2026-01-31", RentAmount: 3000}},
+			}},
+			LandlordLessor: []models.PartyData{{
+				Sequence:        1,
+				TypeOfProfile:   TypeOfProfileIndividual,
+				TaxEntityIDType: TaxEntityIDTypeNRIC,
+				TaxEntityIDNo:   "S1234567D",
+				MailingAddress:  models.MailingAddressData{Country: "SG", PostalCode: "123456"},
+			}},
+			TenantLessee: []models.PartyData{{Sequence: 1, TypeOfProfile: TypeOfProfileEntity, TaxEntityIDType: TaxEntityIDTypeUENLocalCompany, TaxEntityIDNo: "201912345R"}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(req *models.StampTenancyAgreementRequest)
+		wantErrors []models.EStampFieldError
+	}{
+		{
+			name:   "well formed",
+			modify: func(req *models.StampTenancyAgreementRequest) {},
+		},
+		{
+			name:       "missing required field",
+			modify:     func(req *models.StampTenancyAgreementRequest) { req.AssignID = "" },
+			wantErrors: []models.EStampFieldError{{Field: "assignId", Message: "Field is required"}},
+		},
+		{
+			name:       "declaration not made",
+			modify:     func(req *models.StampTenancyAgreementRequest) { req.Submission.Declaration = false },
+			wantErrors: []models.EStampFieldError{{Field: "submission.declaration", Message: "Declaration must be made"}},
+		},
+		{
+			name:       "invalid document date",
+			modify:     func(req *models.StampTenancyAgreementRequest) { req.DateOfDocument = "10/01/2024" },
+			wantErrors: []models.EStampFieldError{{Field: "dateOfDocument", Message: "Date must be in YYYY-MM-DD format"}},
+		},
+		{
+			name:       "received before it is dated",
+			modify:     func(req *models.StampTenancyAgreementRequest) { req.ReceivingDateOfDocument = "2024-01-09" },
+			wantErrors: []models.EStampFieldError{{Field: "receivingDateOfDocument", Message: "Document cannot be received before it is dated"}},
+		},
+		{
+			name: "nested list item",
+			modify: func(req *models.StampTenancyAgreementRequest) {
+				req.AssessmentRental[0].RentalDetails[0].RentAmount = -1
+			},
+			wantErrors: []models.EStampFieldError{{Field: "assessmentRental[0].rentalDetails[0].rentAmount", Message: "Must not be negative"}},
+		},
+		{
+			name: "lease ends before it starts",
+			modify: func(req *models.StampTenancyAgreementRequest) {
+				req.AssessmentRental[0].RentalDetails[0].EndPeriodOfLease = "2024-01-31"
+			},
+			wantErrors: []models.EStampFieldError{{Field: "assessmentRental[0].rentalDetails[0].endPeriodOfLease", Message: "End of lease period must not be before its start"}},
+		},
+		{
+			name: "sequence used twice",
+			modify: func(req *models.StampTenancyAgreementRequest) {
+				req.LandlordLessor = append(req.LandlordLessor, models.PartyData{Sequence: 1})
+			},
+			wantErrors: []models.EStampFieldError{{Field: "landlordLessor[1].sequence", Message: "Sequence number 1 is used more than once"}},
+		},
+		{
+			name:       "invalid identity number",
+			modify:     func(req *models.StampTenancyAgreementRequest) { req.LandlordLessor[0].TaxEntityIDNo = "S1234567A" },
+			wantErrors: []models.EStampFieldError{{Field: "landlordLessor[0].taxEntityIdNo", Message: "Not a valid NRIC"}},
+		},
+		{
+			name:       "identity number missing for its type",
+			modify:     func(req *models.StampTenancyAgreementRequest) { req.TenantLessee[0].TaxEntityIDNo = "" },
+			wantErrors: []models.EStampFieldError{{Field: "tenantLessee[0].taxEntityIdNo", Message: "Identity number is required for the identity type given"}},
+		},
+		{
+			name: "invalid Singapore postal code",
+			modify: func(req *models.StampTenancyAgreementRequest) {
+				req.LandlordLessor[0].MailingAddress.PostalCode = "12345"
+			},
+			wantErrors: []models.EStampFieldError{{Field: "landlordLessor[0].mailingAddress.postalCode", Message: "Postal code must be 6 digits"}},
+		},
+		{
+			name: "overseas postal code not checked",
+			modify: func(req *models.StampTenancyAgreementRequest) {
+				req.LandlordLessor[0].MailingAddress = models.MailingAddressData{Country: "MY", PostalCode: "50450X"}
+			},
+		},
+		{
+			name: "every problem reported together",
+			modify: func(req *models.StampTenancyAgreementRequest) {
+				req.AssignID = ""
+				req.LandlordLessor[0].TaxEntityIDNo = "S1234567A"
+				req.AssessmentRental[0].RentalDetails[0].RentAmount = -1
+			},
+			wantErrors: []models.EStampFieldError{
+				{Field: "assignId", Message: "Field is required"},
+				{Field: "assessmentRental[0].rentalDetails[0].rentAmount", Message: "Must not be negative"},
+				{Field: "landlordLessor[0].taxEntityIdNo", Message: "Not a valid NRIC"},
+			},
+		},
+	}
+
+	validator := NewEStampValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(req)
+
+			fieldErrors := validator.Validate(req)
+			if len(fieldErrors) != len(tt.wantErrors) {
+				t.Fatalf("got field errors %v, want %v", fieldErrors, tt.wantErrors)
+			}
+			for i, want := range tt.wantErrors {
+				if fieldErrors[i] != want {
+					t.Errorf("field error %d = %+v, want %+v", i, fieldErrors[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldPath(t *testing.T) {
+	tests := map[string]string{
+		"StampMortgageRequest.assets.properties[2].postalCode": "assets.properties[2].postalCode",
+		"StampMortgageRequest.amountOfLoan":                    "amountOfLoan",
+		"amountOfLoan":                                         "amountOfLoan",
+	}
+	for namespace, want := range tests {
+		if got := fieldPath(namespace); got != want {
+			t.Errorf("fieldPath(%q) = %q, want %q", namespace, got, want)
+		}
+	}
+}
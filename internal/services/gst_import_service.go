@@ -0,0 +1,606 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GST listing file formats
+const (
+	GSTListingFormatCSV        = "csv"
+	GSTListingFormatFixedWidth = "fixed"
+)
+
+// Import run statuses
+const (
+	GSTImportStatusRunning   = "RUNNING"
+	GSTImportStatusCompleted = "COMPLETED"
+	GSTImportStatusFailed    = "FAILED"
+)
+
+const (
+	// GSTStatusDeregistered is given to registrations missing from a newer
+	// listing
+	GSTStatusDeregistered = "Deregistered"
+
+	// maxImportLineErrors caps the rejected rows kept on an import run
+	maxImportLineErrors = 100
+
+	// gstImportLookupBatch is how many registration IDs are looked up per
+	// query when checking a listing against other clients' registrations
+	gstImportLookupBatch = 1000
+
+	// maxDeregisterRejectedShare is the largest share of rejected rows a
+	// listing can have and still deregister the registrations it leaves
+	// out. Beyond it the listing is treated as damaged: rows whose
+	// registration ID cannot be read would otherwise deregister the
+	// businesses they list.
+	maxDeregisterRejectedShare = 0.05
+)
+
+// gstListingColumns maps the normalized CSV headings of the listing to the
+// registration fields they hold
+var gstListingColumns = map[string]string{
+	"registrationid":        "registrationId",
+	"regid":                 "registrationId",
+	"uen":                   "registrationId",
+	"uennric":               "registrationId",
+	"gstregistrationnumber": "gstRegistrationNumber",
+	"gstregno":              "gstRegistrationNumber",
+	"gstno":                 "gstRegistrationNumber",
+	"name":                  "name",
+	"entityname":            "name",
+	"businessname":          "name",
+	"registeredfrom":        "registeredFrom",
+	"registrationdate":      "registeredFrom",
+	"registeredto":          "registeredTo",
+	"deregistrationdate":    "registeredTo",
+	"status":                "status",
+	"remarks":               "remarks",
+}
+
+// gstListingFixedWidthLayout is the column layout of the fixed-width
+// listing. The remarks run to the end of the line.
+var gstListingFixedWidthLayout = []struct {
+	Field string
+	Start int
+	Width int
+}{
+	{Field: "registrationId", Start: 0, Width: 12},
+	{Field: "gstRegistrationNumber", Start: 12, Width: 12},
+	{Field: "name", Start: 24, Width: 66},
+	{Field: "registeredFrom", Start: 90, Width: 10},
+	{Field: "registeredTo", Start: 100, Width: 10},
+	{Field: "status", Start: 110, Width: 12},
+	{Field: "remarks", Start: 122, Width: -1},
+}
+
+// GSTListingError reports a listing that cannot be imported as given, as
+// opposed to a failure storing it
+type GSTListingError struct {
+	Err error
+}
+
+func (e *GSTListingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *GSTListingError) Unwrap() error {
+	return e.Err
+}
+
+type GSTImportService struct {
+	db *gorm.DB
+}
+
+func NewGSTImportService(db *gorm.DB) *GSTImportService {
+	return &GSTImportService{db: db}
+}
+
+// GSTListingFormatForFile picks the listing format from a file name: .csv
+// files are CSV and anything else is fixed-width
+func GSTListingFormatForFile(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return GSTListingFormatCSV
+	}
+	return GSTListingFormatFixedWidth
+}
+
+// ImportListing loads a GST registered business listing into a client's
+// registrations. Rows are upserted by registration ID, keeping their period
+// history, and registrations missing from the listing are deregistered the
+// day before the listing date. A rejected row still counts as listing its
+// registration ID, and no registrations are deregistered when too many rows
+// are rejected. The run is recorded whether or not it succeeds; rows that
+// cannot be read are rejected without stopping it. Problems with the listing
+// itself are returned as a *GSTListingError.
+func (s *GSTImportService) ImportListing(clientID, source, format, listingDate string, r io.Reader) (*models.GSTImportRun, error) {
+	run := &models.GSTImportRun{
+		ClientID:  clientID,
+		Source:    source,
+		Format:    format,
+		Status:    GSTImportStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	err := s.importListing(run, listingDate, r)
+	if err != nil {
+		run.Status = GSTImportStatusFailed
+		run.ErrorMessage = err.Error()
+	} else {
+		run.Status = GSTImportStatusCompleted
+	}
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+
+	if saveErr := s.db.Save(run).Error; saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return run, err
+}
+
+func (s *GSTImportService) importListing(run *models.GSTImportRun, listingDate string, r io.Reader) error {
+	if strings.TrimSpace(run.ClientID) == "" {
+		return &GSTListingError{Err: errors.New("client ID is required")}
+	}
+
+	date, err := normalizeGSTDate(listingDate)
+	if err != nil {
+		return &GSTListingError{Err: fmt.Errorf("listing date: %w", err)}
+	}
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	run.ListingDate = date
+
+	var rows []map[string]string
+	switch run.Format {
+	case GSTListingFormatCSV:
+		rows, err = readGSTListingCSV(r)
+	case GSTListingFormatFixedWidth:
+		rows, err = readGSTListingFixedWidth(r)
+	default:
+		return &GSTListingError{Err: fmt.Errorf("unknown listing format %q, expected csv or fixed", run.Format)}
+	}
+	if err != nil {
+		return &GSTListingError{Err: err}
+	}
+
+	registrations, lines, lineErrors := parseGSTListingRows(rows, run.ClientID)
+	listed := listedRegistrationIDs(rows)
+	run.TotalRows = len(rows)
+	run.Rejected = len(lineErrors)
+
+	// Rejected rows are recorded on the run however the import ends
+	defer func() {
+		recorded := lineErrors
+		if len(recorded) > maxImportLineErrors {
+			recorded = recorded[:maxImportLineErrors]
+		}
+		if len(recorded) > 0 {
+			details, _ := json.Marshal(recorded)
+			run.LineErrors = string(details)
+		}
+	}()
+
+	// An empty listing would deregister every registration
+	if len(registrations) == 0 {
+		return &GSTListingError{Err: errors.New("listing has no importable rows")}
+	}
+
+	// The counts are only copied to the run once the listing is committed,
+	// so a failed run does not report changes that were rolled back
+	var counts gstListingCounts
+	var claimed []models.GSTImportLineError
+	var deregistrationSkipped bool
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		counts = gstListingCounts{}
+		var err error
+		claimed, err = applyGSTListing(tx, run.ClientID, registrations, lines, &counts)
+		if err != nil {
+			return err
+		}
+
+		rejected := len(lineErrors) + len(claimed)
+		deregistrationSkipped = float64(rejected) > float64(len(rows))*maxDeregisterRejectedShare
+		if deregistrationSkipped {
+			return nil
+		}
+		return deregisterUnlisted(tx, run.ClientID, listed, date, &counts)
+	})
+
+	// Rows whose registration ID is already taken or whose dates overlap
+	// are rejected, in line order with the rows that could not be read
+	if len(claimed) > 0 {
+		lineErrors = append(lineErrors, claimed...)
+		sort.SliceStable(lineErrors, func(i, j int) bool { return lineErrors[i].Line < lineErrors[j].Line })
+		run.Rejected += len(claimed)
+	}
+	if err != nil {
+		return err
+	}
+
+	run.Inserted = counts.Inserted
+	run.Updated = counts.Updated
+	run.Unchanged = counts.Unchanged
+	run.Deregistered = counts.Deregistered
+	run.DeregistrationSkipped = deregistrationSkipped
+	return nil
+}
+
+// gstListingCounts tallies the registrations a listing changed
+type gstListingCounts struct {
+	Inserted     int
+	Updated      int
+	Unchanged    int
+	Deregistered int
+}
+
+// applyGSTListing upserts the listed registrations. Registration IDs are
+// unique across clients, so rows whose ID belongs to another client or to a
+// deleted registration are rejected and returned as line errors, as are rows
+// whose dates overlap the registration's earlier periods; lines gives each
+// registration's line in the listing.
+func applyGSTListing(tx *gorm.DB, clientID string, registrations []models.GSTRegistration, lines []int, counts *gstListingCounts) ([]models.GSTImportLineError, error) {
+	var existing []models.GSTRegistration
+	if err := tx.Where("client_id = ?", clientID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byRegistrationID := make(map[string]*models.GSTRegistration, len(existing))
+	for i := range existing {
+		byRegistrationID[existing[i].RegistrationID] = &existing[i]
+	}
+
+	claimed, err := claimedRegistrationIDs(tx, clientID, registrations, byRegistrationID)
+	if err != nil {
+		return nil, err
+	}
+	var lineErrors []models.GSTImportLineError
+	for i := range registrations {
+		if reason, ok := claimed[registrations[i].RegistrationID]; ok {
+			lineErrors = append(lineErrors, models.GSTImportLineError{Line: lines[i], Field: "registrationId", Message: reason})
+		}
+	}
+	if len(lineErrors) == len(registrations) {
+		return lineErrors, &GSTListingError{Err: errors.New("listing has no importable rows")}
+	}
+
+	for i := range registrations {
+		listing := &registrations[i]
+		if _, ok := claimed[listing.RegistrationID]; ok {
+			continue
+		}
+
+		current, ok := byRegistrationID[listing.RegistrationID]
+		if ok && sameRegistration(current, listing) {
+			counts.Unchanged++
+			continue
+		}
+
+		// Each row is applied in a savepoint so a row whose dates overlap
+		// the registration's history is rejected on its own
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			if !ok {
+				if err := tx.Create(listing).Error; err != nil {
+					return err
+				}
+				return recordRegistrationPeriod(tx, listing)
+			}
+
+			updated := *current
+			updated.GSTRegistrationNumber = listing.GSTRegistrationNumber
+			updated.Name = listing.Name
+			updated.RegisteredFrom = listing.RegisteredFrom
+			updated.RegisteredTo = listing.RegisteredTo
+			updated.Status = listing.Status
+			updated.Remarks = listing.Remarks
+			if err := tx.Save(&updated).Error; err != nil {
+				return err
+			}
+			if err := recordRegistrationPeriod(tx, &updated); err != nil {
+				return err
+			}
+			*current = updated
+			return nil
+		})
+		switch {
+		case errors.Is(err, ErrGSTPeriodOverlap):
+			lineErrors = append(lineErrors, models.GSTImportLineError{Line: lines[i], Field: "registeredFrom", Message: err.Error()})
+		case err != nil:
+			return lineErrors, err
+		case ok:
+			counts.Updated++
+		default:
+			counts.Inserted++
+		}
+	}
+
+	return lineErrors, nil
+}
+
+// deregisterUnlisted deregisters the client's current registrations whose
+// IDs the listing does not include, the day before the listing date
+func deregisterUnlisted(tx *gorm.DB, clientID string, listed map[string]bool, listingDate string, counts *gstListingCounts) error {
+	var existing []models.GSTRegistration
+	if err := tx.Where("client_id = ?", clientID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	listingDay, _ := time.Parse("2006-01-02", listingDate)
+	deregisteredOn := listingDay.AddDate(0, 0, -1).Format("2006-01-02")
+	for i := range existing {
+		current := &existing[i]
+		if listed[current.RegistrationID] || current.RegisteredTo != "" || current.Status == GSTStatusDeregistered {
+			continue
+		}
+
+		current.RegisteredTo = deregisteredOn
+		current.Status = GSTStatusDeregistered
+		current.Remarks = fmt.Sprintf("Not in GST listing of %s", listingDate)
+		if err := tx.Save(current).Error; err != nil {
+			return err
+		}
+		if err := recordRegistrationPeriod(tx, current); err != nil {
+			return err
+		}
+		counts.Deregistered++
+	}
+	return nil
+}
+
+// listedRegistrationIDs collects every readable registration ID in the
+// listing, including those on rows that are rejected, so a registration is
+// not deregistered because its row had a bad name or date
+func listedRegistrationIDs(rows []map[string]string) map[string]bool {
+	listed := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if registrationID := utils.NormalizeTaxID(row["registrationId"]); utils.IsValidTaxRefID(registrationID) {
+			listed[registrationID] = true
+		}
+	}
+	return listed
+}
+
+// claimedRegistrationIDs looks up the listed registration IDs the client
+// does not hold, including deleted registrations, and returns why each one
+// that is already taken cannot be imported
+func claimedRegistrationIDs(tx *gorm.DB, clientID string, registrations []models.GSTRegistration, held map[string]*models.GSTRegistration) (map[string]string, error) {
+	var lookup []string
+	for i := range registrations {
+		if _, ok := held[registrations[i].RegistrationID]; !ok {
+			lookup = append(lookup, registrations[i].RegistrationID)
+		}
+	}
+
+	claimed := make(map[string]string)
+	for start := 0; start < len(lookup); start += gstImportLookupBatch {
+		end := min(start+gstImportLookupBatch, len(lookup))
+
+		var taken []models.GSTRegistration
+		if err := tx.Unscoped().Where("registration_id IN ?", lookup[start:end]).Find(&taken).Error; err != nil {
+			return nil, err
+		}
+		for _, gstReg := range taken {
+			if gstReg.DeletedAt.Valid {
+				claimed[gstReg.RegistrationID] = "Registration ID belongs to a deleted registration"
+			} else if gstReg.ClientID != clientID {
+				claimed[gstReg.RegistrationID] = "Registration ID is registered to another client"
+			}
+		}
+	}
+	return claimed, nil
+}
+
+// sameRegistration reports whether a listing row matches the stored
+// registration, comparing dates in their normalized form
+func sameRegistration(current, listing *models.GSTRegistration) bool {
+	currentFrom, _ := normalizeGSTDate(current.RegisteredFrom)
+	currentTo, _ := normalizeGSTDate(current.RegisteredTo)
+	return current.GSTRegistrationNumber == listing.GSTRegistrationNumber &&
+		current.Name == listing.Name &&
+		currentFrom == listing.RegisteredFrom &&
+		currentTo == listing.RegisteredTo &&
+		current.Status == listing.Status &&
+		current.Remarks == listing.Remarks
+}
+
+// parseGSTListingRows validates the listing rows and converts them to
+// registrations, returning the line each came from. Line numbers count from
+// the first data row.
+func parseGSTListingRows(rows []map[string]string, clientID string) ([]models.GSTRegistration, []int, []models.GSTImportLineError) {
+	var registrations []models.GSTRegistration
+	var lines []int
+	var lineErrors []models.GSTImportLineError
+	seen := make(map[string]bool)
+
+	for i, row := range rows {
+		line := i + 1
+		reject := func(field, message string) {
+			lineErrors = append(lineErrors, models.GSTImportLineError{Line: line, Field: field, Message: message})
+		}
+
+		registrationID := utils.NormalizeTaxID(row["registrationId"])
+		gstNumber := utils.NormalizeTaxID(row["gstRegistrationNumber"])
+		name := strings.TrimSpace(row["name"])
+
+		switch {
+		case !utils.IsValidTaxRefID(registrationID):
+			reject("registrationId", "Not a valid UEN, NRIC or FIN")
+			continue
+		case seen[registrationID]:
+			reject("registrationId", "Registration ID appears more than once in the listing")
+			continue
+		case gstNumber != "" && !utils.IsValidGSTNumber(gstNumber):
+			reject("gstRegistrationNumber", "Not a valid GST registration number")
+			continue
+		case name == "":
+			reject("name", "Name is required")
+			continue
+		}
+
+		registeredFrom, err := normalizeGSTDate(row["registeredFrom"])
+		if err != nil {
+			reject("registeredFrom", err.Error())
+			continue
+		}
+		registeredTo, err := normalizeGSTDate(row["registeredTo"])
+		if err != nil {
+			reject("registeredTo", err.Error())
+			continue
+		}
+		if registeredTo != "" && registeredTo < registeredFrom {
+			reject("registeredTo", "Deregistration date is before the registration date")
+			continue
+		}
+
+		status := strings.TrimSpace(row["status"])
+		if status == "" {
+			status = GSTStatusRegistered
+			if registeredTo != "" {
+				status = GSTStatusDeregistered
+			}
+		}
+
+		seen[registrationID] = true
+		registrations = append(registrations, models.GSTRegistration{
+			ClientID:              clientID,
+			RegistrationID:        registrationID,
+			GSTRegistrationNumber: gstNumber,
+			Name:                  name,
+			RegisteredFrom:        registeredFrom,
+			RegisteredTo:          registeredTo,
+			Status:                status,
+			Remarks:               strings.TrimSpace(row["remarks"]),
+		})
+		lines = append(lines, line)
+	}
+
+	return registrations, lines, lineErrors
+}
+
+// readGSTListingCSV reads a CSV listing, naming each row's fields from the
+// header row. Unrecognised columns are ignored.
+func readGSTListingCSV(r io.Reader) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("listing is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	fields := make([]string, len(header))
+	found := make(map[string]bool)
+	for i, heading := range header {
+		key := strings.ToLower(strings.NewReplacer(" ", "", "_", "", "/", "", "-", "", "\ufeff", "").Replace(heading))
+		fields[i] = gstListingColumns[key]
+		found[fields[i]] = true
+	}
+	if !found["registrationId"] || !found["name"] {
+		return nil, errors.New("CSV header must include registration ID and name columns")
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row := make(map[string]string)
+		for i, value := range record {
+			if i < len(fields) && fields[i] != "" {
+				row[fields[i]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readGSTListingFixedWidth reads a fixed-width listing laid out as in
+// gstListingFixedWidthLayout, skipping blank lines
+func readGSTListingFixedWidth(r io.Reader) ([]map[string]string, error) {
+	var rows []map[string]string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		row := make(map[string]string)
+		for _, column := range gstListingFixedWidthLayout {
+			if column.Start >= len(line) {
+				break
+			}
+			end := len(line)
+			if column.Width >= 0 && column.Start+column.Width < end {
+				end = column.Start + column.Width
+			}
+			row[column.Field] = strings.TrimSpace(line[column.Start:end])
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid fixed-width listing: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetImportRuns lists import runs, most recent first
+func (s *GSTImportService) GetImportRuns(page, limit int) ([]models.GSTImportRun, int64, error) {
+	var runs []models.GSTImportRun
+	var total int64
+
+	// Count total records
+	s.db.Model(&models.GSTImportRun{}).Count(&total)
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	// Get records with pagination
+	err := s.db.Order("started_at DESC").Offset(offset).Limit(limit).Find(&runs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// GetImportRunByID gets an import run by ID
+func (s *GSTImportService) GetImportRunByID(id uint) (*models.GSTImportRun, error) {
+	var run models.GSTImportRun
+	err := s.db.First(&run, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
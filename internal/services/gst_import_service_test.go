@@ -0,0 +1,389 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestReadGSTListingCSV(t *testing.T) {
+	listing := "\ufeffUEN,GST Reg No,Entity Name,Registration Date,Deregistration Date,Unused\n" +
+		"201912345R,M90012345X,ACME PTE LTD,01/04/2019,,x\n" +
+		"\n" +
+		"53312345C,,\"LIM & SONS, TRADING\",2020-07-01,2023-12-31,y\n"
+
+	rows, err := readGSTListingCSV(strings.NewReader(listing))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	want := map[string]string{
+		"registrationId":        "201912345R",
+		"gstRegistrationNumber": "M90012345X",
+		"name":                  "ACME PTE LTD",
+		"registeredFrom":        "01/04/2019",
+		"registeredTo":          "",
+	}
+	for field, value := range want {
+		if rows[0][field] != value {
+			t.Errorf("row 1 %s = %q, want %q", field, rows[0][field], value)
+		}
+	}
+	if rows[1]["name"] != "LIM & SONS, TRADING" {
+		t.Errorf("row 2 name = %q", rows[1]["name"])
+	}
+	if len(rows[0]) != len(want) {
+		t.Errorf("row 1 has unexpected fields: %v", rows[0])
+	}
+}
+
+func TestReadGSTListingCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing string
+	}{
+		{"empty", ""},
+		{"missing name column", "UEN,GST No\n201912345R,M90012345X\n"},
+		{"missing registration ID column", "Name,GST No\nACME,M90012345X\n"},
+		{"unterminated quote", "UEN,Name\n201912345R,\"ACME\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readGSTListingCSV(strings.NewReader(tt.listing)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadGSTListingFixedWidth(t *testing.T) {
+	line := fmt.Sprintf("%-12s%-12s%-66s%-10s%-10s%-12s%s", "201912345R", "M90012345X", "ACME PTE LTD", "2019-04-01", "", "Registered", "Head office moved")
+	short := fmt.Sprintf("%-12s%-12s%s", "53312345C", "", "LIM TRADING")
+
+	rows, err := readGSTListingFixedWidth(strings.NewReader(line + "\r\n   \n" + short + "\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	want := map[string]string{
+		"registrationId":        "201912345R",
+		"gstRegistrationNumber": "M90012345X",
+		"name":                  "ACME PTE LTD",
+		"registeredFrom":        "2019-04-01",
+		"registeredTo":          "",
+		"status":                "Registered",
+		"remarks":               "Head office moved",
+	}
+	for field, value := range want {
+		if rows[0][field] != value {
+			t.Errorf("row 1 %s = %q, want %q", field, rows[0][field], value)
+		}
+	}
+	if rows[1]["registrationId"] != "53312345C" || rows[1]["name"] != "LIM TRADING" {
+		t.Errorf("row 2 = %v", rows[1])
+	}
+	if _, ok := rows[1]["registeredFrom"]; ok {
+		t.Errorf("row 2 should stop at the end of the line: %v", rows[1])
+	}
+}
+
+func TestParseGSTListingRows(t *testing.T) {
+	rows := []map[string]string{
+		{"registrationId": " 201912345r ", "gstRegistrationNumber": "m90012345x", "name": "ACME PTE LTD", "registeredFrom": "01/04/2019"},
+		{"registrationId": "201912345X", "name": "BAD CHECK LETTER"},
+		{"registrationId": "53312345C", "name": "LIM TRADING", "registeredFrom": "01-Jul-2020", "registeredTo": "31 Dec 2023"},
+		{"registrationId": "201912345R", "name": "DUPLICATE"},
+		{"registrationId": "S1234567D", "gstRegistrationNumber": "123", "name": "TAN AH KOW"},
+		{"registrationId": "T08LL1234H", "name": ""},
+		{"registrationId": "199901234D", "name": "OLD CO", "registeredFrom": "2019-02-30"},
+		{"registrationId": "F1234567N", "name": "JOHN SMITH", "status": "Suspended"},
+	}
+
+	registrations, lines, lineErrors := parseGSTListingRows(rows, "client-1")
+
+	wantLines := []int{1, 3, 8}
+	if len(registrations) != len(wantLines) || len(lines) != len(wantLines) {
+		t.Fatalf("got %d registrations on lines %v, want lines %v", len(registrations), lines, wantLines)
+	}
+	for i, line := range wantLines {
+		if lines[i] != line {
+			t.Errorf("registration %d is from line %d, want %d", i, lines[i], line)
+		}
+		if registrations[i].ClientID != "client-1" {
+			t.Errorf("registration %d client = %q", i, registrations[i].ClientID)
+		}
+	}
+
+	acme := registrations[0]
+	if acme.RegistrationID != "201912345R" || acme.GSTRegistrationNumber != "M90012345X" || acme.RegisteredFrom != "2019-04-01" || acme.Status != GSTStatusRegistered {
+		t.Errorf("unexpected registration %+v", acme)
+	}
+	lim := registrations[1]
+	if lim.RegisteredFrom != "2020-07-01" || lim.RegisteredTo != "2023-12-31" || lim.Status != GSTStatusDeregistered {
+		t.Errorf("unexpected registration %+v", lim)
+	}
+	if registrations[2].Status != "Suspended" {
+		t.Errorf("listed status was not kept: %+v", registrations[2])
+	}
+
+	wantErrors := []models.GSTImportLineError{
+		{Line: 2, Field: "registrationId"},
+		{Line: 4, Field: "registrationId"},
+		{Line: 5, Field: "gstRegistrationNumber"},
+		{Line: 6, Field: "name"},
+		{Line: 7, Field: "registeredFrom"},
+	}
+	if len(lineErrors) != len(wantErrors) {
+		t.Fatalf("got line errors %v, want %v", lineErrors, wantErrors)
+	}
+	for i, want := range wantErrors {
+		if lineErrors[i].Line != want.Line || lineErrors[i].Field != want.Field {
+			t.Errorf("line error %d = %+v, want line %d field %s", i, lineErrors[i], want.Line, want.Field)
+		}
+	}
+}
+
+func TestSameRegistration(t *testing.T) {
+	stored := &models.GSTRegistration{
+		RegistrationID:        "201912345R",
+		GSTRegistrationNumber: "M90012345X",
+		Name:                  "ACME PTE LTD",
+		RegisteredFrom:        "01/04/2019",
+		Status:                GSTStatusRegistered,
+	}
+	listing := *stored
+	listing.RegisteredFrom = "2019-04-01"
+
+	if !sameRegistration(stored, &listing) {
+		t.Error("dates in another format should compare equal")
+	}
+
+	renamed := listing
+	renamed.Name = "ACME SINGAPORE PTE LTD"
+	if sameRegistration(stored, &renamed) {
+		t.Error("a renamed registration should not compare equal")
+	}
+
+	deregistered := listing
+	deregistered.RegisteredTo = "2024-06-30"
+	if sameRegistration(stored, &deregistered) {
+		t.Error("a deregistered registration should not compare equal")
+	}
+}
+
+func TestImportListingReportsListingErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		clientID    string
+		format      string
+		listingDate string
+		listing     string
+	}{
+		{"missing client", "", GSTListingFormatCSV, "", "UEN,Name\n201912345R,ACME\n"},
+		{"invalid listing date", "client-1", GSTListingFormatCSV, "31/02/2024", "UEN,Name\n201912345R,ACME\n"},
+		{"unknown format", "client-1", "xlsx", "", "UEN,Name\n201912345R,ACME\n"},
+		{"unreadable listing", "client-1", GSTListingFormatCSV, "", "GST No\nM90012345X\n"},
+		{"no importable rows", "client-1", GSTListingFormatCSV, "", "UEN,Name\n201912345X,ACME\n"},
+	}
+
+	// Each listing is rejected before the database is used
+	service := &GSTImportService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &models.GSTImportRun{ClientID: tt.clientID, Format: tt.format}
+			err := service.importListing(run, tt.listingDate, strings.NewReader(tt.listing))
+
+			var listingErr *GSTListingError
+			if !errors.As(err, &listingErr) {
+				t.Fatalf("expected a *GSTListingError, got %v", err)
+			}
+		})
+	}
+}
+
+// testNRICs returns count valid NRICs, for listings too long to write out
+func testNRICs(t *testing.T, count int) []string {
+	t.Helper()
+
+	var ids []string
+	for n := 1000000; len(ids) < count; n++ {
+		for letter := 'A'; letter <= 'Z'; letter++ {
+			if id := fmt.Sprintf("S%07d%c", n, letter); utils.IsValidNRIC(id) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// seedRegistrations stores open registrations for a client
+func seedRegistrations(t *testing.T, db *gorm.DB, clientID string, ids []string) {
+	t.Helper()
+
+	for _, id := range ids {
+		gstReg := models.GSTRegistration{ClientID: clientID, RegistrationID: id, Name: "BUSINESS " + id, RegisteredFrom: "2020-01-01", Status: GSTStatusRegistered}
+		if err := db.Create(&gstReg).Error; err != nil {
+			t.Fatalf("failed to seed registration: %v", err)
+		}
+	}
+}
+
+// listingCSV writes a CSV listing with a row per registration ID, using the
+// name given for the ID or the seeded name when there is none
+func listingCSV(ids []string, names map[string]string) string {
+	var listing strings.Builder
+	listing.WriteString("UEN,Name,Registration Date\n")
+	for _, id := range ids {
+		name, ok := names[id]
+		if !ok {
+			name = "BUSINESS " + id
+		}
+		fmt.Fprintf(&listing, "%s,%s,2020-01-01\n", id, name)
+	}
+	return listing.String()
+}
+
+func TestImportListingDeregistration(t *testing.T) {
+	ids := testNRICs(t, 21)
+	listedIDs, missing := ids[:20], ids[20]
+
+	tests := []struct {
+		name             string
+		listing          string
+		wantRejected     int
+		wantDeregistered []string
+		wantSkipped      bool
+	}{
+		{
+			name:             "registrations left out are deregistered",
+			listing:          listingCSV(listedIDs, nil),
+			wantDeregistered: []string{missing},
+		},
+		{
+			name:             "rejected row still lists its registration",
+			listing:          listingCSV(listedIDs, map[string]string{ids[0]: ""}),
+			wantRejected:     1,
+			wantDeregistered: []string{missing},
+		},
+		{
+			name:         "unreadable registration IDs stop deregistration",
+			listing:      listingCSV(append([]string{"201912345X", "S1234567A"}, listedIDs[2:]...), nil),
+			wantRejected: 2,
+			wantSkipped:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedRegistrations(t, db, "client-1", ids)
+			service := NewGSTImportService(db)
+
+			run, err := service.ImportListing("client-1", "listing.csv", GSTListingFormatCSV, "2024-07-01", strings.NewReader(tt.listing))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if run.Status != GSTImportStatusCompleted || run.Rejected != tt.wantRejected || run.DeregistrationSkipped != tt.wantSkipped {
+				t.Errorf("run = %s with %d rejected, skipped %v; want %s with %d rejected, skipped %v",
+					run.Status, run.Rejected, run.DeregistrationSkipped, GSTImportStatusCompleted, tt.wantRejected, tt.wantSkipped)
+			}
+			if run.Deregistered != len(tt.wantDeregistered) {
+				t.Errorf("deregistered %d, want %d", run.Deregistered, len(tt.wantDeregistered))
+			}
+
+			var deregistered []string
+			err = db.Model(&models.GSTRegistration{}).Where("status = ?", GSTStatusDeregistered).Order("registration_id").Pluck("registration_id", &deregistered).Error
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(deregistered) != len(tt.wantDeregistered) || (len(deregistered) > 0 && deregistered[0] != tt.wantDeregistered[0]) {
+				t.Errorf("deregistered %v, want %v", deregistered, tt.wantDeregistered)
+			}
+		})
+	}
+}
+
+func TestImportListingRejectsClaimedRegistrationIDs(t *testing.T) {
+	db := newTestDB(t)
+	seedRegistrations(t, db, "client-1", []string{"201912345R"})
+	seedRegistrations(t, db, "client-2", []string{"53312345C", "199901234D"})
+	if err := db.Where("registration_id = ?", "199901234D").Delete(&models.GSTRegistration{}).Error; err != nil {
+		t.Fatalf("failed to delete registration: %v", err)
+	}
+	service := NewGSTImportService(db)
+
+	listing := listingCSV([]string{"201912345R", "53312345C", "199901234D", "T08LL1234H"}, map[string]string{"53312345C": "TAKEOVER"})
+	run, err := service.ImportListing("client-1", "listing.csv", GSTListingFormatCSV, "2024-07-01", strings.NewReader(listing))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Inserted != 1 || run.Unchanged != 1 || run.Rejected != 2 {
+		t.Errorf("inserted %d, unchanged %d, rejected %d; want 1, 1, 2", run.Inserted, run.Unchanged, run.Rejected)
+	}
+
+	var lineErrors []models.GSTImportLineError
+	if err := json.Unmarshal([]byte(run.LineErrors), &lineErrors); err != nil {
+		t.Fatalf("failed to read line errors: %v", err)
+	}
+	wantMessages := map[int]string{
+		2: "Registration ID is registered to another client",
+		3: "Registration ID belongs to a deleted registration",
+	}
+	if len(lineErrors) != len(wantMessages) {
+		t.Fatalf("got line errors %v", lineErrors)
+	}
+	for _, lineError := range lineErrors {
+		if lineError.Message != wantMessages[lineError.Line] {
+			t.Errorf("line %d: %q, want %q", lineError.Line, lineError.Message, wantMessages[lineError.Line])
+		}
+	}
+
+	var other models.GSTRegistration
+	if err := db.Where("registration_id = ?", "53312345C").First(&other).Error; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if other.ClientID != "client-2" || other.Name != "BUSINESS 53312345C" {
+		t.Errorf("another client's registration was changed: %+v", other)
+	}
+}
+
+func TestImportListingRejectsOverlappingPeriods(t *testing.T) {
+	db := newTestDB(t)
+	gstService := NewGSTService(db)
+	gstReg := models.GSTRegistration{ClientID: "client-1", RegistrationID: "201912345R", Name: "ACME PTE LTD", RegisteredFrom: "2018-01-01", RegisteredTo: "2019-12-31", Status: GSTStatusDeregistered}
+	if err := gstService.CreateGSTRegistration(&gstReg); err != nil {
+		t.Fatalf("failed to create registration: %v", err)
+	}
+	service := NewGSTImportService(db)
+
+	listing := "UEN,Name,Registration Date\n201912345R,ACME PTE LTD,2019-06-01\n53312345C,LIM TRADING,2020-01-01\n"
+	run, err := service.ImportListing("client-1", "listing.csv", GSTListingFormatCSV, "2024-07-01", strings.NewReader(listing))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Inserted != 1 || run.Updated != 0 || run.Rejected != 1 {
+		t.Errorf("inserted %d, updated %d, rejected %d; want 1, 0, 1", run.Inserted, run.Updated, run.Rejected)
+	}
+
+	var stored models.GSTRegistration
+	if err := db.First(&stored, gstReg.ID).Error; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.RegisteredFrom != "2018-01-01" || stored.RegisteredTo != "2019-12-31" {
+		t.Errorf("overlapping row was applied: %+v", stored)
+	}
+}
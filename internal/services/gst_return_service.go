@@ -0,0 +1,315 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GST return form types
+const (
+	GSTFormTypeF5 = "F5"
+	GSTFormTypeF8 = "F8"
+)
+
+const (
+	// gstReturnTolerance absorbs rounding when box values are checked
+	// against each other
+	gstReturnTolerance = 0.01
+
+	// maxGSTAccountingMonths is the longest accounting period a return can
+	// cover
+	maxGSTAccountingMonths = 12
+)
+
+// gstRates are the standard GST rates by the date they took effect
+var gstRates = []struct {
+	EffectiveFrom string
+	Rate          float64
+}{
+	{EffectiveFrom: "1994-04-01", Rate: 0.03},
+	{EffectiveFrom: "2003-01-01", Rate: 0.04},
+	{EffectiveFrom: "2004-01-01", Rate: 0.05},
+	{EffectiveFrom: "2007-07-01", Rate: 0.07},
+	{EffectiveFrom: "2023-01-01", Rate: 0.08},
+	{EffectiveFrom: "2024-01-01", Rate: 0.09},
+}
+
+type GSTReturnService struct {
+	db         *gorm.DB
+	gstService *GSTService
+}
+
+func NewGSTReturnService(db *gorm.DB, gstService *GSTService) *GSTReturnService {
+	return &GSTReturnService{db: db, gstService: gstService}
+}
+
+// SubmitReturn validates a GST F5 or F8 return and files it against the
+// registration and accounting period, returning an acknowledgement number.
+// A period can be filed only once; corrections are made on an F7.
+func (s *GSTReturnService) SubmitReturn(formType string, req *models.GSTReturnRequest) (*models.GSTReturnResponse, error) {
+	periodStart, periodEnd, fieldErrors := validateGSTReturnPeriod(&req.FilingInfo)
+	fieldErrors = append(fieldErrors, validateGSTReturnBoxes(req, periodEnd)...)
+	if len(fieldErrors) > 0 {
+		return gstReturnErrorResponse("Invalid GST return", 40006, fieldErrors), nil
+	}
+
+	// The return is filed against the client's registration for the period
+	taxRefNo := utils.NormalizeTaxID(req.FilingInfo.TaxRefNo)
+	var gstReg models.GSTRegistration
+	err := s.db.Where("(registration_id = ? OR gst_registration_number = ?) AND client_id = ?", taxRefNo, taxRefNo, req.FilingInfo.ClientID).First(&gstReg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return gstReturnErrorResponse("GST registration not found", 40007, []models.GSTFieldError{
+			{Field: "filingInfo.taxRefNo", Message: "No GST registration found for this tax reference"},
+		}), nil
+	}
+	if err != nil {
+		return gstReturnServerErrorResponse(), err
+	}
+
+	// A CorpPass token only files for the entity it was issued for
+	if req.FilingInfo.EntityID != "" && gstReg.RegistrationID != req.FilingInfo.EntityID {
+		return gstReturnErrorResponse("Not authorised for this registration", 40009, []models.GSTFieldError{
+			{Field: "filingInfo.taxRefNo", Message: "The access token was not issued for this entity"},
+		}), nil
+	}
+
+	period, err := s.gstService.periodCovering(&gstReg, periodStart.Format("2006-01-02"))
+	if err != nil {
+		return gstReturnServerErrorResponse(), err
+	}
+	if period == nil {
+		return gstReturnErrorResponse("Not GST-registered for the period", 40007, []models.GSTFieldError{
+			{Field: "filingInfo.dtPeriodStart", Message: "The registration was not in force at the start of the accounting period"},
+		}), nil
+	}
+
+	// A final return covers the period up to deregistration
+	if formType == GSTFormTypeF8 && period.RegisteredTo != req.FilingInfo.DtPeriodEnd {
+		message := "An F8 can only be filed for a deregistered registration"
+		if period.RegisteredTo != "" {
+			message = fmt.Sprintf("An F8 must end on the deregistration date, %s", period.RegisteredTo)
+		}
+		return gstReturnErrorResponse("Invalid final return period", 40007, []models.GSTFieldError{
+			{Field: "filingInfo.dtPeriodEnd", Message: message},
+		}), nil
+	}
+	if formType == GSTFormTypeF5 && period.RegisteredTo != "" && req.FilingInfo.DtPeriodEnd > period.RegisteredTo {
+		return gstReturnErrorResponse("Invalid return period", 40007, []models.GSTFieldError{
+			{Field: "filingInfo.dtPeriodEnd", Message: fmt.Sprintf("The registration ended on %s; file an F8 for the final period", period.RegisteredTo)},
+		}), nil
+	}
+
+	ackNo, err := generateGSTReference("GST")
+	if err != nil {
+		return gstReturnServerErrorResponse(), err
+	}
+	returnData, err := json.Marshal(req)
+	if err != nil {
+		return gstReturnServerErrorResponse(), err
+	}
+
+	submittedAt := time.Now()
+	record := &models.GSTReturnRecord{
+		AckNo:                 ackNo,
+		ClientID:              req.FilingInfo.ClientID,
+		FormType:              formType,
+		RegistrationID:        gstReg.RegistrationID,
+		GSTRegistrationNumber: gstReg.GSTRegistrationNumber,
+		PeriodStart:           req.FilingInfo.DtPeriodStart,
+		PeriodEnd:             req.FilingInfo.DtPeriodEnd,
+		TotStdSupply:          req.Supplies.TotStdSupply,
+		TotZeroSupply:         req.Supplies.TotZeroSupply,
+		TotExemptSupply:       req.Supplies.TotExemptSupply,
+		TotValueSupply:        req.Supplies.TotValueSupply,
+		TotTaxPurchase:        req.Purchases.TotTaxPurchase,
+		OutputTaxDue:          req.Taxes.OutputTaxDue,
+		InputTaxRefund:        req.Taxes.InputTaxRefund,
+		NetGSTPaid:            req.Taxes.NetGSTPaid,
+		TotValueScheme:        req.Purchases.TotValueScheme,
+		DeclarantID:           utils.NormalizeTaxID(req.Declaration.DeclarantID),
+		DeclarantName:         req.Declaration.DeclarantName,
+		ReturnData:            string(returnData),
+		SubmittedAt:           submittedAt,
+	}
+
+	// Returns may not overlap an accounting period already filed. The check
+	// and the insert run with the registration row locked, so concurrent
+	// returns for a registration are filed one at a time.
+	alreadyFiled := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var locked models.GSTRegistration
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, gstReg.ID).Error; err != nil {
+			return err
+		}
+
+		var filed int64
+		err := tx.Model(&models.GSTReturnRecord{}).
+			Where("registration_id = ? AND period_start <= ? AND period_end >= ?", gstReg.RegistrationID, req.FilingInfo.DtPeriodEnd, req.FilingInfo.DtPeriodStart).
+			Count(&filed).Error
+		if err != nil {
+			return err
+		}
+		if filed > 0 {
+			alreadyFiled = true
+			return nil
+		}
+
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return gstReturnServerErrorResponse(), err
+	}
+	if alreadyFiled {
+		return gstReturnErrorResponse("Return already filed", 40008, []models.GSTFieldError{
+			{Field: "filingInfo.dtPeriodStart", Message: "A return has already been filed for this accounting period; file an F7 to correct it"},
+		}), nil
+	}
+
+	// Returns are due one month after the end of the accounting period
+	dueDate := utils.AddMonths(periodEnd, 1)
+	today := submittedAt.Truncate(24 * time.Hour)
+
+	return &models.GSTReturnResponse{
+		ReturnCode: 10,
+		Data: &models.GSTReturnData{
+			AckNo:         ackNo,
+			FormType:      formType,
+			TaxRefNo:      gstReg.RegistrationID,
+			DtPeriodStart: record.PeriodStart,
+			DtPeriodEnd:   record.PeriodEnd,
+			NetGSTPaid:    record.NetGSTPaid,
+			FilingDueDate: dueDate.Format("2006-01-02"),
+			LateFiling:    today.After(dueDate),
+			SubmittedAt:   submittedAt.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// validateGSTReturnPeriod checks that the accounting period is in order, at
+// most twelve months long and has ended
+func validateGSTReturnPeriod(filingInfo *models.GSTReturnFilingInfo) (time.Time, time.Time, []models.GSTFieldError) {
+	var fieldErrors []models.GSTFieldError
+
+	periodStart, startErr := time.Parse("2006-01-02", filingInfo.DtPeriodStart)
+	if startErr != nil {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{Field: "filingInfo.dtPeriodStart", Message: "Date must be in YYYY-MM-DD format"})
+	}
+	periodEnd, endErr := time.Parse("2006-01-02", filingInfo.DtPeriodEnd)
+	if endErr != nil {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{Field: "filingInfo.dtPeriodEnd", Message: "Date must be in YYYY-MM-DD format"})
+	}
+	if startErr != nil || endErr != nil {
+		return periodStart, periodEnd, fieldErrors
+	}
+
+	switch {
+	case periodEnd.Before(periodStart):
+		fieldErrors = append(fieldErrors, models.GSTFieldError{Field: "filingInfo.dtPeriodEnd", Message: "Accounting period must not end before it starts"})
+	case !periodEnd.Before(utils.AddMonths(periodStart, maxGSTAccountingMonths)):
+		fieldErrors = append(fieldErrors, models.GSTFieldError{Field: "filingInfo.dtPeriodEnd", Message: fmt.Sprintf("Accounting period must not be longer than %d months", maxGSTAccountingMonths)})
+	case !periodEnd.Before(time.Now().Truncate(24 * time.Hour)):
+		fieldErrors = append(fieldErrors, models.GSTFieldError{Field: "filingInfo.dtPeriodEnd", Message: "A return can only be filed after the accounting period has ended"})
+	}
+
+	return periodStart, periodEnd, fieldErrors
+}
+
+// validateGSTReturnBoxes checks the arithmetic between the boxes of a
+// return. Output and input tax are capped at the GST rate in force at the
+// end of the period, the highest rate the period could have been charged at.
+func validateGSTReturnBoxes(req *models.GSTReturnRequest, periodEnd time.Time) []models.GSTFieldError {
+	var fieldErrors []models.GSTFieldError
+	supplies, purchases, taxes := req.Supplies, req.Purchases, req.Taxes
+
+	totalSupplies := supplies.TotStdSupply + supplies.TotZeroSupply + supplies.TotExemptSupply
+	if math.Abs(supplies.TotValueSupply-totalSupplies) > gstReturnTolerance {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{
+			Field:   "supplies.totValueSupply",
+			Message: fmt.Sprintf("Total supplies (box 4) must equal boxes 1 to 3, %.2f", totalSupplies),
+		})
+	}
+
+	netGST := taxes.OutputTaxDue - taxes.InputTaxRefund
+	if math.Abs(taxes.NetGSTPaid-netGST) > gstReturnTolerance {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{
+			Field:   "taxes.netGSTPaid",
+			Message: fmt.Sprintf("Net GST (box 8) must equal output tax less input tax, %.2f", netGST),
+		})
+	}
+
+	rate := gstRateOn(periodEnd)
+	if rate == 0 {
+		return fieldErrors
+	}
+	if maxOutputTax := roundCents(supplies.TotStdSupply * rate); taxes.OutputTaxDue > maxOutputTax+gstReturnTolerance {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{
+			Field:   "taxes.outputTaxDue",
+			Message: fmt.Sprintf("Output tax (box 6) cannot exceed %.0f%% of standard-rated supplies, %.2f", rate*100, maxOutputTax),
+		})
+	}
+	if maxInputTax := roundCents(purchases.TotTaxPurchase * rate); taxes.InputTaxRefund > maxInputTax+gstReturnTolerance {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{
+			Field:   "taxes.inputTaxRefund",
+			Message: fmt.Sprintf("Input tax (box 7) cannot exceed %.0f%% of taxable purchases, %.2f", rate*100, maxInputTax),
+		})
+	}
+
+	return fieldErrors
+}
+
+// gstRateOn returns the standard GST rate in force on a date, or 0 before
+// GST was introduced
+func gstRateOn(date time.Time) float64 {
+	day := date.Format("2006-01-02")
+	rate := 0.0
+	for _, r := range gstRates {
+		if r.EffectiveFrom <= day {
+			rate = r.Rate
+		}
+	}
+	return rate
+}
+
+// generateGSTReference generates a unique reference for a filed return or
+// listing, e.g. an acknowledgement number
+func generateGSTReference(prefix string) (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate reference: %w", err)
+	}
+	return prefix + time.Now().Format("20060102") + strings.ToUpper(hex.EncodeToString(buf)), nil
+}
+
+// gstReturnErrorResponse reports a return that cannot be filed
+func gstReturnErrorResponse(message string, messageCode int, fieldErrors []models.GSTFieldError) *models.GSTReturnResponse {
+	return &models.GSTReturnResponse{
+		ReturnCode: 40,
+		Info: &models.GSTInfo{
+			Message:       message,
+			MessageCode:   messageCode,
+			FieldInfoList: fieldErrors,
+		},
+	}
+}
+
+// gstReturnServerErrorResponse reports a failure filing the return
+func gstReturnServerErrorResponse() *models.GSTReturnResponse {
+	return &models.GSTReturnResponse{
+		ReturnCode: 50,
+		Info: &models.GSTInfo{
+			Message:     "Internal server error",
+			MessageCode: 50001,
+		},
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"testing"
+	"time"
+)
+
+func TestGSTRateOn(t *testing.T) {
+	tests := []struct {
+		date string
+		want float64
+	}{
+		{"1994-03-31", 0},
+		{"1994-04-01", 0.03},
+		{"2007-06-30", 0.05},
+		{"2007-07-01", 0.07},
+		{"2022-12-31", 0.07},
+		{"2023-01-01", 0.08},
+		{"2024-01-01", 0.09},
+		{"2026-06-30", 0.09},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := gstRateOn(date); got != tt.want {
+				t.Errorf("gstRateOn(%s) = %v, want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGSTReturnBoxes(t *testing.T) {
+	// A balanced return for a period ending in 2024, when GST was 9%
+	valid := func() *models.GSTReturnRequest {
+		return &models.GSTReturnRequest{
+			Supplies: models.GSTReturnSupplies{
+				TotStdSupply:    100000,
+				TotZeroSupply:   20000,
+				TotExemptSupply: 5000,
+				TotValueSupply:  125000,
+			},
+			Purchases: models.GSTReturnPurchases{TotTaxPurchase: 40000},
+			Taxes: models.GSTReturnTaxes{
+				OutputTaxDue:   9000,
+				InputTaxRefund: 3600,
+				NetGSTPaid:     5400,
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		periodEnd  string
+		modify     func(req *models.GSTReturnRequest)
+		wantFields []string
+	}{
+		{
+			name:      "balanced return",
+			periodEnd: "2024-03-31",
+			modify:    func(req *models.GSTReturnRequest) {},
+		},
+		{
+			name:      "rounding within tolerance",
+			periodEnd: "2024-03-31",
+			modify: func(req *models.GSTReturnRequest) {
+				req.Supplies.TotValueSupply = 125000.004
+				req.Taxes.NetGSTPaid = 5399.996
+			},
+		},
+		{
+			name:       "total supplies do not add up",
+			periodEnd:  "2024-03-31",
+			modify:     func(req *models.GSTReturnRequest) { req.Supplies.TotValueSupply = 120000 },
+			wantFields: []string{"supplies.totValueSupply"},
+		},
+		{
+			name:       "net GST does not add up",
+			periodEnd:  "2024-03-31",
+			modify:     func(req *models.GSTReturnRequest) { req.Taxes.NetGSTPaid = 9000 },
+			wantFields: []string{"taxes.netGSTPaid"},
+		},
+		{
+			name:      "GST claimed back",
+			periodEnd: "2024-03-31",
+			modify: func(req *models.GSTReturnRequest) {
+				req.Taxes.OutputTaxDue = 1000
+				req.Taxes.NetGSTPaid = -2600
+			},
+		},
+		{
+			name:      "output tax above the rate",
+			periodEnd: "2024-03-31",
+			modify: func(req *models.GSTReturnRequest) {
+				req.Taxes.OutputTaxDue = 9100
+				req.Taxes.NetGSTPaid = 5500
+			},
+			wantFields: []string{"taxes.outputTaxDue"},
+		},
+		{
+			name:      "input tax above the rate",
+			periodEnd: "2024-03-31",
+			modify: func(req *models.GSTReturnRequest) {
+				req.Taxes.InputTaxRefund = 3700
+				req.Taxes.NetGSTPaid = 5300
+			},
+			wantFields: []string{"taxes.inputTaxRefund"},
+		},
+		{
+			name:       "output tax capped at the rate then in force",
+			periodEnd:  "2023-06-30",
+			modify:     func(req *models.GSTReturnRequest) { req.Taxes.InputTaxRefund, req.Taxes.NetGSTPaid = 0, 9000 },
+			wantFields: []string{"taxes.outputTaxDue"},
+		},
+		{
+			name:      "every problem reported together",
+			periodEnd: "2024-03-31",
+			modify: func(req *models.GSTReturnRequest) {
+				req.Supplies.TotValueSupply = 0
+				req.Taxes.OutputTaxDue = 20000
+				req.Taxes.InputTaxRefund = 10000
+			},
+			wantFields: []string{"supplies.totValueSupply", "taxes.netGSTPaid", "taxes.outputTaxDue", "taxes.inputTaxRefund"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(req)
+			periodEnd, _ := time.Parse("2006-01-02", tt.periodEnd)
+
+			fieldErrors := validateGSTReturnBoxes(req, periodEnd)
+			if len(fieldErrors) != len(tt.wantFields) {
+				t.Fatalf("got field errors %v, want errors on %v", fieldErrors, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if fieldErrors[i].Field != field {
+					t.Errorf("field error %d is on %s, want %s", i, fieldErrors[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGSTReturnPeriod(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		wantFields []string
+	}{
+		{"quarter", "2024-01-01", "2024-03-31", nil},
+		{"twelve months", "2024-01-01", "2024-12-31", nil},
+		{"thirteen months", "2024-01-01", "2025-01-31", []string{"filingInfo.dtPeriodEnd"}},
+		{"ends before it starts", "2024-03-31", "2024-01-01", []string{"filingInfo.dtPeriodEnd"}},
+		{"not yet ended", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), time.Now().AddDate(0, 0, 1).Format("2006-01-02"), []string{"filingInfo.dtPeriodEnd"}},
+		{"invalid dates", "2024-13-01", "31/03/2024", []string{"filingInfo.dtPeriodStart", "filingInfo.dtPeriodEnd"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, fieldErrors := validateGSTReturnPeriod(&models.GSTReturnFilingInfo{DtPeriodStart: tt.start, DtPeriodEnd: tt.end})
+			if len(fieldErrors) != len(tt.wantFields) {
+				t.Fatalf("got field errors %v, want errors on %v", fieldErrors, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if fieldErrors[i].Field != field {
+					t.Errorf("field error %d is on %s, want %s", i, fieldErrors[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitReturnChecksTokenEntity(t *testing.T) {
+	db := newTestDB(t)
+	gstService := NewGSTService(db)
+	gstReg := models.GSTRegistration{ClientID: "client", RegistrationID: "201912345R", GSTRegistrationNumber: "M90012345X", Name: "ACME PTE LTD", RegisteredFrom: "2020-01-01", Status: GSTStatusRegistered}
+	if err := gstService.CreateGSTRegistration(&gstReg); err != nil {
+		t.Fatalf("failed to create registration: %v", err)
+	}
+	service := NewGSTReturnService(db, gstService)
+
+	tests := []struct {
+		name            string
+		entityID        string
+		wantReturnCode  int
+		wantMessageCode int
+	}{
+		{"token for another entity", "199901234D", 40, 40009},
+		{"token for the entity", "201912345R", 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.GSTReturnRequest{
+				FilingInfo: models.GSTReturnFilingInfo{
+					ClientID:      "client",
+					TaxRefNo:      "M90012345X",
+					DtPeriodStart: "2024-01-01",
+					DtPeriodEnd:   "2024-03-31",
+					EntityID:      tt.entityID,
+				},
+				Supplies:  models.GSTReturnSupplies{TotStdSupply: 100000, TotValueSupply: 100000},
+				Purchases: models.GSTReturnPurchases{TotTaxPurchase: 40000},
+				Taxes:     models.GSTReturnTaxes{OutputTaxDue: 9000, InputTaxRefund: 3600, NetGSTPaid: 5400},
+			}
+
+			response, err := service.SubmitReturn(GSTFormTypeF5, req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.ReturnCode != tt.wantReturnCode {
+				t.Fatalf("return code = %d, want %d (%+v)", response.ReturnCode, tt.wantReturnCode, response.Info)
+			}
+			if tt.wantMessageCode != 0 && response.Info.MessageCode != tt.wantMessageCode {
+				t.Errorf("message code = %d, want %d", response.Info.MessageCode, tt.wantMessageCode)
+			}
+		})
+	}
+}
@@ -2,14 +2,60 @@ package services
 
 import (
 	"api-iras/internal/models"
+	"api-iras/pkg/utils"
 	"errors"
+	"fmt"
+	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	// MaxGSTBulkRegIDs is the most registration IDs a bulk search accepts
+	MaxGSTBulkRegIDs = 500
+
+	// GSTStatusRegistered is reported for a registration period covering the
+	// date searched
+	GSTStatusRegistered = "Registered"
+
+	// Candidate search modes
+	GSTSearchByGSTNumber = "gstNumber"
+	GSTSearchByUEN       = "uen"
+	GSTSearchByName      = "name"
+
+	// minNameMatchScore is the lowest trigram score a name candidate needs
+	minNameMatchScore = 0.3
+)
+
+var (
+	// ErrInvalidGSTDate is returned for registration dates that cannot be
+	// read or that end before they start
+	ErrInvalidGSTDate = errors.New("invalid GST registration date")
+
+	// ErrGSTPeriodOverlap is returned when a registration's dates would
+	// overlap one of its earlier registration periods
+	ErrGSTPeriodOverlap = errors.New("registration dates overlap the registration period")
+)
+
+// gstDateLayouts are the formats registration dates are accepted in. They
+// are stored as YYYY-MM-DD so periods compare and sort as text.
+var gstDateLayouts = []string{"2006-01-02", "02/01/2006", "02-Jan-2006", "2 Jan 2006", "2006-01-02T15:04:05Z07:00"}
+
 type GSTService struct {
 	db *gorm.DB
+
+	trigramMu      sync.Mutex
+	trigramChecked bool
+	trigram        bool
+}
+
+// gstCandidateRow is a registration read back with its match score
+type gstCandidateRow struct {
+	models.GSTRegistration
+	Score float64
 }
 
 func NewGSTService(db *gorm.DB) *GSTService {
@@ -36,16 +82,22 @@ func (s *GSTService) SearchGSTRegistered(req *models.GSTRequest) (*models.GSTRes
 	}
 
 	// Validate registration ID
-	if strings.TrimSpace(req.RegID) == "" {
+	if invalid := validateRegID(req.RegID); invalid != nil {
+		return invalid, nil
+	}
+
+	// Validate the date to answer for, when given
+	asOfDate, err := normalizeGSTDate(req.AsOfDate)
+	if err != nil {
 		return &models.GSTResponse{
 			ReturnCode: 40,
 			Info: &models.GSTInfo{
-				Message:     "Invalid registration ID",
-				MessageCode: 40002,
+				Message:     "Invalid as-of date",
+				MessageCode: 40005,
 				FieldInfoList: []models.GSTFieldError{
 					{
-						Field:   "regID",
-						Message: "Registration ID is required",
+						Field:   "asOfDate",
+						Message: "Date must be in YYYY-MM-DD format",
 					},
 				},
 			},
@@ -54,18 +106,12 @@ func (s *GSTService) SearchGSTRegistered(req *models.GSTRequest) (*models.GSTRes
 
 	// Search for GST registration in database
 	var gstReg models.GSTRegistration
-	err := s.db.Where("registration_id = ? AND client_id = ?", req.RegID, req.ClientID).First(&gstReg).Error
+	err = s.db.Where("registration_id = ? AND client_id = ?", utils.NormalizeTaxID(req.RegID), req.ClientID).First(&gstReg).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Return not found response
-			return &models.GSTResponse{
-				ReturnCode: 20,
-				Info: &models.GSTInfo{
-					Message:     "GST registration not found",
-					MessageCode: 20001,
-				},
-			}, nil
+			return gstNotFoundResponse(), nil
 		}
 		// Return server error
 		return &models.GSTResponse{
@@ -77,7 +123,469 @@ func (s *GSTService) SearchGSTRegistered(req *models.GSTRequest) (*models.GSTRes
 		}, err
 	}
 
+	if asOfDate != "" {
+		return s.registrationAsOf(&gstReg, asOfDate)
+	}
+
 	// Return successful response
+	return gstFoundResponse(&gstReg), nil
+}
+
+// registrationAsOf answers a search from the registration period covering
+// a date rather than the registration's current state
+func (s *GSTService) registrationAsOf(gstReg *models.GSTRegistration, asOfDate string) (*models.GSTResponse, error) {
+	period, err := s.periodCovering(gstReg, asOfDate)
+	if err != nil {
+		return &models.GSTResponse{
+			ReturnCode: 50,
+			Info: &models.GSTInfo{
+				Message:     "Internal server error",
+				MessageCode: 50001,
+			},
+		}, err
+	}
+
+	return gstAsOfResponse(gstReg, period, asOfDate), nil
+}
+
+// gstAsOfResponse returns the details of a registration from the period
+// covering a date, reporting it was not registered on the date when period
+// is nil
+func gstAsOfResponse(gstReg *models.GSTRegistration, period *models.GSTRegistrationPeriod, asOfDate string) *models.GSTResponse {
+	if period == nil {
+		return &models.GSTResponse{
+			ReturnCode: 20,
+			Info: &models.GSTInfo{
+				Message:     fmt.Sprintf("Not GST-registered on %s", asOfDate),
+				MessageCode: 20002,
+			},
+		}
+	}
+
+	response := gstFoundResponse(gstReg)
+	response.Data.GSTRegistrationNumber = period.GSTRegistrationNumber
+	response.Data.RegisteredFrom = period.RegisteredFrom
+	response.Data.RegisteredTo = period.RegisteredTo
+	response.Data.Remarks = period.Remarks
+	response.Data.Status = GSTStatusRegistered
+	return response
+}
+
+// periodCovering finds the registration period that includes a date, or nil
+// when the registration was not in force on it
+func (s *GSTService) periodCovering(gstReg *models.GSTRegistration, date string) (*models.GSTRegistrationPeriod, error) {
+	periods, err := s.registrationPeriods([]models.GSTRegistration{*gstReg})
+	if err != nil {
+		return nil, err
+	}
+	return coveringPeriod(periods[gstReg.RegistrationID], date), nil
+}
+
+// registrationPeriods loads the period history of many registrations with a
+// single query, keyed by registration ID. Registrations recorded before
+// history was kept are answered from their current dates.
+func (s *GSTService) registrationPeriods(gstRegs []models.GSTRegistration) (map[string][]models.GSTRegistrationPeriod, error) {
+	byRegistration := make(map[string][]models.GSTRegistrationPeriod)
+	if len(gstRegs) == 0 {
+		return byRegistration, nil
+	}
+
+	registrationIDs := make([]string, 0, len(gstRegs))
+	for i := range gstRegs {
+		registrationIDs = append(registrationIDs, gstRegs[i].RegistrationID)
+	}
+
+	var periods []models.GSTRegistrationPeriod
+	err := s.db.Where("registration_id IN ?", registrationIDs).Order("registered_from").Find(&periods).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, period := range periods {
+		byRegistration[period.RegistrationID] = append(byRegistration[period.RegistrationID], period)
+	}
+
+	for i := range gstRegs {
+		if _, ok := byRegistration[gstRegs[i].RegistrationID]; ok {
+			continue
+		}
+		if period, err := currentPeriod(&gstRegs[i]); err == nil && period != nil {
+			byRegistration[gstRegs[i].RegistrationID] = []models.GSTRegistrationPeriod{*period}
+		}
+	}
+	return byRegistration, nil
+}
+
+// coveringPeriod picks the period that includes a date, or nil when none
+// does
+func coveringPeriod(periods []models.GSTRegistrationPeriod, date string) *models.GSTRegistrationPeriod {
+	for i := range periods {
+		period := &periods[i]
+		if period.RegisteredFrom <= date && (period.RegisteredTo == "" || date <= period.RegisteredTo) {
+			return period
+		}
+	}
+	return nil
+}
+
+// SearchGSTRegisteredBulk looks up many registration IDs with a single
+// query. Each ID gets its own result, in the order given, with the same
+// return codes as SearchGSTRegistered, and an as-of date applies to every
+// ID.
+func (s *GSTService) SearchGSTRegisteredBulk(req *models.GSTBulkRequest) (*models.GSTBulkResponse, error) {
+	// Validate client ID (basic validation)
+	if strings.TrimSpace(req.ClientID) == "" {
+		return &models.GSTBulkResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid client ID",
+				MessageCode: 40001,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "clientID",
+						Message: "Client ID is required",
+					},
+				},
+			},
+		}, nil
+	}
+
+	// Validate the number of registration IDs
+	if len(req.RegIDs) == 0 || len(req.RegIDs) > MaxGSTBulkRegIDs {
+		return &models.GSTBulkResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid registration IDs",
+				MessageCode: 40002,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "regIDs",
+						Message: fmt.Sprintf("Between 1 and %d registration IDs are required", MaxGSTBulkRegIDs),
+					},
+				},
+			},
+		}, nil
+	}
+
+	// Validate the date to answer for, when given
+	asOfDate, err := normalizeGSTDate(req.AsOfDate)
+	if err != nil {
+		return &models.GSTBulkResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid as-of date",
+				MessageCode: 40005,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "asOfDate",
+						Message: "Date must be in YYYY-MM-DD format",
+					},
+				},
+			},
+		}, nil
+	}
+
+	// Collect the distinct valid IDs to look up
+	var lookup []string
+	seen := make(map[string]bool)
+	for _, regID := range req.RegIDs {
+		normalized := utils.NormalizeTaxID(regID)
+		if validateRegID(regID) == nil && !seen[normalized] {
+			lookup = append(lookup, normalized)
+			seen[normalized] = true
+		}
+	}
+
+	// Search for all the registrations at once
+	registrations := make(map[string]*models.GSTRegistration)
+	periods := make(map[string][]models.GSTRegistrationPeriod)
+	if len(lookup) > 0 {
+		var gstRegs []models.GSTRegistration
+		err := s.db.Where("registration_id IN ? AND client_id = ?", lookup, req.ClientID).Find(&gstRegs).Error
+		if err == nil && asOfDate != "" {
+			periods, err = s.registrationPeriods(gstRegs)
+		}
+		if err != nil {
+			return &models.GSTBulkResponse{
+				ReturnCode: 50,
+				Info: &models.GSTInfo{
+					Message:     "Internal server error",
+					MessageCode: 50001,
+				},
+			}, err
+		}
+		for i := range gstRegs {
+			registrations[gstRegs[i].RegistrationID] = &gstRegs[i]
+		}
+	}
+
+	data := &models.GSTBulkData{
+		Total:   len(req.RegIDs),
+		Results: make([]models.GSTBulkResult, 0, len(req.RegIDs)),
+	}
+	for _, regID := range req.RegIDs {
+		response := validateRegID(regID)
+		gstReg := registrations[utils.NormalizeTaxID(regID)]
+		switch {
+		case response != nil:
+			data.Invalid++
+		case gstReg == nil:
+			response = gstNotFoundResponse()
+			data.NotFound++
+		case asOfDate != "":
+			response = gstAsOfResponse(gstReg, coveringPeriod(periods[gstReg.RegistrationID], asOfDate), asOfDate)
+			if response.ReturnCode == 10 {
+				data.Found++
+			} else {
+				data.NotFound++
+			}
+		default:
+			response = gstFoundResponse(gstReg)
+			data.Found++
+		}
+
+		data.Results = append(data.Results, models.GSTBulkResult{
+			RegID:      regID,
+			ReturnCode: response.ReturnCode,
+			Data:       response.Data,
+			Info:       response.Info,
+		})
+	}
+
+	return &models.GSTBulkResponse{
+		ReturnCode: 10,
+		Data:       data,
+	}, nil
+}
+
+// SearchGSTCandidates finds registrations by GST number, UEN or entity name
+// and returns them ranked by match score, a page at a time. Without a
+// searchBy, a query that is a valid GST number or UEN is matched exactly and
+// anything else is matched against names.
+func (s *GSTService) SearchGSTCandidates(req *models.GSTCandidateSearchRequest) (*models.GSTCandidateSearchResponse, error) {
+	// Validate client ID (basic validation)
+	if strings.TrimSpace(req.ClientID) == "" {
+		return gstCandidateErrorResponse("Invalid client ID", 40001, "clientID", "Client ID is required"), nil
+	}
+
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return gstCandidateErrorResponse("Invalid search query", 40002, "query", "Search query is required"), nil
+	}
+
+	searchBy := req.SearchBy
+	switch searchBy {
+	case "":
+		searchBy = GSTSearchByName
+		if utils.IsValidGSTRegID(query) {
+			searchBy = GSTSearchByGSTNumber
+		}
+	case GSTSearchByGSTNumber:
+		if !utils.IsValidGSTRegID(query) {
+			return gstCandidateErrorResponse("Invalid search query", 40002, "query", "Not a valid GST registration number, UEN, NRIC or FIN"), nil
+		}
+	case GSTSearchByUEN:
+		if !utils.IsValidTaxRefID(query) {
+			return gstCandidateErrorResponse("Invalid search query", 40002, "query", "Not a valid UEN, NRIC or FIN"), nil
+		}
+	case GSTSearchByName:
+	default:
+		return gstCandidateErrorResponse("Invalid search mode", 40002, "searchBy", "Search mode must be gstNumber, uen or name"), nil
+	}
+
+	page, limit := req.Page, req.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	var scoreSQL, matchSQL string
+	var scoreArgs, matchArgs []interface{}
+	if searchBy == GSTSearchByName {
+		scoreSQL, scoreArgs, matchSQL, matchArgs = s.nameMatch(query)
+	} else {
+		scoreSQL, scoreArgs, matchSQL, matchArgs = idMatch(searchBy, utils.NormalizeTaxID(query))
+	}
+
+	base := s.db.Model(&models.GSTRegistration{}).Where("client_id = ?", req.ClientID).Where(matchSQL, matchArgs...)
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return gstCandidateServerErrorResponse(), err
+	}
+	if total == 0 {
+		return &models.GSTCandidateSearchResponse{
+			ReturnCode: 20,
+			Info: &models.GSTInfo{
+				Message:     "No matching GST registrations found",
+				MessageCode: 20001,
+			},
+		}, nil
+	}
+
+	var rows []gstCandidateRow
+	err := base.Session(&gorm.Session{}).
+		Select("gst_registrations.*, ("+scoreSQL+") AS score", scoreArgs...).
+		Order("score DESC, name, registration_id").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return gstCandidateServerErrorResponse(), err
+	}
+
+	candidates := make([]models.GSTCandidate, 0, len(rows))
+	for i := range rows {
+		candidates = append(candidates, models.GSTCandidate{
+			GSTData:   *gstFoundResponse(&rows[i].GSTRegistration).Data,
+			Score:     math.Round(rows[i].Score*1000) / 1000,
+			MatchedOn: matchedOn(searchBy, &rows[i].GSTRegistration, utils.NormalizeTaxID(query)),
+		})
+	}
+
+	return &models.GSTCandidateSearchResponse{
+		ReturnCode: 10,
+		Data: &models.GSTCandidateSearchData{
+			SearchBy:   searchBy,
+			Total:      total,
+			Page:       page,
+			Limit:      limit,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+			Candidates: candidates,
+		},
+	}, nil
+}
+
+// idMatch matches registrations exactly on GST number or on the UEN or
+// NRIC/FIN they are registered under
+func idMatch(searchBy, id string) (scoreSQL string, scoreArgs []interface{}, matchSQL string, matchArgs []interface{}) {
+	if searchBy == GSTSearchByUEN {
+		return "1.0", nil, "registration_id = ?", []interface{}{id}
+	}
+	return "1.0", nil, "gst_registration_number = ? OR registration_id = ?", []interface{}{id, id}
+}
+
+// nameMatch ranks registrations by how closely their name matches. Trigram
+// similarity is used when pg_trgm is installed, falling back to ranking
+// exact, prefix and substring ILIKE matches.
+func (s *GSTService) nameMatch(name string) (scoreSQL string, scoreArgs []interface{}, matchSQL string, matchArgs []interface{}) {
+	contains := "%" + escapeLike(name) + "%"
+	prefix := escapeLike(name) + "%"
+
+	if s.hasTrigram() {
+		scoreSQL = "GREATEST(similarity(name, ?), word_similarity(?, name))"
+		matchSQL = "similarity(name, ?) >= ? OR word_similarity(?, name) >= ? OR name ILIKE ?"
+		return scoreSQL, []interface{}{name, name}, matchSQL, []interface{}{name, minNameMatchScore, name, minNameMatchScore, contains}
+	}
+
+	scoreSQL = "CASE WHEN LOWER(name) = LOWER(?) THEN 1.0 WHEN name ILIKE ? THEN 0.8 ELSE 0.6 END"
+	return scoreSQL, []interface{}{name, prefix}, "name ILIKE ?", []interface{}{contains}
+}
+
+// hasTrigram reports whether the pg_trgm extension is installed. The answer
+// is kept once the check succeeds; a failed check falls back to ILIKE
+// ranking and is retried on the next search.
+func (s *GSTService) hasTrigram() bool {
+	s.trigramMu.Lock()
+	defer s.trigramMu.Unlock()
+
+	if !s.trigramChecked {
+		var count int64
+		if err := s.db.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'pg_trgm'").Scan(&count).Error; err != nil {
+			return false
+		}
+		s.trigram = count > 0
+		s.trigramChecked = true
+	}
+	return s.trigram
+}
+
+// matchedOn names the field a candidate was matched by
+func matchedOn(searchBy string, gstReg *models.GSTRegistration, id string) string {
+	switch {
+	case searchBy == GSTSearchByName:
+		return "name"
+	case gstReg.GSTRegistrationNumber == id:
+		return "gstRegistrationNumber"
+	default:
+		return "registrationId"
+	}
+}
+
+// escapeLike escapes the ILIKE wildcards in user input
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// gstCandidateErrorResponse reports an invalid candidate search field
+func gstCandidateErrorResponse(message string, messageCode int, field, fieldMessage string) *models.GSTCandidateSearchResponse {
+	return &models.GSTCandidateSearchResponse{
+		ReturnCode: 40,
+		Info: &models.GSTInfo{
+			Message:     message,
+			MessageCode: messageCode,
+			FieldInfoList: []models.GSTFieldError{
+				{
+					Field:   field,
+					Message: fieldMessage,
+				},
+			},
+		},
+	}
+}
+
+// gstCandidateServerErrorResponse reports a failed candidate search query
+func gstCandidateServerErrorResponse() *models.GSTCandidateSearchResponse {
+	return &models.GSTCandidateSearchResponse{
+		ReturnCode: 50,
+		Info: &models.GSTInfo{
+			Message:     "Internal server error",
+			MessageCode: 50001,
+		},
+	}
+}
+
+// validateRegID returns the error response for a missing or malformed
+// registration ID, or nil when it can be searched
+func validateRegID(regID string) *models.GSTResponse {
+	if strings.TrimSpace(regID) == "" {
+		return &models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid registration ID",
+				MessageCode: 40002,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "regID",
+						Message: "Registration ID is required",
+					},
+				},
+			},
+		}
+	}
+
+	if !utils.IsValidGSTRegID(regID) {
+		return &models.GSTResponse{
+			ReturnCode: 40,
+			Info: &models.GSTInfo{
+				Message:     "Invalid registration ID",
+				MessageCode: 40002,
+				FieldInfoList: []models.GSTFieldError{
+					{
+						Field:   "regID",
+						Message: "Registration ID must be a valid GST registration number, UEN, NRIC or FIN",
+					},
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+// gstFoundResponse returns the details of a GST registration
+func gstFoundResponse(gstReg *models.GSTRegistration) *models.GSTResponse {
 	return &models.GSTResponse{
 		ReturnCode: 10,
 		Data: &models.GSTData{
@@ -89,12 +597,28 @@ func (s *GSTService) SearchGSTRegistered(req *models.GSTRequest) (*models.GSTRes
 			Remarks:               gstReg.Remarks,
 			Status:                gstReg.Status,
 		},
-	}, nil
+	}
+}
+
+// gstNotFoundResponse reports a registration ID with no GST registration
+func gstNotFoundResponse() *models.GSTResponse {
+	return &models.GSTResponse{
+		ReturnCode: 20,
+		Info: &models.GSTInfo{
+			Message:     "GST registration not found",
+			MessageCode: 20001,
+		},
+	}
 }
 
 // CreateGSTRegistration creates a new GST registration record (for admin/setup purposes)
 func (s *GSTService) CreateGSTRegistration(gstReg *models.GSTRegistration) error {
-	return s.db.Create(gstReg).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(gstReg).Error; err != nil {
+			return err
+		}
+		return recordRegistrationPeriod(tx, gstReg)
+	})
 }
 
 // GetGSTRegistrationByID gets GST registration by ID
@@ -107,9 +631,148 @@ func (s *GSTService) GetGSTRegistrationByID(id uint) (*models.GSTRegistration, e
 	return &gstReg, nil
 }
 
-// UpdateGSTRegistration updates GST registration
+// UpdateGSTRegistration updates GST registration. The registration's
+// periods are kept: a new registration date starts a new period and a
+// deregistration date closes the current one. A changed registration ID
+// takes the periods recorded under the old one.
 func (s *GSTService) UpdateGSTRegistration(id uint, updates map[string]interface{}) error {
-	return s.db.Model(&models.GSTRegistration{}).Where("id = ?", id).Updates(updates).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var previous models.GSTRegistration
+		if err := tx.First(&previous, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.GSTRegistration{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var gstReg models.GSTRegistration
+		if err := tx.First(&gstReg, id).Error; err != nil {
+			return err
+		}
+		if gstReg.RegistrationID != previous.RegistrationID {
+			err := tx.Model(&models.GSTRegistrationPeriod{}).
+				Where("registration_id = ?", previous.RegistrationID).
+				Update("registration_id", gstReg.RegistrationID).Error
+			if err != nil {
+				return err
+			}
+		}
+		return recordRegistrationPeriod(tx, &gstReg)
+	})
+}
+
+// GetGSTRegistrationHistory lists the registration periods of a registration
+// ID, earliest first
+func (s *GSTService) GetGSTRegistrationHistory(registrationID string) ([]models.GSTRegistrationPeriod, error) {
+	var periods []models.GSTRegistrationPeriod
+	err := s.db.Where("registration_id = ?", registrationID).Order("registered_from").Find(&periods).Error
+	if err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// recordRegistrationPeriod brings the period history in line with the
+// current state of a registration. A registration date later than the
+// latest period's starts a new period, closing any period left open the day
+// before; otherwise the latest period takes the current dates and status.
+// Dates that would overlap an earlier period return ErrGSTPeriodOverlap.
+func recordRegistrationPeriod(tx *gorm.DB, gstReg *models.GSTRegistration) error {
+	current, err := currentPeriod(gstReg)
+	if err != nil || current == nil {
+		return err
+	}
+
+	var periods []models.GSTRegistrationPeriod
+	err = tx.Where("registration_id = ?", gstReg.RegistrationID).Order("registered_from").Find(&periods).Error
+	if err != nil {
+		return err
+	}
+	if len(periods) == 0 {
+		return tx.Create(current).Error
+	}
+
+	latest := &periods[len(periods)-1]
+	if current.RegisteredFrom > latest.RegisteredFrom {
+		if latest.RegisteredTo == "" {
+			registeredFrom, _ := time.Parse("2006-01-02", current.RegisteredFrom)
+			latest.RegisteredTo = registeredFrom.AddDate(0, 0, -1).Format("2006-01-02")
+			if err := tx.Save(latest).Error; err != nil {
+				return err
+			}
+		} else if current.RegisteredFrom <= latest.RegisteredTo {
+			return periodOverlapError(latest)
+		}
+		return tx.Create(current).Error
+	}
+
+	// A corrected registration date must still start after the period
+	// before the latest one
+	if len(periods) > 1 {
+		previous := &periods[len(periods)-2]
+		if previous.RegisteredTo == "" || current.RegisteredFrom <= previous.RegisteredTo {
+			return periodOverlapError(previous)
+		}
+	}
+
+	latest.GSTRegistrationNumber = current.GSTRegistrationNumber
+	latest.RegisteredFrom = current.RegisteredFrom
+	latest.RegisteredTo = current.RegisteredTo
+	latest.Status = current.Status
+	latest.Remarks = current.Remarks
+	return tx.Save(latest).Error
+}
+
+// periodOverlapError reports the period a registration's dates overlap
+func periodOverlapError(period *models.GSTRegistrationPeriod) error {
+	if period.RegisteredTo == "" {
+		return fmt.Errorf("%w from %s", ErrGSTPeriodOverlap, period.RegisteredFrom)
+	}
+	return fmt.Errorf("%w from %s to %s", ErrGSTPeriodOverlap, period.RegisteredFrom, period.RegisteredTo)
+}
+
+// currentPeriod describes the period a registration is in from its own
+// dates, or returns nil when it has no registration date
+func currentPeriod(gstReg *models.GSTRegistration) (*models.GSTRegistrationPeriod, error) {
+	registeredFrom, err := normalizeGSTDate(gstReg.RegisteredFrom)
+	if err != nil {
+		return nil, fmt.Errorf("%w: registered_from %v", ErrInvalidGSTDate, err)
+	}
+	if registeredFrom == "" {
+		return nil, nil
+	}
+	registeredTo, err := normalizeGSTDate(gstReg.RegisteredTo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: registered_to %v", ErrInvalidGSTDate, err)
+	}
+	if registeredTo != "" && registeredTo < registeredFrom {
+		return nil, fmt.Errorf("%w: registered_to %s is before registered_from %s", ErrInvalidGSTDate, registeredTo, registeredFrom)
+	}
+
+	return &models.GSTRegistrationPeriod{
+		RegistrationID:        gstReg.RegistrationID,
+		GSTRegistrationNumber: gstReg.GSTRegistrationNumber,
+		RegisteredFrom:        registeredFrom,
+		RegisteredTo:          registeredTo,
+		Status:                gstReg.Status,
+		Remarks:               gstReg.Remarks,
+	}, nil
+}
+
+// normalizeGSTDate converts a registration date to YYYY-MM-DD, leaving an
+// empty date empty
+func normalizeGSTDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	for _, layout := range gstDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("%q is not a recognised date", value)
 }
 
 // DeleteGSTRegistration deletes GST registration
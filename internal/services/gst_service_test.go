@@ -0,0 +1,415 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory database private to the test with the GST
+// and access token tables migrated
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&models.GSTRegistration{},
+		&models.GSTRegistrationPeriod{},
+		&models.GSTImportRun{},
+		&models.GSTReturnRecord{},
+		&models.CorpPassAuthRecord{},
+		&models.CorpPassTokenRecord{},
+		&models.SingPassTokenRecord{},
+		&models.AbsdRefundClaim{},
+		&models.PropertyConsolidatedStatementRecord{},
+	)
+	if err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func TestSearchGSTRegisteredBulk(t *testing.T) {
+	db := newTestDB(t)
+	service := NewGSTService(db)
+	registrations := []models.GSTRegistration{
+		{ClientID: "client", RegistrationID: "201912345R", GSTRegistrationNumber: "M90012345X", Name: "Alpha Pte Ltd", RegisteredFrom: "2020-01-01", Status: "Registered"},
+		{ClientID: "client", RegistrationID: "53312345C", GSTRegistrationNumber: "M2-0012345-6", Name: "Beta Trading", RegisteredFrom: "2018-04-01", RegisteredTo: "2022-03-31", Status: "Deregistered"},
+		{ClientID: "other", RegistrationID: "199901234D", Name: "Other Client Pte Ltd", RegisteredFrom: "2019-01-01", Status: "Registered"},
+	}
+	for i := range registrations {
+		if err := service.CreateGSTRegistration(&registrations[i]); err != nil {
+			t.Fatalf("failed to create registration: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		req            models.GSTBulkRequest
+		wantReturnCode int
+		wantCodes      []int
+		wantFound      int
+		wantNotFound   int
+		wantInvalid    int
+	}{
+		{
+			name:           "results in request order",
+			req:            models.GSTBulkRequest{ClientID: "client", RegIDs: []string{"53312345C", " 201912345r ", "12345678M", "201912345X", ""}},
+			wantReturnCode: 10,
+			wantCodes:      []int{10, 10, 20, 40, 40},
+			wantFound:      2,
+			wantNotFound:   1,
+			wantInvalid:    2,
+		},
+		{
+			name:           "repeated IDs each get a result",
+			req:            models.GSTBulkRequest{ClientID: "client", RegIDs: []string{"201912345R", "201912345R"}},
+			wantReturnCode: 10,
+			wantCodes:      []int{10, 10},
+			wantFound:      2,
+		},
+		{
+			name:           "other client's registrations are not found",
+			req:            models.GSTBulkRequest{ClientID: "client", RegIDs: []string{"199901234D"}},
+			wantReturnCode: 10,
+			wantCodes:      []int{20},
+			wantNotFound:   1,
+		},
+		{
+			name:           "missing client ID",
+			req:            models.GSTBulkRequest{RegIDs: []string{"201912345R"}},
+			wantReturnCode: 40,
+		},
+		{
+			name:           "no registration IDs",
+			req:            models.GSTBulkRequest{ClientID: "client"},
+			wantReturnCode: 40,
+		},
+		{
+			name:           "too many registration IDs",
+			req:            models.GSTBulkRequest{ClientID: "client", RegIDs: make([]string, MaxGSTBulkRegIDs+1)},
+			wantReturnCode: 40,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := service.SearchGSTRegisteredBulk(&tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.ReturnCode != tt.wantReturnCode {
+				t.Fatalf("return code = %d, want %d", response.ReturnCode, tt.wantReturnCode)
+			}
+			if tt.wantReturnCode != 10 {
+				if response.Info == nil || len(response.Info.FieldInfoList) == 0 {
+					t.Errorf("expected field errors, got %+v", response.Info)
+				}
+				return
+			}
+
+			data := response.Data
+			if data.Total != len(tt.req.RegIDs) || data.Found != tt.wantFound || data.NotFound != tt.wantNotFound || data.Invalid != tt.wantInvalid {
+				t.Errorf("counts = %d/%d/%d of %d, want %d/%d/%d of %d",
+					data.Found, data.NotFound, data.Invalid, data.Total,
+					tt.wantFound, tt.wantNotFound, tt.wantInvalid, len(tt.req.RegIDs))
+			}
+			if len(data.Results) != len(tt.wantCodes) {
+				t.Fatalf("got %d results, want %d", len(data.Results), len(tt.wantCodes))
+			}
+			for i, result := range data.Results {
+				if result.RegID != tt.req.RegIDs[i] {
+					t.Errorf("result %d is for %q, want %q", i, result.RegID, tt.req.RegIDs[i])
+				}
+				if result.ReturnCode != tt.wantCodes[i] {
+					t.Errorf("result %d return code = %d, want %d", i, result.ReturnCode, tt.wantCodes[i])
+				}
+				if result.ReturnCode == 10 && result.Data.RegistrationID != utils.NormalizeTaxID(result.RegID) {
+					t.Errorf("result %d is registration %s", i, result.Data.RegistrationID)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchGSTRegisteredBulkAsOfDate(t *testing.T) {
+	db := newTestDB(t)
+	service := NewGSTService(db)
+	gstReg := models.GSTRegistration{ClientID: "client", RegistrationID: "201912345R", GSTRegistrationNumber: "M90012345X", Name: "Alpha Pte Ltd", RegisteredFrom: "2018-01-01", RegisteredTo: "2019-12-31", Status: "Deregistered"}
+	if err := service.CreateGSTRegistration(&gstReg); err != nil {
+		t.Fatalf("failed to create registration: %v", err)
+	}
+	if err := service.UpdateGSTRegistration(gstReg.ID, map[string]interface{}{"registered_from": "2021-06-01", "registered_to": "", "status": "Registered"}); err != nil {
+		t.Fatalf("failed to re-register: %v", err)
+	}
+
+	tests := []struct {
+		asOfDate       string
+		wantReturnCode int
+		wantCode       int
+		wantFrom       string
+	}{
+		{"", 10, 10, "2021-06-01"},
+		{"2019-06-30", 10, 10, "2018-01-01"},
+		{"2020-06-30", 10, 20, ""},
+		{"2022-01-01", 10, 10, "2021-06-01"},
+		{"30/06/2019", 10, 10, "2018-01-01"},
+		{"not a date", 40, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asOfDate, func(t *testing.T) {
+			response, err := service.SearchGSTRegisteredBulk(&models.GSTBulkRequest{ClientID: "client", RegIDs: []string{"201912345R", "53312345C"}, AsOfDate: tt.asOfDate})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.ReturnCode != tt.wantReturnCode {
+				t.Fatalf("return code = %d, want %d", response.ReturnCode, tt.wantReturnCode)
+			}
+			if tt.wantReturnCode != 10 {
+				return
+			}
+
+			result := response.Data.Results[0]
+			if result.ReturnCode != tt.wantCode {
+				t.Fatalf("result return code = %d, want %d", result.ReturnCode, tt.wantCode)
+			}
+			if tt.wantCode == 10 && result.Data.RegisteredFrom != tt.wantFrom {
+				t.Errorf("registered from %s, want %s", result.Data.RegisteredFrom, tt.wantFrom)
+			}
+			if response.Data.Results[1].ReturnCode != 20 {
+				t.Errorf("unregistered ID return code = %d, want 20", response.Data.Results[1].ReturnCode)
+			}
+		})
+	}
+}
+
+func TestCoveringPeriod(t *testing.T) {
+	periods := []models.GSTRegistrationPeriod{
+		{RegisteredFrom: "2018-01-01", RegisteredTo: "2019-12-31"},
+		{RegisteredFrom: "2021-06-01"},
+	}
+
+	tests := []struct {
+		date     string
+		wantFrom string
+	}{
+		{"2017-12-31", ""},
+		{"2018-01-01", "2018-01-01"},
+		{"2019-12-31", "2018-01-01"},
+		{"2020-01-01", ""},
+		{"2021-06-01", "2021-06-01"},
+		{"2030-01-01", "2021-06-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			period := coveringPeriod(periods, tt.date)
+			switch {
+			case tt.wantFrom == "" && period != nil:
+				t.Errorf("got period from %s, want none", period.RegisteredFrom)
+			case tt.wantFrom != "" && (period == nil || period.RegisteredFrom != tt.wantFrom):
+				t.Errorf("got %+v, want period from %s", period, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestRecordRegistrationPeriod(t *testing.T) {
+	type dates struct{ from, to string }
+
+	tests := []struct {
+		name        string
+		updates     []dates
+		wantErr     error
+		wantPeriods []dates
+	}{
+		{
+			name:        "first registration",
+			wantPeriods: []dates{{"2018-01-01", ""}},
+		},
+		{
+			name:        "deregistration closes the period",
+			updates:     []dates{{"2018-01-01", "2019-12-31"}},
+			wantPeriods: []dates{{"2018-01-01", "2019-12-31"}},
+		},
+		{
+			name:        "registering again starts a new period",
+			updates:     []dates{{"2018-01-01", "2019-12-31"}, {"2021-06-01", ""}},
+			wantPeriods: []dates{{"2018-01-01", "2019-12-31"}, {"2021-06-01", ""}},
+		},
+		{
+			name:        "later registration date closes an open period",
+			updates:     []dates{{"2021-06-01", ""}},
+			wantPeriods: []dates{{"2018-01-01", "2021-05-31"}, {"2021-06-01", ""}},
+		},
+		{
+			name:        "corrected registration date",
+			updates:     []dates{{"2017-07-01", ""}},
+			wantPeriods: []dates{{"2017-07-01", ""}},
+		},
+		{
+			name:        "new period starting inside a closed one",
+			updates:     []dates{{"2018-01-01", "2019-12-31"}, {"2019-06-01", ""}},
+			wantErr:     ErrGSTPeriodOverlap,
+			wantPeriods: []dates{{"2018-01-01", "2019-12-31"}},
+		},
+		{
+			name:        "corrected date reaching into the previous period",
+			updates:     []dates{{"2018-01-01", "2019-12-31"}, {"2021-06-01", ""}, {"2019-12-31", ""}},
+			wantErr:     ErrGSTPeriodOverlap,
+			wantPeriods: []dates{{"2018-01-01", "2019-12-31"}, {"2021-06-01", ""}},
+		},
+		{
+			name:        "deregistered before registered",
+			updates:     []dates{{"2018-01-01", "2017-12-31"}},
+			wantErr:     ErrInvalidGSTDate,
+			wantPeriods: []dates{{"2018-01-01", ""}},
+		},
+		{
+			name:        "unreadable date",
+			updates:     []dates{{"2018-13-01", ""}},
+			wantErr:     ErrInvalidGSTDate,
+			wantPeriods: []dates{{"2018-01-01", ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewGSTService(newTestDB(t))
+			gstReg := models.GSTRegistration{ClientID: "client", RegistrationID: "201912345R", Name: "Alpha Pte Ltd", RegisteredFrom: "01/01/2018", Status: "Registered"}
+			if err := service.CreateGSTRegistration(&gstReg); err != nil {
+				t.Fatalf("failed to create registration: %v", err)
+			}
+
+			var err error
+			for _, update := range tt.updates {
+				err = service.UpdateGSTRegistration(gstReg.ID, map[string]interface{}{"registered_from": update.from, "registered_to": update.to})
+				if err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			periods, err := service.GetGSTRegistrationHistory("201912345R")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := make([]dates, 0, len(periods))
+			for _, period := range periods {
+				got = append(got, dates{period.RegisteredFrom, period.RegisteredTo})
+			}
+			if !reflect.DeepEqual(got, tt.wantPeriods) {
+				t.Errorf("periods = %v, want %v", got, tt.wantPeriods)
+			}
+		})
+	}
+}
+
+func TestUpdateGSTRegistrationKeepsHistoryOnNewRegistrationID(t *testing.T) {
+	service := NewGSTService(newTestDB(t))
+	gstReg := models.GSTRegistration{ClientID: "client", RegistrationID: "53312345C", Name: "Alpha Trading", RegisteredFrom: "2018-01-01", RegisteredTo: "2019-12-31", Status: "Deregistered"}
+	if err := service.CreateGSTRegistration(&gstReg); err != nil {
+		t.Fatalf("failed to create registration: %v", err)
+	}
+
+	// The business is incorporated and registers again under its company UEN
+	updates := map[string]interface{}{"registration_id": "201912345R", "registered_from": "2021-06-01", "registered_to": "", "status": "Registered"}
+	if err := service.UpdateGSTRegistration(gstReg.ID, updates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	periods, err := service.GetGSTRegistrationHistory("201912345R")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(periods) != 2 || periods[0].RegisteredFrom != "2018-01-01" || periods[1].RegisteredFrom != "2021-06-01" {
+		t.Errorf("periods = %+v, want both periods under the new registration ID", periods)
+	}
+
+	old, err := service.GetGSTRegistrationHistory("53312345C")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(old) != 0 {
+		t.Errorf("%d periods left under the old registration ID", len(old))
+	}
+}
+
+func TestHasTrigramRetriesFailedCheck(t *testing.T) {
+	db := newTestDB(t)
+	service := NewGSTService(db)
+
+	// Without a pg_extension catalog the check fails and is not kept
+	if service.hasTrigram() {
+		t.Fatal("hasTrigram() = true after a failed check")
+	}
+	if service.trigramChecked {
+		t.Fatal("failed check was kept")
+	}
+
+	if err := db.Exec("CREATE TABLE pg_extension (extname TEXT)").Error; err != nil {
+		t.Fatalf("failed to create catalog: %v", err)
+	}
+	if err := db.Exec("INSERT INTO pg_extension (extname) VALUES ('pg_trgm')").Error; err != nil {
+		t.Fatalf("failed to record extension: %v", err)
+	}
+	if !service.hasTrigram() {
+		t.Error("hasTrigram() = false once the check succeeds")
+	}
+}
+
+func TestMatchedOn(t *testing.T) {
+	gstReg := &models.GSTRegistration{RegistrationID: "201912345R", GSTRegistrationNumber: "M90012345X"}
+
+	tests := []struct {
+		searchBy string
+		id       string
+		want     string
+	}{
+		{GSTSearchByName, "Alpha", "name"},
+		{GSTSearchByGSTNumber, "M90012345X", "gstRegistrationNumber"},
+		{GSTSearchByGSTNumber, "201912345R", "registrationId"},
+		{GSTSearchByUEN, "201912345R", "registrationId"},
+	}
+
+	for _, tt := range tests {
+		if got := matchedOn(tt.searchBy, gstReg, tt.id); got != tt.want {
+			t.Errorf("matchedOn(%s, %s) = %s, want %s", tt.searchBy, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"Alpha Pte Ltd": "Alpha Pte Ltd",
+		"100%":          `100\%`,
+		"A_B":           `A\_B`,
+		`C:\Temp`:       `C:\\Temp`,
+		`%_\`:           `\%\_\\`,
+	}
+	for value, want := range tests {
+		if got := escapeLike(value); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxGSTTransListLines is the most lines a transaction listing accepts,
+	// supplies and purchases together
+	MaxGSTTransListLines = 5000
+
+	// Transaction listing statuses. A listing with any line in error is
+	// rejected as a whole and must be resubmitted.
+	GSTTransListStatusAccepted = "ACCEPTED"
+	GSTTransListStatusRejected = "REJECTED"
+
+	// Listings a transaction listing carries
+	gstTransListSupplies  = "supplies"
+	gstTransListPurchases = "purchases"
+)
+
+type GSTTransListService struct {
+	db         *gorm.DB
+	gstService *GSTService
+}
+
+func NewGSTTransListService(db *gorm.DB, gstService *GSTService) *GSTTransListService {
+	return &GSTTransListService{db: db, gstService: gstService}
+}
+
+// SubmitTransactionListing checks every line of a supply and purchase
+// listing against the stored GST registrations and records the listing
+// under a submission reference. Each line's counterparty must have been
+// GST-registered on the invoice date, and its GST cannot exceed the rate
+// then in force.
+func (s *GSTTransListService) SubmitTransactionListing(req *models.GSTTransListRequest) (*models.GSTTransListResponse, error) {
+	_, _, fieldErrors := validateGSTReturnPeriod(&req.FilingInfo)
+	lines := len(req.Supplies) + len(req.Purchases)
+	if lines == 0 || lines > MaxGSTTransListLines {
+		fieldErrors = append(fieldErrors, models.GSTFieldError{
+			Field:   gstTransListSupplies,
+			Message: fmt.Sprintf("Between 1 and %d supply and purchase lines are required", MaxGSTTransListLines),
+		})
+	}
+	if len(fieldErrors) > 0 {
+		return gstTransListErrorResponse("Invalid transaction listing", 40006, fieldErrors), nil
+	}
+
+	// The listing is filed against the client's registration for the period
+	taxRefNo := utils.NormalizeTaxID(req.FilingInfo.TaxRefNo)
+	var gstReg models.GSTRegistration
+	err := s.db.Where("(registration_id = ? OR gst_registration_number = ?) AND client_id = ?", taxRefNo, taxRefNo, req.FilingInfo.ClientID).First(&gstReg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return gstTransListErrorResponse("GST registration not found", 40007, []models.GSTFieldError{
+			{Field: "filingInfo.taxRefNo", Message: "No GST registration found for this tax reference"},
+		}), nil
+	}
+	if err != nil {
+		return gstTransListServerErrorResponse(), err
+	}
+
+	// A CorpPass token only files for the entity it was issued for
+	if req.FilingInfo.EntityID != "" && gstReg.RegistrationID != req.FilingInfo.EntityID {
+		return gstTransListErrorResponse("Not authorised for this registration", 40009, []models.GSTFieldError{
+			{Field: "filingInfo.taxRefNo", Message: "The access token was not issued for this entity"},
+		}), nil
+	}
+
+	period, err := s.gstService.periodCovering(&gstReg, req.FilingInfo.DtPeriodStart)
+	if err != nil {
+		return gstTransListServerErrorResponse(), err
+	}
+	if period == nil {
+		return gstTransListErrorResponse("Not GST-registered for the period", 40007, []models.GSTFieldError{
+			{Field: "filingInfo.dtPeriodStart", Message: "The registration was not in force at the start of the accounting period"},
+		}), nil
+	}
+
+	counterparties, err := s.counterpartyPeriods(req)
+	if err != nil {
+		return gstTransListServerErrorResponse(), err
+	}
+
+	data := &models.GSTTransListData{
+		TaxRefNo:      gstReg.RegistrationID,
+		DtPeriodStart: req.FilingInfo.DtPeriodStart,
+		DtPeriodEnd:   req.FilingInfo.DtPeriodEnd,
+		SupplyLines:   len(req.Supplies),
+		PurchaseLines: len(req.Purchases),
+	}
+	for i, line := range req.Supplies {
+		data.TotalSupplyValue += line.ValueExclGST
+		data.TotalSupplyGST += line.GSTAmount
+		data.LineErrors = append(data.LineErrors, checkGSTTransListLine(gstTransListSupplies, i+1, &line, &req.FilingInfo, counterparties)...)
+	}
+	for i, line := range req.Purchases {
+		data.TotalPurchaseValue += line.ValueExclGST
+		data.TotalPurchaseGST += line.GSTAmount
+		data.LineErrors = append(data.LineErrors, checkGSTTransListLine(gstTransListPurchases, i+1, &line, &req.FilingInfo, counterparties)...)
+	}
+	data.TotalSupplyValue = roundCents(data.TotalSupplyValue)
+	data.TotalSupplyGST = roundCents(data.TotalSupplyGST)
+	data.TotalPurchaseValue = roundCents(data.TotalPurchaseValue)
+	data.TotalPurchaseGST = roundCents(data.TotalPurchaseGST)
+
+	// Count each line in error once, however many problems it has
+	type listingLine struct {
+		listing string
+		line    int
+	}
+	rejected := make(map[listingLine]bool)
+	for _, lineError := range data.LineErrors {
+		rejected[listingLine{lineError.Listing, lineError.Line}] = true
+	}
+	data.RejectedLines = len(rejected)
+	data.Status = GSTTransListStatusAccepted
+	if data.RejectedLines > 0 {
+		data.Status = GSTTransListStatusRejected
+	}
+
+	if err := s.recordListing(req, &gstReg, data); err != nil {
+		return gstTransListServerErrorResponse(), err
+	}
+
+	return &models.GSTTransListResponse{
+		ReturnCode: 10,
+		Data:       data,
+	}, nil
+}
+
+// counterpartyPeriods looks up the registrations of every valid GST number
+// on a listing with a single query, returning their periods keyed by both
+// GST registration number and registration ID
+func (s *GSTTransListService) counterpartyPeriods(req *models.GSTTransListRequest) (map[string][]models.GSTRegistrationPeriod, error) {
+	var lookup []string
+	seen := make(map[string]bool)
+	for _, listing := range [][]models.GSTTransListLine{req.Supplies, req.Purchases} {
+		for _, line := range listing {
+			gstNo := utils.NormalizeTaxID(line.GSTNo)
+			if utils.IsValidGSTNumber(gstNo) && !seen[gstNo] {
+				lookup = append(lookup, gstNo)
+				seen[gstNo] = true
+			}
+		}
+	}
+
+	counterparties := make(map[string][]models.GSTRegistrationPeriod)
+	if len(lookup) == 0 {
+		return counterparties, nil
+	}
+
+	var gstRegs []models.GSTRegistration
+	err := s.db.Where("(registration_id IN ? OR gst_registration_number IN ?) AND client_id = ?", lookup, lookup, req.FilingInfo.ClientID).Find(&gstRegs).Error
+	if err != nil {
+		return nil, err
+	}
+	periods, err := s.gstService.registrationPeriods(gstRegs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range gstRegs {
+		gstRegPeriods := periods[gstRegs[i].RegistrationID]
+		counterparties[gstRegs[i].RegistrationID] = gstRegPeriods
+		if gstRegs[i].GSTRegistrationNumber != "" {
+			counterparties[utils.NormalizeTaxID(gstRegs[i].GSTRegistrationNumber)] = gstRegPeriods
+		}
+	}
+	return counterparties, nil
+}
+
+// recordListing stores a checked listing with its outcome
+func (s *GSTTransListService) recordListing(req *models.GSTTransListRequest, gstReg *models.GSTRegistration, data *models.GSTTransListData) error {
+	submissionRef, err := generateGSTReference("GTL")
+	if err != nil {
+		return err
+	}
+	listingData, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var lineErrors []byte
+	if len(data.LineErrors) > 0 {
+		if lineErrors, err = json.Marshal(data.LineErrors); err != nil {
+			return err
+		}
+	}
+
+	submittedAt := time.Now()
+	err = s.db.Create(&models.GSTTransListRecord{
+		SubmissionRef:      submissionRef,
+		ClientID:           req.FilingInfo.ClientID,
+		RegistrationID:     gstReg.RegistrationID,
+		PeriodStart:        req.FilingInfo.DtPeriodStart,
+		PeriodEnd:          req.FilingInfo.DtPeriodEnd,
+		Status:             data.Status,
+		SupplyLines:        data.SupplyLines,
+		PurchaseLines:      data.PurchaseLines,
+		RejectedLines:      data.RejectedLines,
+		TotalSupplyValue:   data.TotalSupplyValue,
+		TotalSupplyGST:     data.TotalSupplyGST,
+		TotalPurchaseValue: data.TotalPurchaseValue,
+		TotalPurchaseGST:   data.TotalPurchaseGST,
+		LineErrors:         string(lineErrors),
+		ListingData:        string(listingData),
+		SubmittedAt:        submittedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	data.SubmissionRef = submissionRef
+	data.SubmittedAt = submittedAt.Format(time.RFC3339)
+	return nil
+}
+
+// checkGSTTransListLine checks one line of a listing: the invoice must fall
+// in the accounting period, and the counterparty's GST number must be well
+// formed and registered on the invoice date
+func checkGSTTransListLine(listing string, lineNo int, line *models.GSTTransListLine, filingInfo *models.GSTReturnFilingInfo, counterparties map[string][]models.GSTRegistrationPeriod) []models.GSTTransListLineError {
+	var lineErrors []models.GSTTransListLineError
+	lineError := func(field, message string) {
+		lineErrors = append(lineErrors, models.GSTTransListLineError{
+			Listing:   listing,
+			Line:      lineNo,
+			InvoiceNo: line.InvoiceNo,
+			Field:     field,
+			Message:   message,
+		})
+	}
+
+	if line.InvoiceDate < filingInfo.DtPeriodStart || line.InvoiceDate > filingInfo.DtPeriodEnd {
+		lineError("invoiceDate", "Invoice date is outside the accounting period")
+	}
+
+	gstNo := utils.NormalizeTaxID(line.GSTNo)
+	if !utils.IsValidGSTNumber(gstNo) {
+		lineError("gstNo", "Not a valid GST registration number")
+		return lineErrors
+	}
+	periods, ok := counterparties[gstNo]
+	if !ok {
+		lineError("gstNo", "No GST registration found for this GST number")
+		return lineErrors
+	}
+	if coveringPeriod(periods, line.InvoiceDate) == nil {
+		lineError("gstNo", fmt.Sprintf("Not GST-registered on %s", line.InvoiceDate))
+		return lineErrors
+	}
+
+	invoiceDate, _ := time.Parse("2006-01-02", line.InvoiceDate)
+	rate := gstRateOn(invoiceDate)
+	if maxGST := roundCents(math.Abs(line.ValueExclGST) * rate); math.Abs(line.GSTAmount) > maxGST+gstReturnTolerance {
+		lineError("gstAmount", fmt.Sprintf("GST cannot exceed %.0f%% of the value, %.2f", rate*100, maxGST))
+	}
+	return lineErrors
+}
+
+// gstTransListErrorResponse reports a listing that cannot be submitted
+func gstTransListErrorResponse(message string, messageCode int, fieldErrors []models.GSTFieldError) *models.GSTTransListResponse {
+	return &models.GSTTransListResponse{
+		ReturnCode: 40,
+		Info: &models.GSTInfo{
+			Message:       message,
+			MessageCode:   messageCode,
+			FieldInfoList: fieldErrors,
+		},
+	}
+}
+
+// gstTransListServerErrorResponse reports a failure submitting the listing
+func gstTransListServerErrorResponse() *models.GSTTransListResponse {
+	return &models.GSTTransListResponse{
+		ReturnCode: 50,
+		Info: &models.GSTInfo{
+			Message:     "Internal server error",
+			MessageCode: 50001,
+		},
+	}
+}
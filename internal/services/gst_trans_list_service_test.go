@@ -0,0 +1,88 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"testing"
+)
+
+func TestCheckGSTTransListLine(t *testing.T) {
+	filingInfo := &models.GSTReturnFilingInfo{DtPeriodStart: "2023-10-01", DtPeriodEnd: "2024-03-31"}
+	counterparties := map[string][]models.GSTRegistrationPeriod{
+		"M90012345X": {{RegisteredFrom: "2020-01-01"}},
+		"M2-0012345-6": {
+			{RegisteredFrom: "2018-01-01", RegisteredTo: "2023-11-30"},
+			{RegisteredFrom: "2024-02-01"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		line       models.GSTTransListLine
+		wantFields []string
+	}{
+		{
+			name: "valid line at 9%",
+			line: models.GSTTransListLine{InvoiceNo: "INV-1", InvoiceDate: "2024-01-15", GSTNo: "m90012345x", ValueExclGST: 1000, GSTAmount: 90},
+		},
+		{
+			name: "valid line at 8% in 2023",
+			line: models.GSTTransListLine{InvoiceNo: "INV-2", InvoiceDate: "2023-12-15", GSTNo: "M90012345X", ValueExclGST: 1000, GSTAmount: 80},
+		},
+		{
+			name: "credit note",
+			line: models.GSTTransListLine{InvoiceNo: "CN-1", InvoiceDate: "2024-01-15", GSTNo: "M90012345X", ValueExclGST: -1000, GSTAmount: -90},
+		},
+		{
+			name:       "9% charged in 2023",
+			line:       models.GSTTransListLine{InvoiceNo: "INV-3", InvoiceDate: "2023-12-15", GSTNo: "M90012345X", ValueExclGST: 1000, GSTAmount: 90},
+			wantFields: []string{"gstAmount"},
+		},
+		{
+			name:       "invoice outside the period",
+			line:       models.GSTTransListLine{InvoiceNo: "INV-4", InvoiceDate: "2024-04-01", GSTNo: "M90012345X", ValueExclGST: 1000, GSTAmount: 90},
+			wantFields: []string{"invoiceDate"},
+		},
+		{
+			name:       "malformed GST number",
+			line:       models.GSTTransListLine{InvoiceNo: "INV-5", InvoiceDate: "2024-01-15", GSTNo: "X123", ValueExclGST: 1000, GSTAmount: 90},
+			wantFields: []string{"gstNo"},
+		},
+		{
+			name:       "unregistered GST number",
+			line:       models.GSTTransListLine{InvoiceNo: "INV-6", InvoiceDate: "2024-01-15", GSTNo: "53312345C", ValueExclGST: 1000, GSTAmount: 90},
+			wantFields: []string{"gstNo"},
+		},
+		{
+			name:       "counterparty between registrations",
+			line:       models.GSTTransListLine{InvoiceNo: "INV-7", InvoiceDate: "2023-12-15", GSTNo: "M2-0012345-6", ValueExclGST: 1000, GSTAmount: 80},
+			wantFields: []string{"gstNo"},
+		},
+		{
+			name: "counterparty registered again",
+			line: models.GSTTransListLine{InvoiceNo: "INV-8", InvoiceDate: "2024-02-01", GSTNo: "M2-0012345-6", ValueExclGST: 1000, GSTAmount: 90},
+		},
+		{
+			name:       "outside the period and unregistered",
+			line:       models.GSTTransListLine{InvoiceNo: "INV-9", InvoiceDate: "2023-09-30", GSTNo: "53312345C", ValueExclGST: 1000, GSTAmount: 80},
+			wantFields: []string{"invoiceDate", "gstNo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineErrors := checkGSTTransListLine(gstTransListSupplies, 3, &tt.line, filingInfo, counterparties)
+			if len(lineErrors) != len(tt.wantFields) {
+				t.Fatalf("got line errors %v, want errors on %v", lineErrors, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				lineError := lineErrors[i]
+				if lineError.Field != field {
+					t.Errorf("line error %d is on %s, want %s", i, lineError.Field, field)
+				}
+				if lineError.Listing != gstTransListSupplies || lineError.Line != 3 || lineError.InvoiceNo != tt.line.InvoiceNo {
+					t.Errorf("line error %d does not identify the line: %+v", i, lineError)
+				}
+			}
+		})
+	}
+}
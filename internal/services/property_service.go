@@ -2,6 +2,7 @@ package services
 
 import (
 	"api-iras/internal/models"
+	"api-iras/pkg/utils"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -54,9 +55,25 @@ func (s *PropertyService) RetrieveConsolidatedStatement(req *models.PropertyCons
 		}, nil
 	}
 
+	if !utils.IsValidPropertyTaxRef(req.PropertyTaxRef) {
+		return &models.PropertyConsolidatedStatementResponse{
+			ReturnCode: 40,
+			Info: &models.PropertyConsolidatedStatementInfo{
+				Message:     "Invalid property tax reference",
+				MessageCode: 40002,
+				FieldInfoList: []models.PropertyConsolidatedFieldError{
+					{
+						Field:   "propertyTaxRef",
+						Message: "Not a valid property tax reference",
+					},
+				},
+			},
+		}, nil
+	}
+
 	// Search for property consolidated statement in database
 	var propertyRecord models.PropertyConsolidatedStatementRecord
-	err := s.db.Where("ref_no = ? AND property_tax_ref = ?", req.RefNo, req.PropertyTaxRef).First(&propertyRecord).Error
+	err := s.db.Where("ref_no = ? AND property_tax_ref = ?", req.RefNo, utils.NormalizeTaxID(req.PropertyTaxRef)).First(&propertyRecord).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -206,11 +223,36 @@ func (s *PropertyService) SearchPropertyTaxBalance(req *models.PropertyTaxBalanc
 		}, nil
 	}
 
+	// Validate the tax references searched by, when given
+	var fieldErrors []models.PropertyTaxBalanceSearchFieldError
+	if req.PptyTaxRefNo != "" && !utils.IsValidPropertyTaxRef(req.PptyTaxRefNo) {
+		fieldErrors = append(fieldErrors, models.PropertyTaxBalanceSearchFieldError{
+			Field:   "pptyTaxRefNo",
+			Message: "Not a valid property tax reference",
+		})
+	}
+	if req.OwnerTaxRefID != "" && !utils.IsValidTaxRefID(req.OwnerTaxRefID) {
+		fieldErrors = append(fieldErrors, models.PropertyTaxBalanceSearchFieldError{
+			Field:   "ownerTaxRefID",
+			Message: "Owner tax reference must be a valid UEN, NRIC or FIN",
+		})
+	}
+	if len(fieldErrors) > 0 {
+		return &models.PropertyTaxBalanceSearchResponse{
+			ReturnCode: 40,
+			Info: &models.PropertyTaxBalanceSearchInfo{
+				Message:       "Invalid search criteria",
+				MessageCode:   40002,
+				FieldInfoList: fieldErrors,
+			},
+		}, nil
+	}
+
 	// Build query based on provided criteria
 	query := s.db.Where("client_id = ?", req.ClientID)
 
 	if req.PptyTaxRefNo != "" {
-		query = query.Where("property_tax_reference_no = ?", req.PptyTaxRefNo)
+		query = query.Where("property_tax_reference_no = ?", utils.NormalizeTaxID(req.PptyTaxRefNo))
 	}
 	if req.PostalCode != "" {
 		query = query.Where("postal_code = ?", req.PostalCode)
@@ -228,7 +270,7 @@ func (s *PropertyService) SearchPropertyTaxBalance(req *models.PropertyTaxBalanc
 		query = query.Where("unit_no = ?", req.UnitNo)
 	}
 	if req.OwnerTaxRefID != "" {
-		query = query.Where("owner_tax_ref_id = ?", req.OwnerTaxRefID)
+		query = query.Where("owner_tax_ref_id = ?", utils.NormalizeTaxID(req.OwnerTaxRefID))
 	}
 
 	// Search for property tax balance in database
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"testing"
+)
+
+func TestRetrieveConsolidatedStatementNormalizesTaxRef(t *testing.T) {
+	db := newTestDB(t)
+	service := NewPropertyService(db)
+
+	record := models.PropertyConsolidatedStatementRecord{RefNo: "REF-1", PropertyTaxRef: "1234567890B", StatementDate: "2024-01-31"}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatalf("failed to seed statement: %v", err)
+	}
+
+	response, err := service.RetrieveConsolidatedStatement(&models.PropertyConsolidatedStatementRequest{RefNo: "REF-1", PropertyTaxRef: " 1234567890b "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Data == nil || response.Data.PropertyTaxRef != record.PropertyTaxRef {
+		t.Fatalf("Data = %+v, want the stored statement for %s", response.Data, record.PropertyTaxRef)
+	}
+}
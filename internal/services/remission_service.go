@@ -0,0 +1,415 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Roles of the parties to an eStamp document
+const (
+	PartyRoleLessor      = "lessor"
+	PartyRoleLessee      = "lessee"
+	PartyRoleTransferor  = "transferor"
+	PartyRoleTransferee  = "transferee"
+	PartyRoleSeller      = "seller"
+	PartyRoleBuyer       = "buyer"
+	PartyRoleMortgagor   = "mortgagor"
+	PartyRoleMortgagee   = "mortgagee"
+	PartyRoleTrustee     = "trustee"
+	PartyRoleBeneficiary = "beneficiary"
+)
+
+// RemissionParty is a party to an eStamp document as seen by the remission
+// eligibility checks
+type RemissionParty struct {
+	Role          string
+	Sequence      int
+	TypeOfProfile int
+	Name          string
+}
+
+// RemissionClaim is a remission listed in a request's AssessmentRemissions.
+// Reference is the supporting reference given in remissionOptionText1.
+type RemissionClaim struct {
+	Sequence      int
+	RemissionType string
+	Reference     string
+}
+
+// RemissionContext carries the parts of an eStamp request that remission
+// eligibility is checked against
+type RemissionContext struct {
+	DocumentType  string
+	Parties       []RemissionParty
+	PropertyTypes []int
+	MarriedCouple bool
+}
+
+// remissionType describes a stamp duty remission. DutyTypes limits the
+// remission to those duty components; an empty list remits every component.
+// Reference names the supporting reference a claim must give, such as a
+// licence or approval number, or is empty when none is needed. Eligible
+// returns the reason a request does not qualify, or "" if it does.
+type remissionType struct {
+	Description  string
+	DocumentType string
+	DutyTypes    []string
+	Share        float64
+	Reference    string
+	Eligible     func(ctx *RemissionContext) string
+}
+
+// remissionCatalogue lists the remissions recognised in AssessmentRemissions
+var remissionCatalogue = map[string]remissionType{
+	"TA_CHARITY": {
+		Description:  "Lease to an approved Institution of a Public Character",
+		DocumentType: DocumentTypeTenancy,
+		Share:        1,
+		Reference:    "The IPC approval reference",
+		Eligible:     requireEntities(PartyRoleLessee, "The tenant must be an approved charity"),
+	},
+	"TA_SOCIAL_SERVICE": {
+		Description:  "Lease of premises for government-funded social services",
+		DocumentType: DocumentTypeTenancy,
+		Share:        1,
+		Reference:    "The funding approval reference of the social service",
+		Eligible:     requireEntities(PartyRoleLessee, "The tenant must be a social service organisation"),
+	},
+	"ST_RECONSTRUCTION": {
+		Description:  "Transfer of shares under a reconstruction or amalgamation of companies",
+		DocumentType: DocumentTypeShareTransfer,
+		Share:        1,
+		Reference:    "The approval reference for the reconstruction or amalgamation relief",
+		Eligible:     requireEntities(PartyRoleTransferor+","+PartyRoleTransferee, "Transferor and transferee must both be companies"),
+	},
+	"ST_ASSOCIATED": {
+		Description:  "Transfer of shares between associated companies",
+		DocumentType: DocumentTypeShareTransfer,
+		Share:        1,
+		Reference:    "Evidence of the association, such as the approval reference for associated companies relief",
+		Eligible:     requireEntities(PartyRoleTransferor+","+PartyRoleTransferee, "Transferor and transferee must both be companies"),
+	},
+	"ABSD_DEVELOPER": {
+		Description:  "ABSD remission for licensed housing developers",
+		DocumentType: DocumentTypeBuyers,
+		DutyTypes:    []string{"ABSD"},
+		Share:        1,
+		Reference:    "The housing developer licence number",
+		Eligible: func(ctx *RemissionContext) string {
+			if reason := requireEntities(PartyRoleBuyer, "Every buyer must be a housing developer")(ctx); reason != "" {
+				return reason
+			}
+			return requireResidential(ctx)
+		},
+	},
+	"ABSD_SPOUSE": {
+		Description:  "ABSD remission for adding a spouse to the title of a matrimonial home",
+		DocumentType: DocumentTypeBuyers,
+		DutyTypes:    []string{"ABSD"},
+		Share:        1,
+		Eligible: func(ctx *RemissionContext) string {
+			if !ctx.MarriedCouple {
+				return "Buyers must be a married couple"
+			}
+			if reason := requireIndividuals(PartyRoleBuyer, "Every buyer must be an individual")(ctx); reason != "" {
+				return reason
+			}
+			return requireResidential(ctx)
+		},
+	},
+	"SSD_DIVORCE": {
+		Description:  "SSD remission for a transfer under a divorce or separation order",
+		DocumentType: DocumentTypeSellers,
+		DutyTypes:    []string{"SSD"},
+		Share:        1,
+		Eligible:     requireIndividuals(PartyRoleSeller+","+PartyRoleBuyer, "Sellers and buyers must be individuals"),
+	},
+	"SSD_ESTATE": {
+		Description:  "SSD remission for the disposal of a deceased owner's property",
+		DocumentType: DocumentTypeSellers,
+		DutyTypes:    []string{"SSD"},
+		Share:        1,
+		Eligible: func(ctx *RemissionContext) string {
+			if countRole(ctx, PartyRoleSeller) == 0 {
+				return "The estate's executor must be listed as a seller"
+			}
+			return ""
+		},
+	},
+}
+
+// requireEntities returns an eligibility check that every party in the given
+// comma-separated roles is an entity and that each role is present
+func requireEntities(roles, reason string) func(ctx *RemissionContext) string {
+	return requireProfile(roles, TypeOfProfileEntity, reason)
+}
+
+// requireIndividuals returns an eligibility check that every party in the
+// given comma-separated roles is an individual and that each role is present
+func requireIndividuals(roles, reason string) func(ctx *RemissionContext) string {
+	return requireProfile(roles, TypeOfProfileIndividual, reason)
+}
+
+func requireProfile(roles string, typeOfProfile int, reason string) func(ctx *RemissionContext) string {
+	return func(ctx *RemissionContext) string {
+		for _, role := range strings.Split(roles, ",") {
+			if countRole(ctx, role) == 0 {
+				return reason
+			}
+			for _, party := range ctx.Parties {
+				if party.Role == role && party.TypeOfProfile != typeOfProfile {
+					return reason
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// requireResidential checks that at least one property is residential
+func requireResidential(ctx *RemissionContext) string {
+	for _, propertyType := range ctx.PropertyTypes {
+		if propertyType == PropertyTypeResidential {
+			return ""
+		}
+	}
+	return "At least one residential property is required"
+}
+
+func countRole(ctx *RemissionContext, role string) int {
+	count := 0
+	for _, party := range ctx.Parties {
+		if party.Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+type RemissionService struct{}
+
+func NewRemissionService() *RemissionService {
+	return &RemissionService{}
+}
+
+// ApplyTenancyRemissions applies the remissions claimed on a tenancy agreement
+func (s *RemissionService) ApplyTenancyRemissions(req *models.StampTenancyAgreementRequest, breakdown *models.EStampDutyBreakdown) error {
+	ctx := &RemissionContext{
+		DocumentType:  DocumentTypeTenancy,
+		PropertyTypes: propertyTypes(req.Assets.Properties),
+	}
+	ctx.Parties = append(ctx.Parties, partyDataParties(PartyRoleLessor, req.LandlordLessor)...)
+	ctx.Parties = append(ctx.Parties, partyDataParties(PartyRoleLessee, req.TenantLessee)...)
+
+	return s.apply(ctx, remissionClaims(req.AssessmentRemissions), breakdown)
+}
+
+// ApplyShareTransferRemissions applies the remissions claimed on a share transfer
+func (s *RemissionService) ApplyShareTransferRemissions(req *models.ShareTransferRequest, breakdown *models.EStampDutyBreakdown) error {
+	ctx := &RemissionContext{
+		DocumentType:  DocumentTypeShareTransfer,
+		PropertyTypes: propertyTypes(req.Assets.Properties),
+	}
+	ctx.Parties = append(ctx.Parties, partyDataParties(PartyRoleTransferor, req.Transferor)...)
+	ctx.Parties = append(ctx.Parties, partyDataParties(PartyRoleTransferee, req.Transferee)...)
+
+	return s.apply(ctx, remissionClaims(req.AssessmentRemissions), breakdown)
+}
+
+// ApplyBuyersRemissions applies the remissions claimed on a sale and purchase
+// document for buyers
+func (s *RemissionService) ApplyBuyersRemissions(req *models.SalePurchaseBuyersRequest, breakdown *models.EStampDutyBreakdown) error {
+	ctx := &RemissionContext{
+		DocumentType:  DocumentTypeBuyers,
+		PropertyTypes: propertyTypes(req.Assets.Properties),
+		MarriedCouple: req.MaritalStatus.MarriedCouple == 1,
+	}
+	for _, party := range req.SellerTransferor {
+		ctx.Parties = append(ctx.Parties, RemissionParty{Role: PartyRoleSeller, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, Name: party.FTTaxEntityName})
+	}
+	for _, party := range req.BuyerTransferee {
+		ctx.Parties = append(ctx.Parties, RemissionParty{Role: PartyRoleBuyer, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, Name: party.FTTaxEntityName})
+	}
+
+	return s.apply(ctx, remissionClaims(req.AssessmentRemissions), breakdown)
+}
+
+// ApplySellersRemissions applies the remissions claimed on a sale and
+// purchase document for sellers
+func (s *RemissionService) ApplySellersRemissions(req *models.SalePurchaseSellersRequest, breakdown *models.EStampDutyBreakdown) error {
+	ctx := &RemissionContext{
+		DocumentType:  DocumentTypeSellers,
+		PropertyTypes: propertyTypes(req.Assets.Properties),
+	}
+	for _, party := range req.SellerTransferor {
+		ctx.Parties = append(ctx.Parties, RemissionParty{Role: PartyRoleSeller, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, Name: party.FTTaxEntityName})
+	}
+	for _, party := range req.BuyerTransferee {
+		ctx.Parties = append(ctx.Parties, RemissionParty{Role: PartyRoleBuyer, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, Name: party.FTTaxEntityName})
+	}
+
+	return s.apply(ctx, remissionClaims(req.AssessmentRemissions), breakdown)
+}
+
+// apply checks each claimed remission against the catalogue and the request,
+// then adds a remission line for each one to the breakdown. Remissions apply
+// in turn to the duty left after earlier remissions. All problems are
+// returned together as DutyFieldErrors.
+func (s *RemissionService) apply(ctx *RemissionContext, claims []RemissionClaim, breakdown *models.EStampDutyBreakdown) error {
+	var fieldErrors DutyFieldErrors
+	var eligible []RemissionClaim
+	seen := make(map[string]bool)
+
+	for i, claim := range claims {
+		field := fmt.Sprintf("assessmentRemissions[%d].remissionType", i)
+		code := strings.ToUpper(strings.TrimSpace(claim.RemissionType))
+
+		catalogueEntry, ok := remissionCatalogue[code]
+		switch {
+		case !ok:
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field, Message: fmt.Sprintf("Unknown remission type %q", claim.RemissionType)})
+			continue
+		case catalogueEntry.DocumentType != ctx.DocumentType:
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field, Message: fmt.Sprintf("Remission type %s does not apply to this document", code)})
+			continue
+		case seen[code]:
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field, Message: fmt.Sprintf("Remission type %s is listed more than once", code)})
+			continue
+		}
+		seen[code] = true
+
+		if reason := catalogueEntry.Eligible(ctx); reason != "" {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field, Message: fmt.Sprintf("Not eligible for remission type %s: %s", code, reason)})
+			continue
+		}
+		claim.Reference = strings.TrimSpace(claim.Reference)
+		if catalogueEntry.Reference != "" && claim.Reference == "" {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{
+				Field:   fmt.Sprintf("assessmentRemissions[%d].remissionOptionText1", i),
+				Message: fmt.Sprintf("Not eligible for remission type %s: %s is required", code, catalogueEntry.Reference),
+			})
+			continue
+		}
+
+		claim.RemissionType = code
+		eligible = append(eligible, claim)
+	}
+
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+
+	// Duty not yet remitted, by duty type in the order the types first
+	// appear in the breakdown
+	var dutyTypes []string
+	remaining := make(map[string]float64)
+	for _, component := range breakdown.Components {
+		if component.Duty <= 0 {
+			continue
+		}
+		if _, ok := remaining[component.DutyType]; !ok {
+			dutyTypes = append(dutyTypes, component.DutyType)
+		}
+		remaining[component.DutyType] += component.Duty
+	}
+
+	for _, claim := range eligible {
+		catalogueEntry := remissionCatalogue[claim.RemissionType]
+		remits := func(dutyType string) bool {
+			return len(catalogueEntry.DutyTypes) == 0 || containsString(catalogueEntry.DutyTypes, dutyType)
+		}
+
+		var remissible float64
+		for _, dutyType := range dutyTypes {
+			if remits(dutyType) {
+				remissible += remaining[dutyType]
+			}
+		}
+
+		amount := math.Min(math.Floor(roundCents(remissible*catalogueEntry.Share)), breakdown.TotalDuty)
+		if amount <= 0 {
+			continue
+		}
+
+		remarks := catalogueEntry.Description
+		if claim.Reference != "" {
+			remarks += ", ref " + claim.Reference
+		}
+		breakdown.Components = append(breakdown.Components, models.EStampDutyComponent{
+			DutyType:      "REMISSION",
+			Duty:          -amount,
+			RemissionType: claim.RemissionType,
+			Remarks:       remarks,
+		})
+		breakdown.TotalDuty -= amount
+
+		// Later remissions only reach the duty this one left
+		left := amount
+		for _, dutyType := range dutyTypes {
+			if left <= 0 {
+				break
+			}
+			if remits(dutyType) {
+				deducted := math.Min(left, remaining[dutyType])
+				remaining[dutyType] -= deducted
+				left -= deducted
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemittedDuty returns the total duty remitted in a breakdown
+func (s *RemissionService) RemittedDuty(breakdown *models.EStampDutyBreakdown) float64 {
+	return remittedDuty(breakdown, "")
+}
+
+// remittedDuty returns the duty remitted in a breakdown by remissions that
+// apply to dutyType, or by every remission when dutyType is empty
+func remittedDuty(breakdown *models.EStampDutyBreakdown, dutyType string) float64 {
+	var remitted float64
+	for _, component := range breakdown.Components {
+		if component.DutyType != "REMISSION" {
+			continue
+		}
+		if dutyType != "" && !containsString(remissionCatalogue[component.RemissionType].DutyTypes, dutyType) {
+			continue
+		}
+		remitted -= component.Duty
+	}
+	return remitted
+}
+
+// remissionClaims lists the remissions claimed in a request's
+// AssessmentRemissions, in either the basic or the advanced request format
+func remissionClaims[T models.AssessmentRemissionData | models.AdvancedAssessmentRemission](remissions []T) []RemissionClaim {
+	claims := make([]RemissionClaim, 0, len(remissions))
+	for _, remission := range remissions {
+		switch remission := any(remission).(type) {
+		case models.AssessmentRemissionData:
+			claims = append(claims, RemissionClaim{Sequence: remission.Sequence, RemissionType: remission.RemissionType, Reference: remission.RemissionOptionText1})
+		case models.AdvancedAssessmentRemission:
+			claims = append(claims, RemissionClaim{Sequence: remission.Sequence, RemissionType: remission.RemissionType, Reference: remission.RemissionOptionText1})
+		}
+	}
+	return claims
+}
+
+func partyDataParties(role string, parties []models.PartyData) []RemissionParty {
+	result := make([]RemissionParty, 0, len(parties))
+	for _, party := range parties {
+		result = append(result, RemissionParty{Role: role, Sequence: party.Sequence, TypeOfProfile: party.TypeOfProfile, Name: party.FTTaxEntityName})
+	}
+	return result
+}
+
+func propertyTypes(properties []models.PropertyData) []int {
+	result := make([]int, 0, len(properties))
+	for _, property := range properties {
+		result = append(result, property.PropertyType)
+	}
+	return result
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestRemissionServiceApply(t *testing.T) {
+	// Partial remissions show how each applies to the duty left by the last
+	remissionCatalogue["TEST_HALF_A"] = remissionType{DocumentType: DocumentTypeTenancy, Share: 0.5, Eligible: func(*RemissionContext) string { return "" }}
+	remissionCatalogue["TEST_HALF_B"] = remissionType{DocumentType: DocumentTypeTenancy, Share: 0.5, Eligible: func(*RemissionContext) string { return "" }}
+	remissionCatalogue["TEST_HALF_ABSD"] = remissionType{DocumentType: DocumentTypeBuyers, DutyTypes: []string{"ABSD"}, Share: 0.5, Eligible: func(*RemissionContext) string { return "" }}
+	t.Cleanup(func() {
+		delete(remissionCatalogue, "TEST_HALF_A")
+		delete(remissionCatalogue, "TEST_HALF_B")
+		delete(remissionCatalogue, "TEST_HALF_ABSD")
+	})
+
+	charity := &RemissionContext{
+		DocumentType: DocumentTypeTenancy,
+		Parties: []RemissionParty{
+			{Role: PartyRoleLessor, Sequence: 1, TypeOfProfile: TypeOfProfileIndividual},
+			{Role: PartyRoleLessee, Sequence: 1, TypeOfProfile: TypeOfProfileEntity},
+		},
+	}
+	developer := &RemissionContext{
+		DocumentType:  DocumentTypeBuyers,
+		Parties:       []RemissionParty{{Role: PartyRoleBuyer, Sequence: 1, TypeOfProfile: TypeOfProfileEntity}},
+		PropertyTypes: []int{PropertyTypeResidential},
+	}
+
+	tests := []struct {
+		name         string
+		ctx          *RemissionContext
+		claims       []string
+		noReference  bool
+		components   []models.EStampDutyComponent
+		wantRemitted []float64
+		wantTotal    float64
+		wantErrors   []string
+	}{
+		{
+			name:         "full remission",
+			ctx:          charity,
+			claims:       []string{"ta_charity"},
+			components:   []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantRemitted: []float64{1000},
+			wantTotal:    0,
+		},
+		{
+			name:         "second full remission has nothing left",
+			ctx:          charity,
+			claims:       []string{"TA_CHARITY", "TA_SOCIAL_SERVICE"},
+			components:   []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantRemitted: []float64{1000},
+			wantTotal:    0,
+		},
+		{
+			name:         "partial remissions stack on the duty left",
+			ctx:          charity,
+			claims:       []string{"TEST_HALF_A", "TEST_HALF_B"},
+			components:   []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantRemitted: []float64{500, 250},
+			wantTotal:    250,
+		},
+		{
+			name:   "duty type remission after partial remission",
+			ctx:    developer,
+			claims: []string{"TEST_HALF_ABSD", "ABSD_DEVELOPER"},
+			components: []models.EStampDutyComponent{
+				{DutyType: "BSD", Duty: 24600},
+				{DutyType: "ABSD", Duty: 280000},
+			},
+			wantRemitted: []float64{140000, 140000},
+			wantTotal:    24600,
+		},
+		{
+			name:   "later remission of a fully remitted duty type",
+			ctx:    developer,
+			claims: []string{"ABSD_DEVELOPER", "TEST_HALF_ABSD"},
+			components: []models.EStampDutyComponent{
+				{DutyType: "BSD", Duty: 24600},
+				{DutyType: "ABSD", Duty: 280000},
+			},
+			wantRemitted: []float64{280000},
+			wantTotal:    24600,
+		},
+		{
+			name:       "unknown remission",
+			ctx:        charity,
+			claims:     []string{"TA_UNKNOWN"},
+			components: []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantTotal:  1000,
+			wantErrors: []string{`Unknown remission type "TA_UNKNOWN"`},
+		},
+		{
+			name:       "remission for another document",
+			ctx:        charity,
+			claims:     []string{"SSD_ESTATE"},
+			components: []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantTotal:  1000,
+			wantErrors: []string{"Remission type SSD_ESTATE does not apply to this document"},
+		},
+		{
+			name:       "remission listed twice",
+			ctx:        charity,
+			claims:     []string{"TA_CHARITY", "ta_charity "},
+			components: []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantTotal:  1000,
+			wantErrors: []string{"Remission type TA_CHARITY is listed more than once"},
+		},
+		{
+			name:        "developer without a licence number",
+			ctx:         developer,
+			claims:      []string{"ABSD_DEVELOPER"},
+			noReference: true,
+			components:  []models.EStampDutyComponent{{DutyType: "ABSD", Duty: 280000}},
+			wantTotal:   280000,
+			wantErrors:  []string{"Not eligible for remission type ABSD_DEVELOPER: The housing developer licence number is required"},
+		},
+		{
+			name:        "charity without an IPC approval",
+			ctx:         charity,
+			claims:      []string{"TA_CHARITY"},
+			noReference: true,
+			components:  []models.EStampDutyComponent{{DutyType: "LEASE", Duty: 1000}},
+			wantTotal:   1000,
+			wantErrors:  []string{"Not eligible for remission type TA_CHARITY: The IPC approval reference is required"},
+		},
+		{
+			name:       "ineligible parties",
+			ctx:        developer,
+			claims:     []string{"ABSD_SPOUSE"},
+			components: []models.EStampDutyComponent{{DutyType: "ABSD", Duty: 280000}},
+			wantTotal:  280000,
+			wantErrors: []string{"Not eligible for remission type ABSD_SPOUSE: Buyers must be a married couple"},
+		},
+	}
+
+	service := NewRemissionService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := &models.EStampDutyBreakdown{Components: append([]models.EStampDutyComponent(nil), tt.components...)}
+			for _, component := range tt.components {
+				breakdown.TotalDuty += component.Duty
+			}
+			var claims []RemissionClaim
+			for i, code := range tt.claims {
+				claim := RemissionClaim{Sequence: i + 1, RemissionType: code}
+				if !tt.noReference {
+					claim.Reference = "REF-1"
+				}
+				claims = append(claims, claim)
+			}
+
+			err := service.apply(tt.ctx, claims, breakdown)
+
+			var fieldErrors DutyFieldErrors
+			if len(tt.wantErrors) > 0 {
+				if !errors.As(err, &fieldErrors) {
+					t.Fatalf("expected field errors, got %v", err)
+				}
+				if len(fieldErrors) != len(tt.wantErrors) {
+					t.Fatalf("got %d field errors, want %d: %v", len(fieldErrors), len(tt.wantErrors), fieldErrors)
+				}
+				for i, want := range tt.wantErrors {
+					if fieldErrors[i].Message != want {
+						t.Errorf("field error %d = %q, want %q", i, fieldErrors[i].Message, want)
+					}
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var remitted []float64
+			for _, component := range breakdown.Components {
+				if component.DutyType == "REMISSION" {
+					remitted = append(remitted, -component.Duty)
+				}
+			}
+			if len(remitted) != len(tt.wantRemitted) {
+				t.Fatalf("remitted %v, want %v", remitted, tt.wantRemitted)
+			}
+			for i := range remitted {
+				if remitted[i] != tt.wantRemitted[i] {
+					t.Errorf("remission %d = %.2f, want %.2f", i+1, remitted[i], tt.wantRemitted[i])
+				}
+			}
+			if breakdown.TotalDuty != tt.wantTotal {
+				t.Errorf("total duty = %.2f, want %.2f", breakdown.TotalDuty, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestApplySellersRemissions(t *testing.T) {
+	req := &models.SalePurchaseSellersRequest{
+		SellerTransferor:     []models.SalePurchaseAdvancedParty{{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual}},
+		BuyerTransferee:      []models.SalePurchaseAdvancedParty{{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual}},
+		AssessmentRemissions: []models.AdvancedAssessmentRemission{{Sequence: 1, RemissionType: "SSD_DIVORCE"}},
+	}
+	breakdown := &models.EStampDutyBreakdown{
+		Components: []models.EStampDutyComponent{{DutyType: "SSD", Duty: 48000}},
+		TotalDuty:  48000,
+	}
+
+	service := NewRemissionService()
+	if err := service.ApplySellersRemissions(req, breakdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if breakdown.TotalDuty != 0 {
+		t.Errorf("total duty = %.2f, want 0", breakdown.TotalDuty)
+	}
+	if remitted := service.RemittedDuty(breakdown); remitted != 48000 {
+		t.Errorf("remitted duty = %.2f, want 48000", remitted)
+	}
+	if remitted := remittedDuty(breakdown, "SSD"); remitted != 48000 {
+		t.Errorf("remitted SSD = %.2f, want 48000", remitted)
+	}
+}
+
+func TestApplyShareTransferRemissionsReference(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		wantTotal float64
+		wantErr   bool
+	}{
+		{"with evidence of association", "ACR/2024/0012", 0, false},
+		{"without evidence of association", " ", 2000, true},
+	}
+
+	service := NewRemissionService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.ShareTransferRequest{
+				Transferor:           []models.PartyData{{Sequence: 1, TypeOfProfile: TypeOfProfileEntity}},
+				Transferee:           []models.PartyData{{Sequence: 1, TypeOfProfile: TypeOfProfileEntity}},
+				AssessmentRemissions: []models.AssessmentRemissionData{{Sequence: 1, RemissionType: "ST_ASSOCIATED", RemissionOptionText1: tt.reference}},
+			}
+			breakdown := &models.EStampDutyBreakdown{
+				Components: []models.EStampDutyComponent{{DutyType: "SHARE", Duty: 2000}},
+				TotalDuty:  2000,
+			}
+
+			err := service.ApplyShareTransferRemissions(req, breakdown)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if breakdown.TotalDuty != tt.wantTotal {
+				t.Errorf("total duty = %.2f, want %.2f", breakdown.TotalDuty, tt.wantTotal)
+			}
+			if !tt.wantErr && breakdown.Components[1].Remarks != "Transfer of shares between associated companies, ref ACR/2024/0012" {
+				t.Errorf("remarks = %q", breakdown.Components[1].Remarks)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"api-iras/internal/models"
+	"api-iras/pkg/utils"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -121,6 +122,12 @@ func (s *RentalService) SubmitRental(req *models.RentalSubmissionRequest) (*mode
 				Message:  "Property tax reference is required",
 				RecordID: fmt.Sprintf("%.0f", property.RecordID),
 			})
+		} else if !utils.IsValidPropertyTaxRef(property.PropertyTaxRef) {
+			fieldErrors = append(fieldErrors, models.RentalSubmissionFieldError{
+				Field:    "propertyTaxRef",
+				Message:  "Not a valid property tax reference",
+				RecordID: fmt.Sprintf("%.0f", property.RecordID),
+			})
 		}
 	}
 
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// qrPayloadPrefix identifies and versions stamp certificate QR payloads
+const qrPayloadPrefix = "SC1:"
+
+// Errors returned when a stamp certificate QR payload cannot be verified
+var (
+	ErrMalformedCertificatePayload = errors.New("stamp certificate payload is malformed")
+	ErrInvalidCertificateSignature = errors.New("stamp certificate signature is invalid")
+)
+
+// certificateParties is a group of parties listed on a stamp certificate
+type certificateParties struct {
+	Role    string
+	Heading string
+}
+
+// certificateLayout describes what a stamp certificate shows for a
+// document type
+type certificateLayout struct {
+	Title        string
+	Parties      []certificateParties
+	Properties   bool
+	Lands        bool
+	StocksShares bool
+	Security     bool
+}
+
+var certificateLayouts = map[string]certificateLayout{
+	DocumentTypeTenancy: {
+		Title:      "Stamp Certificate - Lease / Tenancy Agreement",
+		Parties:    []certificateParties{{PartyRoleLessor, "Landlord / Lessor"}, {PartyRoleLessee, "Tenant / Lessee"}},
+		Properties: true,
+		Lands:      true,
+	},
+	DocumentTypeShareTransfer: {
+		Title:        "Stamp Certificate - Transfer of Shares",
+		Parties:      []certificateParties{{PartyRoleTransferor, "Transferor"}, {PartyRoleTransferee, "Transferee"}},
+		StocksShares: true,
+	},
+	DocumentTypeMortgage: {
+		Title:        "Stamp Certificate - Mortgage",
+		Parties:      []certificateParties{{PartyRoleMortgagor, "Mortgagor"}, {PartyRoleMortgagee, "Mortgagee"}},
+		Properties:   true,
+		Lands:        true,
+		StocksShares: true,
+		Security:     true,
+	},
+	DocumentTypeBuyers: {
+		Title:      "Stamp Certificate - Sale and Purchase (Buyer's Stamp Duty)",
+		Parties:    []certificateParties{{PartyRoleBuyer, "Buyer / Transferee"}, {PartyRoleSeller, "Seller / Transferor"}},
+		Properties: true,
+		Lands:      true,
+	},
+	DocumentTypeSellers: {
+		Title:      "Stamp Certificate - Sale and Purchase (Seller's Stamp Duty)",
+		Parties:    []certificateParties{{PartyRoleSeller, "Seller / Transferor"}, {PartyRoleBuyer, "Buyer / Transferee"}},
+		Properties: true,
+		Lands:      true,
+	},
+}
+
+type StampCertificateService struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewStampCertificateService creates the certificate service. signingKey is
+// a base64-encoded 32-byte Ed25519 seed. Only when allowEphemeralKey is set,
+// as in development, may it be empty or invalid: an ephemeral key is then
+// generated and certificates issued before a restart will no longer verify.
+func NewStampCertificateService(signingKey string, allowEphemeralKey bool) (*StampCertificateService, error) {
+	if signingKey != "" {
+		seed, err := base64.StdEncoding.DecodeString(signingKey)
+		if err == nil && len(seed) == ed25519.SeedSize {
+			return &StampCertificateService{privateKey: ed25519.NewKeyFromSeed(seed)}, nil
+		}
+		if !allowEphemeralKey {
+			return nil, fmt.Errorf("invalid stamp certificate signing key, expected a base64-encoded %d-byte seed", ed25519.SeedSize)
+		}
+		log.Printf("Warning: ignoring invalid stamp certificate signing key, expected a base64-encoded %d-byte seed", ed25519.SeedSize)
+	} else if !allowEphemeralKey {
+		return nil, errors.New("no stamp certificate signing key configured")
+	}
+
+	log.Println("Warning: no stamp certificate signing key configured, using an ephemeral key")
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate stamp certificate signing key: %w", err)
+	}
+	return &StampCertificateService{privateKey: privateKey}, nil
+}
+
+// PublicKey returns the base64-encoded public key that verifies certificates.
+// It is published so certificates can be verified offline.
+func (s *StampCertificateService) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.privateKey.Public().(ed25519.PublicKey))
+}
+
+// Sign sets the record's QR payload: the certificate fields and their
+// Ed25519 signature, each base64url-encoded
+func (s *StampCertificateService) Sign(record *models.EStampRecord) error {
+	payload, err := json.Marshal(models.StampCertificatePayload{
+		DocRefNo:        record.DocRefNo,
+		StampCertRef:    record.StampCertRef,
+		DocumentType:    record.DocumentType,
+		DateOfDocument:  record.DateOfDocument,
+		IssueDate:       record.StampCertIssueDate.Format("2006-01-02"),
+		SDAmount:        record.SDAmount,
+		SDPenalty:       record.SDPenalty,
+		TotalAmtPayable: record.TotalAmtPayable,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize certificate payload: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(s.privateKey, []byte(encoded))
+	record.QRPayload = qrPayloadPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return nil
+}
+
+// VerifyPayload checks the signature on a QR payload and returns the
+// certificate fields it carries. It does not check revocation.
+func (s *StampCertificateService) VerifyPayload(qrPayload string) (*models.StampCertificatePayload, error) {
+	body := strings.TrimPrefix(strings.TrimSpace(qrPayload), qrPayloadPrefix)
+	if body == strings.TrimSpace(qrPayload) {
+		return nil, ErrMalformedCertificatePayload
+	}
+
+	encoded, encodedSignature, ok := strings.Cut(body, ".")
+	if !ok {
+		return nil, ErrMalformedCertificatePayload
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, ErrMalformedCertificatePayload
+	}
+
+	if !ed25519.Verify(s.privateKey.Public().(ed25519.PublicKey), []byte(encoded), signature) {
+		return nil, ErrInvalidCertificateSignature
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrMalformedCertificatePayload
+	}
+	var payload models.StampCertificatePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, ErrMalformedCertificatePayload
+	}
+	return &payload, nil
+}
+
+// GenerateCertificate renders the stamp certificate for a stamped document
+// as a PDF. The certificate is rebuilt from the stored record, so the same
+// record always produces the same certificate.
+func (s *StampCertificateService) GenerateCertificate(record *models.EStampRecord) ([]byte, error) {
+	layout, ok := certificateLayouts[record.DocumentType]
+	if !ok {
+		return nil, fmt.Errorf("no certificate layout for document type %q", record.DocumentType)
+	}
+
+	var breakdown models.EStampDutyBreakdown
+	if err := json.Unmarshal([]byte(record.DutyBreakdownData), &breakdown); err != nil {
+		return nil, fmt.Errorf("failed to read duty breakdown: %w", err)
+	}
+	var parties []models.EStampRecordParty
+	if err := json.Unmarshal([]byte(record.PartiesData), &parties); err != nil {
+		return nil, fmt.Errorf("failed to read parties: %w", err)
+	}
+	var assets models.AssetsData
+	if err := json.Unmarshal([]byte(record.AssetsData), &assets); err != nil {
+		return nil, fmt.Errorf("failed to read assets: %w", err)
+	}
+
+	issueDate := record.StampCertIssueDate.Format("2006-01-02")
+
+	doc := utils.NewPDFDocument()
+	doc.SetInfo("Title", layout.Title)
+	doc.SetInfo("DocRefNo", record.DocRefNo)
+	doc.SetInfo("StampCertRef", record.StampCertRef)
+	doc.SetInfo("DocumentType", record.DocumentType)
+	doc.SetInfo("IssueDate", issueDate)
+	doc.SetInfo("QRPayload", record.QRPayload)
+
+	doc.Title(layout.Title)
+	doc.Field("Document Reference No", record.DocRefNo)
+	doc.Field("Stamp Certificate Ref", record.StampCertRef)
+	doc.Field("Document Description", record.DocDescription)
+	doc.Field("Date of Document", record.DateOfDocument)
+	doc.Field("Issue Date", issueDate)
+
+	for _, group := range layout.Parties {
+		doc.Heading(group.Heading)
+		listed := false
+		for _, party := range parties {
+			if party.Role != group.Role {
+				continue
+			}
+			line := fmt.Sprintf("%d. %s", party.Sequence, party.Name)
+			if party.TaxEntityIDNo != "" {
+				line += " (" + party.TaxEntityIDNo + ")"
+			}
+			doc.Text(line)
+			listed = true
+		}
+		if !listed {
+			doc.Text("None")
+		}
+	}
+
+	if layout.Properties && len(assets.Properties) > 0 {
+		doc.Heading("Properties")
+		for _, property := range assets.Properties {
+			address := strings.TrimSpace(property.BlockNo + " " + property.StreetName)
+			for _, unit := range property.LevelUnits {
+				address += fmt.Sprintf(" #%s-%s", unit.FloorNo, unit.UnitNo)
+			}
+			doc.Text(fmt.Sprintf("%d. %s Singapore %s", property.Sequence, address, property.PostalCode))
+		}
+	}
+	if layout.Lands && len(assets.Lands) > 0 {
+		doc.Heading("Land")
+		for _, land := range assets.Lands {
+			doc.Text(fmt.Sprintf("%d. %s Lot %s, %s", land.Sequence, land.MKOrTSNo, land.FTLotNo, land.StreetName))
+		}
+	}
+	if layout.StocksShares && len(assets.StocksShares) > 0 {
+		doc.Heading("Stocks and Shares")
+		for _, stockShare := range assets.StocksShares {
+			doc.Text(fmt.Sprintf("%d. %s (%s): %d shares", stockShare.Sequence, stockShare.FTCompanyName, stockShare.TaxEntityID, stockShare.NoStockShares))
+		}
+	}
+	if layout.Security && len(assets.Security) > 0 {
+		doc.Heading("Security")
+		for _, security := range assets.Security {
+			doc.Text(fmt.Sprintf("%d. %s", security.Sequence, security.FTDescription))
+		}
+	}
+
+	doc.Heading("Duty Breakdown")
+	doc.Field("Rate Version", breakdown.RateVersion)
+	for _, component := range breakdown.Components {
+		label := component.DutyType
+		if component.Schedule != "" {
+			label += " (" + component.Schedule + ")"
+		}
+		if component.RemissionType != "" {
+			label += " " + component.RemissionType
+		}
+		doc.Text(fmt.Sprintf("%s on %.2f: %.2f", label, component.DutiableAmount, component.Duty))
+		for _, band := range component.Bands {
+			doc.Text(fmt.Sprintf("    %.2f at %.2f%%: %.2f", band.TaxableAmount, band.Rate*100, band.Duty))
+		}
+		if component.Remarks != "" {
+			doc.Text("    " + component.Remarks)
+		}
+	}
+
+	doc.Heading("Penalty")
+	if breakdown.Penalty != nil {
+		doc.Field("Stamping Deadline", breakdown.Penalty.StampingDeadline)
+		doc.Field("Days Late", fmt.Sprintf("%d", breakdown.Penalty.DaysLate))
+		if breakdown.Penalty.Tier != "" {
+			doc.Field("Penalty Tier", breakdown.Penalty.Tier)
+		}
+	}
+	doc.Field("Penalty Amount", fmt.Sprintf("%.2f", record.SDPenalty))
+
+	doc.Heading("Amount Payable")
+	doc.Field("Stamp Duty", fmt.Sprintf("%.2f", record.SDAmount))
+	if record.SDRemission > 0 {
+		doc.Field("Remission", fmt.Sprintf("%.2f", record.SDRemission))
+	}
+	doc.Field("Penalty", fmt.Sprintf("%.2f", record.SDPenalty))
+	doc.Field("Total Amount Payable", fmt.Sprintf("%.2f", record.TotalAmtPayable))
+	doc.Field("Payment Due Date", record.PaymentDueDate)
+
+	doc.Heading("Verification")
+	if record.RevokedAt != nil {
+		doc.Field("Status", "REVOKED on "+record.RevokedAt.Format("2006-01-02"))
+	}
+	doc.Text("Submit the payload below to VerifyStampCertificate, or check its Ed25519")
+	doc.Text("signature against the key published at StampCertificateKey:")
+	doc.Text(record.QRPayload)
+
+	return doc.Bytes(), nil
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSigningKey is a fixed base64-encoded Ed25519 seed
+var testSigningKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+func TestNewStampCertificateService(t *testing.T) {
+	tests := []struct {
+		name              string
+		signingKey        string
+		allowEphemeralKey bool
+		wantErr           bool
+	}{
+		{"configured key", testSigningKey, false, false},
+		{"configured key in development", testSigningKey, true, false},
+		{"missing key", "", false, true},
+		{"missing key in development", "", true, false},
+		{"invalid key", "not-a-key", false, true},
+		{"short key", base64.StdEncoding.EncodeToString([]byte("short")), false, true},
+		{"invalid key in development", "not-a-key", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := NewStampCertificateService(tt.signingKey, tt.allowEphemeralKey)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service.PublicKey() == "" {
+				t.Error("expected a public key")
+			}
+		})
+	}
+
+	// A configured key always yields the same public key
+	first, _ := NewStampCertificateService(testSigningKey, false)
+	second, _ := NewStampCertificateService(testSigningKey, false)
+	if first.PublicKey() != second.PublicKey() {
+		t.Error("the same signing key produced different public keys")
+	}
+}
+
+func TestStampCertificateRoundTrip(t *testing.T) {
+	service, err := NewStampCertificateService(testSigningKey, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record := testStampRecord(t)
+	if err := service.Sign(record); err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+
+	pdf, err := service.GenerateCertificate(record)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %v", err)
+	}
+
+	// The certificate fields are carried in the information dictionary
+	for key, want := range map[string]string{
+		"DocRefNo":     record.DocRefNo,
+		"StampCertRef": record.StampCertRef,
+		"DocumentType": DocumentTypeBuyers,
+		"IssueDate":    "2025-03-14",
+		"QRPayload":    record.QRPayload,
+	} {
+		if got := pdfInfo(t, pdf, key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	// and printed on the certificate
+	for _, want := range []string{
+		"(Document Reference No: SP123456789012) Tj",
+		"(Stamp Certificate Ref: SCA1B2C3D4E5F6) Tj",
+		"(1. TAN AH KOW \\(S1234567D\\)) Tj",
+		"(Stamp Duty: 24600.00) Tj",
+		"(Penalty: 10.00) Tj",
+		"(Total Amount Payable: 24610.00) Tj",
+		"(signature against the key published at StampCertificateKey:) Tj",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("certificate does not contain %q", want)
+		}
+	}
+
+	// The QR payload read back from the certificate verifies against the
+	// published public key
+	qrPayload := pdfInfo(t, pdf, "QRPayload")
+	encoded, encodedSignature, ok := strings.Cut(strings.TrimPrefix(qrPayload, qrPayloadPrefix), ".")
+	if !ok {
+		t.Fatalf("malformed QR payload %q", qrPayload)
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(service.PublicKey())
+	if err != nil {
+		t.Fatalf("invalid public key: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		t.Fatalf("invalid signature encoding: %v", err)
+	}
+	if !ed25519.Verify(publicKey, []byte(encoded), signature) {
+		t.Fatal("Ed25519 signature does not verify")
+	}
+
+	payload, err := service.VerifyPayload(qrPayload)
+	if err != nil {
+		t.Fatalf("failed to verify payload: %v", err)
+	}
+	want := models.StampCertificatePayload{
+		DocRefNo:        "SP123456789012",
+		StampCertRef:    "SCA1B2C3D4E5F6",
+		DocumentType:    DocumentTypeBuyers,
+		DateOfDocument:  "2025-03-01",
+		IssueDate:       "2025-03-14",
+		SDAmount:        24600,
+		SDPenalty:       10,
+		TotalAmtPayable: 24610,
+	}
+	if *payload != want {
+		t.Errorf("payload = %+v, want %+v", *payload, want)
+	}
+}
+
+func TestVerifyPayloadRejectsTampering(t *testing.T) {
+	service, _ := NewStampCertificateService(testSigningKey, false)
+	record := testStampRecord(t)
+	if err := service.Sign(record); err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+
+	encoded, signature, _ := strings.Cut(strings.TrimPrefix(record.QRPayload, qrPayloadPrefix), ".")
+	raw, _ := base64.RawURLEncoding.DecodeString(encoded)
+	tampered := bytes.Replace(raw, []byte(`"sdAmount":24600`), []byte(`"sdAmount":246`), 1)
+	otherKey, _ := NewStampCertificateService(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{9}, ed25519.SeedSize)), false)
+
+	tests := []struct {
+		name      string
+		service   *StampCertificateService
+		qrPayload string
+		wantErr   error
+	}{
+		{"altered duty", service, qrPayloadPrefix + base64.RawURLEncoding.EncodeToString(tampered) + "." + signature, ErrInvalidCertificateSignature},
+		{"other signing key", otherKey, record.QRPayload, ErrInvalidCertificateSignature},
+		{"missing prefix", service, strings.TrimPrefix(record.QRPayload, qrPayloadPrefix), ErrMalformedCertificatePayload},
+		{"missing signature", service, qrPayloadPrefix + encoded, ErrMalformedCertificatePayload},
+		{"truncated signature", service, record.QRPayload[:len(record.QRPayload)-4], ErrMalformedCertificatePayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.service.VerifyPayload(tt.qrPayload); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyPayload() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testStampRecord builds a stamped buyer's document as RecordStamping
+// stores it
+func testStampRecord(t *testing.T) *models.EStampRecord {
+	t.Helper()
+
+	breakdown := models.EStampDutyBreakdown{
+		RateVersion:    "BSD_2023_02_15",
+		DocumentDate:   "2025-03-01",
+		DutiableAmount: 800000,
+		Components: []models.EStampDutyComponent{
+			{DutyType: "BSD", DutiableAmount: 800000, Duty: 24600},
+		},
+		TotalDuty: 24600,
+		Penalty:   &models.EStampPenalty{StampingDeadline: "2025-03-15", DaysLate: 0, Amount: 10},
+	}
+	parties := []models.EStampRecordParty{
+		{Role: PartyRoleBuyer, Sequence: 1, TaxEntityIDNo: "S1234567D", Name: "TAN AH KOW"},
+	}
+
+	return &models.EStampRecord{
+		DocRefNo:           "SP123456789012",
+		DocRefNumber:       123456789012,
+		StampCertRef:       "SCA1B2C3D4E5F6",
+		DocumentType:       DocumentTypeBuyers,
+		DateOfDocument:     "2025-03-01",
+		SDAmount:           24600,
+		SDPenalty:          10,
+		TotalAmtPayable:    24610,
+		PaymentDueDate:     "2025-03-15",
+		StampCertIssueDate: time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC),
+		DutyBreakdownData:  mustJSON(t, breakdown),
+		PartiesData:        mustJSON(t, parties),
+		AssetsData:         mustJSON(t, models.AssetsData{}),
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to serialize %T: %v", v, err)
+	}
+	return string(data)
+}
+
+// pdfInfo reads an entry of a generated PDF's information dictionary
+func pdfInfo(t *testing.T, pdf []byte, key string) string {
+	t.Helper()
+	match := regexp.MustCompile(`/` + key + ` \(((?:\\.|[^\\)])*)\)`).FindSubmatch(pdf)
+	if match == nil {
+		t.Fatalf("PDF has no %s information entry", key)
+	}
+	return string(match[1])
+}
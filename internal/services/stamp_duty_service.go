@@ -0,0 +1,1731 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"api-iras/pkg/utils"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property types used in eStamp asset data
+const (
+	PropertyTypeResidential    = 1
+	PropertyTypeNonResidential = 2
+	PropertyTypeIndustrial     = 3
+)
+
+// Party profile and identity types used in eStamp party data
+const (
+	TypeOfProfileIndividual = 1
+	TypeOfProfileEntity     = 2
+
+	TaxEntityIDTypeNRIC            = 1
+	TaxEntityIDTypeFIN             = 2
+	TaxEntityIDTypeUENBusiness     = 3
+	TaxEntityIDTypeUENLocalCompany = 4
+	TaxEntityIDTypeUENOthers       = 5
+)
+
+// ABSD profiles
+const (
+	ABSDProfileCitizen   = "SC"
+	ABSDProfilePR        = "SPR"
+	ABSDProfileForeigner = "FOREIGNER"
+	ABSDProfileEntity    = "ENTITY"
+	ABSDProfileTrust     = "TRUST"
+)
+
+// Mortgage types
+const (
+	MortgageTypeLegal      = 1
+	MortgageTypeEquitable  = 2
+	MortgageTypeStockShare = 3
+)
+
+// Share of a property or land transferred, and how its co-owners hold it
+const (
+	ShareTransferredWhole = 1
+	ShareTransferredPart  = 2
+
+	MannerOfHoldingJointTenancy    = 1
+	MannerOfHoldingTenancyInCommon = 2
+)
+
+// Master Plan zoning of land, used to pick the duty schedule for land
+// without a residential and non-residential price split
+const (
+	MasterPlanZoningResidential                      = 1
+	MasterPlanZoningCommercial                       = 2
+	MasterPlanZoningCommercialAndResidential         = 3
+	MasterPlanZoningResidentialWithCommercialAtFirst = 4
+	MasterPlanZoningBusiness                         = 5
+	MasterPlanZoningOthers                           = 6
+)
+
+// Asset types reported on per-asset duty components
+const (
+	AssetTypeProperty   = "property"
+	AssetTypeLand       = "land"
+	AssetTypeStockShare = "stockShare"
+)
+
+// Duty schedules
+const (
+	ScheduleResidential    = "residential"
+	ScheduleNonResidential = "non-residential"
+	ScheduleIndustrial     = "industrial"
+)
+
+// eStamp document types
+const (
+	DocumentTypeTenancy       = "TENANCY"
+	DocumentTypeShareTransfer = "SHARE_TRANSFER"
+	DocumentTypeMortgage      = "MORTGAGE"
+	DocumentTypeBuyers        = "SALE_PURCHASE_BUYERS"
+	DocumentTypeSellers       = "SALE_PURCHASE_SELLERS"
+)
+
+// SSDRuleVersionNone is reported when a property was acquired before any
+// Seller's Stamp Duty regime applied to it
+const SSDRuleVersionNone = "NONE"
+
+// DutyFieldErrors reports input problems that prevent a duty calculation
+type DutyFieldErrors []models.EStampFieldError
+
+func (e DutyFieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldError := range e {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// dutyBand is one marginal band of a duty schedule. A zero Width means the
+// band covers the remainder of the dutiable amount.
+type dutyBand struct {
+	Width float64
+	Rate  float64
+}
+
+// bsdRateTable is a Buyer's Stamp Duty schedule in force from EffectiveFrom
+type bsdRateTable struct {
+	Version        string
+	EffectiveFrom  time.Time
+	Residential    []dutyBand
+	NonResidential []dutyBand
+}
+
+// bsdRateTables must be kept in ascending order of EffectiveFrom
+var bsdRateTables = []bsdRateTable{
+	{
+		Version:       "BSD-1998",
+		EffectiveFrom: time.Date(1998, time.February, 27, 0, 0, 0, 0, time.UTC),
+		Residential: []dutyBand{
+			{Width: 180000, Rate: 0.01},
+			{Width: 180000, Rate: 0.02},
+			{Rate: 0.03},
+		},
+		NonResidential: []dutyBand{
+			{Width: 180000, Rate: 0.01},
+			{Width: 180000, Rate: 0.02},
+			{Rate: 0.03},
+		},
+	},
+	{
+		Version:       "BSD-2018",
+		EffectiveFrom: time.Date(2018, time.February, 20, 0, 0, 0, 0, time.UTC),
+		Residential: []dutyBand{
+			{Width: 180000, Rate: 0.01},
+			{Width: 180000, Rate: 0.02},
+			{Width: 640000, Rate: 0.03},
+			{Rate: 0.04},
+		},
+		NonResidential: []dutyBand{
+			{Width: 180000, Rate: 0.01},
+			{Width: 180000, Rate: 0.02},
+			{Rate: 0.03},
+		},
+	},
+	{
+		Version:       "BSD-2023",
+		EffectiveFrom: time.Date(2023, time.February, 15, 0, 0, 0, 0, time.UTC),
+		Residential: []dutyBand{
+			{Width: 180000, Rate: 0.01},
+			{Width: 180000, Rate: 0.02},
+			{Width: 640000, Rate: 0.03},
+			{Width: 500000, Rate: 0.04},
+			{Width: 1500000, Rate: 0.05},
+			{Rate: 0.06},
+		},
+		NonResidential: []dutyBand{
+			{Width: 180000, Rate: 0.01},
+			{Width: 180000, Rate: 0.02},
+			{Width: 640000, Rate: 0.03},
+			{Width: 500000, Rate: 0.04},
+			{Rate: 0.05},
+		},
+	},
+}
+
+// ssdBand charges Rate when the holding period does not exceed MaxYears
+type ssdBand struct {
+	MaxYears int
+	Rate     float64
+}
+
+// ssdRuleTable is a Seller's Stamp Duty regime that applies to properties
+// acquired on or after AcquiredFrom
+type ssdRuleTable struct {
+	Version      string
+	AcquiredFrom time.Time
+	Bands        []ssdBand
+}
+
+// residentialSSDRuleTables must be kept in ascending order of AcquiredFrom
+var residentialSSDRuleTables = []ssdRuleTable{
+	{
+		Version:      "SSD-2011",
+		AcquiredFrom: time.Date(2011, time.January, 14, 0, 0, 0, 0, time.UTC),
+		Bands:        []ssdBand{{1, 0.16}, {2, 0.12}, {3, 0.08}, {4, 0.04}},
+	},
+	{
+		Version:      "SSD-2017",
+		AcquiredFrom: time.Date(2017, time.March, 11, 0, 0, 0, 0, time.UTC),
+		Bands:        []ssdBand{{1, 0.12}, {2, 0.08}, {3, 0.04}},
+	},
+	{
+		Version:      "SSD-2025",
+		AcquiredFrom: time.Date(2025, time.July, 4, 0, 0, 0, 0, time.UTC),
+		Bands:        []ssdBand{{1, 0.16}, {2, 0.12}, {3, 0.08}, {4, 0.04}},
+	},
+}
+
+// industrialSSDRuleTables must be kept in ascending order of AcquiredFrom.
+// Industrial properties acquired before 12 Jan 2013 are not subject to SSD.
+var industrialSSDRuleTables = []ssdRuleTable{
+	{
+		Version:      "ISSD-2013",
+		AcquiredFrom: time.Date(2013, time.January, 12, 0, 0, 0, 0, time.UTC),
+		Bands:        []ssdBand{{1, 0.15}, {2, 0.10}, {3, 0.05}},
+	},
+}
+
+// SSDResult is the outcome of a Seller's Stamp Duty calculation
+type SSDResult struct {
+	HoldingPeriod models.SSDHoldingPeriod
+	RuleVersion   string
+	Rate          float64
+	Duty          float64
+}
+
+// leaseDutyRule is the lease duty regime for documents dated on or after
+// EffectiveFrom. Leases longer than MaxTermYears are charged on MaxTermYears
+// times the average annual rent; leases with an average annual rent of
+// ExemptAAR or less are exempt.
+type leaseDutyRule struct {
+	Version       string
+	EffectiveFrom time.Time
+	Rate          float64
+	MaxTermYears  float64
+	ExemptAAR     float64
+}
+
+// leaseDutyRules must be kept in ascending order of EffectiveFrom
+var leaseDutyRules = []leaseDutyRule{
+	{
+		Version:       "LEASE-2014",
+		EffectiveFrom: time.Date(2014, time.February, 22, 0, 0, 0, 0, time.UTC),
+		Rate:          0.004,
+		MaxTermYears:  4,
+		ExemptAAR:     1000,
+	},
+}
+
+// Share transfer duty rate
+const (
+	shareTransferRate        = 0.002
+	shareTransferRateVersion = "SHARE-2014"
+)
+
+// Additional Conveyance Duties apply to transfers of equity interests in
+// property-holding entities: entities whose Singapore residential property
+// makes up at least acdPropertyHoldingThreshold of their total tangible
+// assets, where the transferor or transferee is a significant owner holding
+// at least acdSignificantOwnerThreshold of the entity. The seller's duty is
+// charged at acdSellerRate on interests disposed of within acdSellerYears of
+// acquisition; the buyer's duty at BSD rates plus the entity ABSD rate.
+const (
+	acdRateVersion               = "ACD-2017"
+	acdPropertyHoldingThreshold  = 0.5
+	acdSignificantOwnerThreshold = 0.5
+	acdSellerRate                = 0.12
+	acdSellerYears               = 3
+)
+
+var acdEffectiveFrom = time.Date(2017, time.March, 11, 0, 0, 0, 0, time.UTC)
+
+// mortgageRule is the duty on a mortgage: Rate of the loan amount, subject
+// to MinimumDuty and capped at MaximumDuty per document
+type mortgageRule struct {
+	Rate        float64
+	MinimumDuty float64
+	MaximumDuty float64
+}
+
+const mortgageRateVersion = "MORTGAGE-2014"
+
+// mortgageDuty applies to every type of mortgage. The Stamp Duties Act
+// charges legal and equitable mortgages, and charges over stocks and
+// shares, under the same article of the First Schedule, so the type only
+// decides which assets secure the loan.
+var mortgageDuty = mortgageRule{
+	Rate:        0.004,
+	MinimumDuty: 5,
+	MaximumDuty: 500,
+}
+
+// mortgageTypeDescriptions names each TypeOfMortgage
+var mortgageTypeDescriptions = map[int]string{
+	MortgageTypeLegal:      "Legal mortgage",
+	MortgageTypeEquitable:  "Equitable mortgage",
+	MortgageTypeStockShare: "Mortgage of stocks and shares",
+}
+
+// Documents signed in Singapore must be stamped within
+// stampingWindowInSingaporeDays of signing; documents signed overseas within
+// stampingWindowOverseasDays of being received in Singapore. Duty is payable
+// by the stamping deadline, and at least paymentGraceDays after submission.
+const (
+	stampingWindowInSingaporeDays = 14
+	stampingWindowOverseasDays    = 30
+	paymentGraceDays              = 14
+)
+
+// latePenaltyTier is the penalty for documents stamped up to MaxMonthsLate
+// months after the stamping deadline: the greater of Minimum and Multiple
+// times the duty. MaxMonthsLate 0 covers any later stamping.
+type latePenaltyTier struct {
+	Tier          string
+	MaxMonthsLate int
+	Minimum       float64
+	Multiple      float64
+}
+
+// latePenaltyTiers must be kept in ascending order of MaxMonthsLate, with
+// the open-ended tier last
+var latePenaltyTiers = []latePenaltyTier{
+	{Tier: "UP_TO_3_MONTHS", MaxMonthsLate: 3, Minimum: 10, Multiple: 1},
+	{Tier: "OVER_3_MONTHS", Minimum: 20, Multiple: 4},
+}
+
+// absdRateTable maps each ABSD profile to its rates for the first, second
+// and subsequent residential properties. The last rate applies to every
+// further property.
+type absdRateTable struct {
+	Version       string
+	EffectiveFrom time.Time
+	Rates         map[string][]float64
+}
+
+// absdRateTables must be kept in ascending order of EffectiveFrom. Documents
+// dated before the first table do not attract ABSD.
+var absdRateTables = []absdRateTable{
+	{
+		Version:       "ABSD-2013",
+		EffectiveFrom: time.Date(2013, time.January, 12, 0, 0, 0, 0, time.UTC),
+		Rates: map[string][]float64{
+			ABSDProfileCitizen:   {0, 0.07, 0.10},
+			ABSDProfilePR:        {0.05, 0.10},
+			ABSDProfileForeigner: {0.15},
+			ABSDProfileEntity:    {0.15},
+		},
+	},
+	{
+		Version:       "ABSD-2018",
+		EffectiveFrom: time.Date(2018, time.July, 6, 0, 0, 0, 0, time.UTC),
+		Rates: map[string][]float64{
+			ABSDProfileCitizen:   {0, 0.12, 0.15},
+			ABSDProfilePR:        {0.05, 0.15},
+			ABSDProfileForeigner: {0.20},
+			ABSDProfileEntity:    {0.25},
+		},
+	},
+	{
+		Version:       "ABSD-2021",
+		EffectiveFrom: time.Date(2021, time.December, 16, 0, 0, 0, 0, time.UTC),
+		Rates: map[string][]float64{
+			ABSDProfileCitizen:   {0, 0.17, 0.25},
+			ABSDProfilePR:        {0.05, 0.25, 0.30},
+			ABSDProfileForeigner: {0.30},
+			ABSDProfileEntity:    {0.35},
+		},
+	},
+	{
+		Version:       "ABSD-2022",
+		EffectiveFrom: time.Date(2022, time.May, 9, 0, 0, 0, 0, time.UTC),
+		Rates: map[string][]float64{
+			ABSDProfileCitizen:   {0, 0.17, 0.25},
+			ABSDProfilePR:        {0.05, 0.25, 0.30},
+			ABSDProfileForeigner: {0.30},
+			ABSDProfileEntity:    {0.35},
+			ABSDProfileTrust:     {0.35},
+		},
+	},
+	{
+		Version:       "ABSD-2023",
+		EffectiveFrom: time.Date(2023, time.April, 27, 0, 0, 0, 0, time.UTC),
+		Rates: map[string][]float64{
+			ABSDProfileCitizen:   {0, 0.20, 0.30},
+			ABSDProfilePR:        {0.05, 0.30, 0.35},
+			ABSDProfileForeigner: {0.60},
+			ABSDProfileEntity:    {0.65},
+			ABSDProfileTrust:     {0.65},
+		},
+	},
+}
+
+// Nationals of these countries are accorded citizen ABSD treatment under
+// free trade agreements
+var absdFTACountries = map[string]bool{
+	"US": true, "USA": true, "UNITED STATES": true,
+	"IS": true, "ICELAND": true,
+	"LI": true, "LIECHTENSTEIN": true,
+	"NO": true, "NORWAY": true,
+	"CH": true, "SWITZERLAND": true,
+}
+
+type StampDutyService struct {
+	absdRateOverrides map[string][]float64
+}
+
+// NewStampDutyService creates the duty engine. absdRates optionally overrides
+// the current ABSD rates per profile, e.g. "SC=0,0.20,0.30;FOREIGNER=0.60".
+func NewStampDutyService(absdRates string) *StampDutyService {
+	return &StampDutyService{absdRateOverrides: parseABSDRates(absdRates)}
+}
+
+// parseABSDRates parses ABSD rate overrides, skipping malformed entries
+func parseABSDRates(value string) map[string][]float64 {
+	overrides := make(map[string][]float64)
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			log.Printf("Warning: ignoring malformed ABSD rate entry %q", entry)
+			continue
+		}
+
+		var rates []float64
+		for _, field := range strings.Split(parts[1], ",") {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil || rate < 0 {
+				rates = nil
+				break
+			}
+			rates = append(rates, rate)
+		}
+		if len(rates) == 0 {
+			log.Printf("Warning: ignoring malformed ABSD rate entry %q", entry)
+			continue
+		}
+
+		overrides[strings.ToUpper(strings.TrimSpace(parts[0]))] = rates
+	}
+
+	return overrides
+}
+
+// ParseDocumentDate parses an eStamp document date (YYYY-MM-DD). An empty
+// value falls back to today's date.
+func (s *StampDutyService) ParseDocumentDate(value string) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		now := time.Now().UTC()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date must be in YYYY-MM-DD format: %w", err)
+	}
+	return date, nil
+}
+
+// parseDateField parses a date for a duty calculation, reporting failures
+// against the given field path
+func (s *StampDutyService) parseDateField(field, value string) (time.Time, error) {
+	date, err := s.ParseDocumentDate(value)
+	if err != nil {
+		return time.Time{}, DutyFieldErrors{{Field: field, Message: "Date must be in YYYY-MM-DD format"}}
+	}
+	return date, nil
+}
+
+// CalculateBuyersDuty computes Buyer's Stamp Duty for a sale and purchase
+// document from the per-property and per-land values
+func (s *StampDutyService) CalculateBuyersDuty(req *models.SalePurchaseBuyersRequest) (*models.EStampDutyBreakdown, error) {
+	documentDate, err := s.parseDateField("dateOfDocument", req.DateOfDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	values, fieldErrors := s.buyingValuesBySchedule(req.Assets)
+	fieldErrors = append(fieldErrors, s.validateTrust(req)...)
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	// Mixed-use assets contribute to both schedules; list their parts so the
+	// apportionment can be followed from the response
+	var residential, nonResidential float64
+	var residentialParts, nonResidentialParts []string
+	for _, value := range values {
+		residential += value.Residential
+		nonResidential += value.NonResidential
+		if value.Residential > 0 && value.NonResidential > 0 {
+			residentialParts = append(residentialParts, fmt.Sprintf("%s %d: %.2f", value.AssetType, value.Sequence, value.Residential))
+			nonResidentialParts = append(nonResidentialParts, fmt.Sprintf("%s %d: %.2f", value.AssetType, value.Sequence, value.NonResidential))
+		}
+	}
+
+	// Fall back to the headline price when no asset values are provided
+	if residential == 0 && nonResidential == 0 {
+		residential = math.Max(req.PurchasePrice, req.ConsiderationAmount)
+	}
+
+	table := s.bsdRateTableFor(documentDate)
+	breakdown := &models.EStampDutyBreakdown{
+		RateVersion:    table.Version,
+		DocumentDate:   documentDate.Format("2006-01-02"),
+		DutiableAmount: residential + nonResidential,
+	}
+
+	if residential > 0 {
+		component := s.bandedComponent("BSD", ScheduleResidential, residential, table.Residential)
+		if len(residentialParts) > 0 {
+			component.Remarks = "Includes residential part of mixed-use " + strings.Join(residentialParts, ", ")
+		}
+		breakdown.Components = append(breakdown.Components, component)
+	}
+	if nonResidential > 0 {
+		component := s.bandedComponent("BSD", ScheduleNonResidential, nonResidential, table.NonResidential)
+		if len(nonResidentialParts) > 0 {
+			component.Remarks = "Includes non-residential part of mixed-use " + strings.Join(nonResidentialParts, ", ")
+		}
+		breakdown.Components = append(breakdown.Components, component)
+	}
+
+	if residential > 0 {
+		if !documentDate.Before(absdRateTables[0].EffectiveFrom) {
+			if fieldErrors := validateABSDNationality(req); len(fieldErrors) > 0 {
+				return nil, fieldErrors
+			}
+		}
+		if absd, ok := s.absdComponent(documentDate, residential, req); ok {
+			breakdown.Components = append(breakdown.Components, absd)
+			breakdown.RateVersion += "/" + s.absdRateTableFor(documentDate).Version
+		}
+	}
+
+	for _, component := range breakdown.Components {
+		breakdown.TotalDuty += component.Duty
+	}
+
+	return breakdown, nil
+}
+
+// CalculateSellersDuty computes Seller's Stamp Duty for each property and
+// land disposed of, using its acquisition date and the document date
+func (s *StampDutyService) CalculateSellersDuty(req *models.SalePurchaseSellersRequest) (*models.EStampDutyBreakdown, error) {
+	documentDate, err := s.parseDateField("dateOfDocument", req.DateOfDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &models.EStampDutyBreakdown{
+		DocumentDate: documentDate.Format("2006-01-02"),
+	}
+	versions := make(map[string]bool)
+	var fieldErrors DutyFieldErrors
+
+	addDisposal := func(field string, sequence int, schedule string, value float64, acquisition string) {
+		if value == 0 {
+			return
+		}
+		breakdown.DutiableAmount += value
+
+		component := models.EStampDutyComponent{
+			DutyType:       "SSD",
+			Schedule:       schedule,
+			DutiableAmount: value,
+			AssetSequence:  sequence,
+		}
+		switch {
+		case strings.HasPrefix(field, "assets.properties"):
+			component.AssetType = AssetTypeProperty
+		case strings.HasPrefix(field, "assets.lands"):
+			component.AssetType = AssetTypeLand
+		}
+
+		if schedule == ScheduleNonResidential {
+			component.Remarks = "Non-residential, non-industrial property is not subject to SSD"
+			breakdown.Components = append(breakdown.Components, component)
+			return
+		}
+
+		acquisitionField := "dateOfAcquisition"
+		if field != "" {
+			acquisitionField = field + ".dateOfAcquisition"
+		}
+		if strings.TrimSpace(acquisition) == "" {
+			acquisition = req.DateOfAcquisition
+		}
+		if strings.TrimSpace(acquisition) == "" {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: acquisitionField, Message: "Acquisition date is required"})
+			return
+		}
+		acquisitionDate, err := time.Parse("2006-01-02", strings.TrimSpace(acquisition))
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: acquisitionField, Message: "Date must be in YYYY-MM-DD format"})
+			return
+		}
+		if acquisitionDate.After(documentDate) {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: acquisitionField, Message: "Acquisition date must not be after the document date"})
+			return
+		}
+
+		tables := residentialSSDRuleTables
+		if schedule == ScheduleIndustrial {
+			tables = industrialSSDRuleTables
+		}
+		result := s.calculateSSD(tables, value, acquisitionDate, documentDate)
+
+		component.Rate = result.Rate
+		component.Duty = result.Duty
+		component.Remarks = fmt.Sprintf("Held %d years %d months %d days under %s", result.HoldingPeriod.Years, result.HoldingPeriod.Months, result.HoldingPeriod.Days, result.RuleVersion)
+		breakdown.Components = append(breakdown.Components, component)
+		versions[result.RuleVersion] = true
+	}
+
+	for i, property := range req.Assets.Properties {
+		field := fmt.Sprintf("assets.properties[%d]", i)
+		fraction, errs := s.propertyFraction(field, property)
+		if len(errs) > 0 {
+			fieldErrors = append(fieldErrors, errs...)
+			continue
+		}
+
+		// A mixed-use property is disposed of in two parts: the residential
+		// part under residential SSD and the rest under the schedule for its
+		// property type
+		nonResidentialSchedule := ScheduleNonResidential
+		if property.PropertyType == PropertyTypeIndustrial {
+			nonResidentialSchedule = ScheduleIndustrial
+		}
+		isResidential := property.PropertyType != PropertyTypeNonResidential && property.PropertyType != PropertyTypeIndustrial
+		residential, nonResidential := apportionValue(property.SellingPriceMarketValueResidential, property.SellingPriceMarketValueNonResidential, property.ValuationValue, isResidential)
+
+		addDisposal(field, property.Sequence, ScheduleResidential, roundCents(fraction*residential), property.DateOfAcquisition)
+		addDisposal(field, property.Sequence, nonResidentialSchedule, roundCents(fraction*nonResidential), property.DateOfAcquisition)
+	}
+
+	for i, land := range req.Assets.Lands {
+		field := fmt.Sprintf("assets.lands[%d]", i)
+		fraction, errs := s.landFraction(field, land)
+		if len(errs) > 0 {
+			fieldErrors = append(fieldErrors, errs...)
+			continue
+		}
+
+		schedule := landSchedule(land.MasterPlanZoning)
+		nonResidentialSchedule := ScheduleNonResidential
+		if schedule == ScheduleIndustrial {
+			nonResidentialSchedule = ScheduleIndustrial
+		}
+		residential, nonResidential := apportionValue(land.SellingPriceMarketValueResidential, land.SellingPriceMarketValueNonResidential, land.ValuationValue, schedule == ScheduleResidential)
+
+		addDisposal(field, land.Sequence, ScheduleResidential, roundCents(fraction*residential), land.DateOfAcquisition)
+		addDisposal(field, land.Sequence, nonResidentialSchedule, roundCents(fraction*nonResidential), land.DateOfAcquisition)
+	}
+
+	// Fall back to the headline price when no asset values are provided
+	if breakdown.DutiableAmount == 0 && len(fieldErrors) == 0 {
+		headline := math.Max(req.SellingPrice, math.Max(req.ConsiderationAmount, req.PurchasePrice))
+		addDisposal("", 0, ScheduleResidential, headline, req.DateOfAcquisition)
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	for _, component := range breakdown.Components {
+		breakdown.TotalDuty += component.Duty
+	}
+	breakdown.RateVersion = joinVersions(versions)
+
+	return breakdown, nil
+}
+
+// CalculateTenancyDuty computes lease duty on each rental assessment from
+// its lease term and average annual rent, plus BSD on any premium
+func (s *StampDutyService) CalculateTenancyDuty(req *models.StampTenancyAgreementRequest) (*models.EStampDutyBreakdown, error) {
+	documentDate, err := s.parseDateField("dateOfDocument", req.DateOfDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := leaseDutyRules[0]
+	for _, candidate := range leaseDutyRules {
+		if !documentDate.Before(candidate.EffectiveFrom) {
+			rule = candidate
+		}
+	}
+	bsdTable := s.bsdRateTableFor(documentDate)
+
+	breakdown := &models.EStampDutyBreakdown{
+		RateVersion:  rule.Version,
+		DocumentDate: documentDate.Format("2006-01-02"),
+	}
+	var fieldErrors DutyFieldErrors
+
+	for i, rental := range req.AssessmentRental {
+		field := fmt.Sprintf("assessmentRental[%d]", i)
+
+		totalRent, termYears, errs := s.leaseRent(field, rental)
+		if len(errs) > 0 {
+			fieldErrors = append(fieldErrors, errs...)
+			continue
+		}
+
+		var averageAnnualRent float64
+		if termYears > 0 {
+			averageAnnualRent = totalRent / termYears
+		}
+
+		dutiable := totalRent
+		if termYears > rule.MaxTermYears {
+			dutiable = rule.MaxTermYears * averageAnnualRent
+		}
+
+		component := models.EStampDutyComponent{
+			DutyType:       "LEASE",
+			DutiableAmount: roundCents(dutiable),
+			Rate:           rule.Rate,
+			Remarks:        fmt.Sprintf("Lease term %.2f years, average annual rent %.2f", termYears, averageAnnualRent),
+		}
+		if averageAnnualRent <= rule.ExemptAAR {
+			component.Remarks += "; exempt as average annual rent does not exceed " + fmt.Sprintf("%.2f", rule.ExemptAAR)
+		} else {
+			component.Duty = math.Floor(roundCents(dutiable * rule.Rate))
+		}
+		breakdown.DutiableAmount += component.DutiableAmount
+		breakdown.Components = append(breakdown.Components, component)
+
+		// A premium is charged at conveyance (BSD) rates
+		if rental.IsPremiumConsiderationMade && rental.PremiumConsiderationAmount > 0 {
+			residential, nonResidential := s.premiumBySchedule(rental, req.Assets)
+			if residential > 0 {
+				premium := s.bandedComponent("BSD", ScheduleResidential, residential, bsdTable.Residential)
+				premium.Remarks = "Premium consideration"
+				breakdown.Components = append(breakdown.Components, premium)
+			}
+			if nonResidential > 0 {
+				premium := s.bandedComponent("BSD", ScheduleNonResidential, nonResidential, bsdTable.NonResidential)
+				premium.Remarks = "Premium consideration"
+				breakdown.Components = append(breakdown.Components, premium)
+			}
+			breakdown.DutiableAmount += rental.PremiumConsiderationAmount
+			breakdown.RateVersion = rule.Version + "/" + bsdTable.Version
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	for _, component := range breakdown.Components {
+		breakdown.TotalDuty += component.Duty
+	}
+
+	return breakdown, nil
+}
+
+// CalculateShareTransferDuty computes duty on a transfer of shares on the
+// higher of the consideration and the net asset value of the shares
+// transferred. Transfers of interests in property-holding entities also
+// attract Additional Conveyance Duties.
+func (s *StampDutyService) CalculateShareTransferDuty(req *models.ShareTransferRequest) (*models.EStampDutyBreakdown, error) {
+	documentDate, err := s.parseDateField("dateOfDocument", req.DateOfDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	company := req.TargetCompany
+	var fieldErrors DutyFieldErrors
+
+	// The fraction of the company's equity being transferred, by share count
+	// for a single class of shares and by value otherwise
+	var fraction, navShare float64
+	switch {
+	case !company.HasOneClassOfShares:
+		if company.NetAssetValue > 0 && company.NetAssetValueOfSharesTransferred <= 0 {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{
+				Field:   "targetCompany.netAssetValueOfSharesTransferred",
+				Message: "Net asset value of the shares transferred is required when the company has more than one class of shares",
+			})
+			break
+		}
+		navShare = company.NetAssetValueOfSharesTransferred
+		if company.NetAssetValue > 0 {
+			fraction = navShare / company.NetAssetValue
+		}
+	case company.NetAssetValue > 0 || company.ResidentialPropertyValue > 0:
+		if company.TotalIssuedShares <= 0 {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{
+				Field:   "targetCompany.totalIssuedShares",
+				Message: "Total issued shares is required to apportion the company's net asset value",
+			})
+			break
+		}
+		if company.NoOfSharesTransferred > company.TotalIssuedShares {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{
+				Field:   "targetCompany.noOfSharesTransferred",
+				Message: "Shares transferred cannot exceed total issued shares",
+			})
+			break
+		}
+		fraction = float64(company.NoOfSharesTransferred) / float64(company.TotalIssuedShares)
+		navShare = roundCents(company.NetAssetValue * fraction)
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	dutiable := math.Max(req.ConsiderationAmount, navShare)
+	if dutiable == 0 {
+		dutiable = company.TotalMarketPrice
+	}
+
+	// 0.2% of the dutiable value, subject to a minimum of $1
+	duty := math.Max(math.Floor(roundCents(dutiable*shareTransferRate)), 1)
+
+	component := models.EStampDutyComponent{
+		DutyType:       "SHARE",
+		DutiableAmount: dutiable,
+		Rate:           shareTransferRate,
+		Duty:           duty,
+	}
+	if navShare > req.ConsiderationAmount {
+		component.Remarks = fmt.Sprintf("Charged on net asset value of shares transferred (%.2f) as it exceeds the consideration", navShare)
+	}
+
+	breakdown := &models.EStampDutyBreakdown{
+		DocumentDate:   documentDate.Format("2006-01-02"),
+		DutiableAmount: dutiable,
+		Components:     []models.EStampDutyComponent{component},
+	}
+	versions := map[string]bool{shareTransferRateVersion: true}
+
+	acdComponents, acdVersions, err := s.additionalConveyanceDuty(documentDate, company, fraction)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.Components = append(breakdown.Components, acdComponents...)
+	for version := range acdVersions {
+		versions[version] = true
+	}
+
+	for _, component := range breakdown.Components {
+		breakdown.TotalDuty += component.Duty
+	}
+	breakdown.RateVersion = joinVersions(versions)
+
+	return breakdown, nil
+}
+
+// IsPropertyHoldingEntity reports whether a company's Singapore residential
+// property makes up enough of its tangible assets for Additional Conveyance
+// Duties to apply to transfers of its shares
+func (s *StampDutyService) IsPropertyHoldingEntity(company models.TargetCompanyData) bool {
+	if company.TotalTangibleAssets <= 0 || company.ResidentialPropertyValue <= 0 {
+		return false
+	}
+	return company.ResidentialPropertyValue/company.TotalTangibleAssets >= acdPropertyHoldingThreshold
+}
+
+// additionalConveyanceDuty computes the buyer's and seller's Additional
+// Conveyance Duties on a transfer of the given fraction of a
+// property-holding entity's equity, returning no components when ACD does
+// not apply
+func (s *StampDutyService) additionalConveyanceDuty(documentDate time.Time, company models.TargetCompanyData, fraction float64) ([]models.EStampDutyComponent, map[string]bool, error) {
+	versions := make(map[string]bool)
+	if documentDate.Before(acdEffectiveFrom) || fraction <= 0 || !s.IsPropertyHoldingEntity(company) {
+		return nil, versions, nil
+	}
+
+	propertyValue := roundCents(company.ResidentialPropertyValue * fraction)
+	var components []models.EStampDutyComponent
+
+	// Buyer's ACD: BSD rates plus the entity ABSD rate on the residential
+	// property attributable to the interest transferred
+	if company.TransfereeEquityInterest >= acdSignificantOwnerThreshold {
+		bsdTable := s.bsdRateTableFor(documentDate)
+		absdTable := s.absdRateTableFor(documentDate)
+		versions[acdRateVersion] = true
+		versions[bsdTable.Version] = true
+
+		bsd := s.bandedComponent("ACD", ScheduleResidential, propertyValue, bsdTable.Residential)
+		bsd.Remarks = "Buyer's ACD at BSD rates on residential property of a property-holding entity"
+		components = append(components, bsd)
+
+		if !documentDate.Before(absdTable.EffectiveFrom) {
+			versions[absdTable.Version] = true
+			rate := s.absdRate(absdTable, ABSDProfileEntity, 0)
+			components = append(components, models.EStampDutyComponent{
+				DutyType:       "ACD",
+				Schedule:       ScheduleResidential,
+				DutiableAmount: propertyValue,
+				Rate:           rate,
+				Duty:           math.Floor(roundCents(propertyValue * rate)),
+				Profile:        ABSDProfileEntity,
+				Remarks:        "Buyer's ACD at the entity ABSD rate",
+			})
+		}
+	}
+
+	// Seller's ACD on interests held for less than acdSellerYears
+	if company.TransferorEquityInterest >= acdSignificantOwnerThreshold && strings.TrimSpace(company.TransferorDateOfAcquisition) != "" {
+		acquired, err := s.parseDateField("targetCompany.transferorDateOfAcquisition", company.TransferorDateOfAcquisition)
+		if err != nil {
+			return nil, nil, err
+		}
+		if documentDate.Before(utils.AddMonths(acquired, acdSellerYears*12)) {
+			versions[acdRateVersion] = true
+			components = append(components, models.EStampDutyComponent{
+				DutyType:       "ACD",
+				Schedule:       ScheduleResidential,
+				DutiableAmount: propertyValue,
+				Rate:           acdSellerRate,
+				Duty:           math.Floor(roundCents(propertyValue * acdSellerRate)),
+				Remarks:        fmt.Sprintf("Seller's ACD on an interest held for less than %d years", acdSellerYears),
+			})
+		}
+	}
+
+	return components, versions, nil
+}
+
+// CalculateMortgageDuty computes duty on a mortgage from the loan amount,
+// then allocates the loan and the duty across the mortgaged assets in
+// proportion to their values
+func (s *StampDutyService) CalculateMortgageDuty(req *models.StampMortgageRequest) (*models.EStampDutyBreakdown, error) {
+	documentDate, err := s.parseDateField("dateOfDocument", req.DateOfDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	var fieldErrors DutyFieldErrors
+
+	// Documents that do not state a type are treated as legal mortgages
+	mortgageType := req.TypeOfMortgage
+	if mortgageType == 0 {
+		mortgageType = MortgageTypeLegal
+	}
+	description, ok := mortgageTypeDescriptions[mortgageType]
+	if !ok {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "typeOfMortgage", Message: fmt.Sprintf("Unknown mortgage type %d", req.TypeOfMortgage)})
+	}
+	if req.AmountOfLoan <= 0 {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "amountOfLoan", Message: "Amount of loan must be greater than 0"})
+	}
+	if mortgageType == MortgageTypeStockShare && len(req.Assets.StocksShares) == 0 {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "assets.stocksShares", Message: "A mortgage of stocks and shares must list the stocks and shares mortgaged"})
+	}
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	rule := mortgageDuty
+	duty := math.Floor(roundCents(req.AmountOfLoan * rule.Rate))
+	remarks := description
+	switch {
+	case duty < rule.MinimumDuty:
+		duty = rule.MinimumDuty
+		remarks += fmt.Sprintf("; minimum duty of %.2f applies", rule.MinimumDuty)
+	case duty > rule.MaximumDuty:
+		duty = rule.MaximumDuty
+		remarks += fmt.Sprintf("; duty capped at %.2f per document", rule.MaximumDuty)
+	}
+
+	breakdown := &models.EStampDutyBreakdown{
+		RateVersion:    mortgageRateVersion,
+		DocumentDate:   documentDate.Format("2006-01-02"),
+		DutiableAmount: req.AmountOfLoan,
+		TotalDuty:      duty,
+	}
+
+	// Stocks and shares secure a stock/share mortgage; properties and land
+	// secure the others
+	var assets []models.EStampDutyComponent
+	var weights []float64
+	if mortgageType == MortgageTypeStockShare {
+		for _, stockShare := range req.Assets.StocksShares {
+			assets = append(assets, models.EStampDutyComponent{AssetType: AssetTypeStockShare, AssetSequence: stockShare.Sequence})
+			weights = append(weights, float64(stockShare.NoStockShares))
+		}
+	} else {
+		for _, property := range req.Assets.Properties {
+			assets = append(assets, models.EStampDutyComponent{AssetType: AssetTypeProperty, AssetSequence: property.Sequence})
+			weights = append(weights, math.Max(property.BuyingPriceMarketValueResidential+property.BuyingPriceMarketValueNonResidential, property.ValuationValue))
+		}
+		for _, land := range req.Assets.Lands {
+			assets = append(assets, models.EStampDutyComponent{AssetType: AssetTypeLand, AssetSequence: land.Sequence})
+			weights = append(weights, math.Max(land.BuyingPriceMarketValueResidential+land.BuyingPriceMarketValueNonResidential, land.ValuationValue))
+		}
+	}
+
+	if len(assets) == 0 {
+		breakdown.Components = []models.EStampDutyComponent{
+			{
+				DutyType:       "MORTGAGE",
+				DutiableAmount: req.AmountOfLoan,
+				Rate:           rule.Rate,
+				Duty:           duty,
+				Remarks:        remarks,
+			},
+		}
+		return breakdown, nil
+	}
+
+	// Allocate by value, or equally when no asset values are given. The last
+	// asset takes any rounding difference so the allocations add up.
+	var totalWeight float64
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	var allocatedLoan, allocatedDuty float64
+	for i := range assets {
+		share := 1 / float64(len(assets))
+		if totalWeight > 0 {
+			share = weights[i] / totalWeight
+		}
+
+		component := assets[i]
+		component.DutyType = "MORTGAGE"
+		component.Rate = rule.Rate
+		component.Remarks = remarks
+		if i == len(assets)-1 {
+			component.DutiableAmount = roundCents(req.AmountOfLoan - allocatedLoan)
+			component.Duty = roundCents(duty - allocatedDuty)
+		} else {
+			component.DutiableAmount = roundCents(req.AmountOfLoan * share)
+			component.Duty = roundCents(duty * share)
+		}
+		allocatedLoan += component.DutiableAmount
+		allocatedDuty += component.Duty
+
+		breakdown.Components = append(breakdown.Components, component)
+	}
+
+	return breakdown, nil
+}
+
+// ApplyLatePenalty records the stamping deadline and any late stamping
+// penalty on the breakdown for a document submitted on submittedOn, and
+// returns the date by which duty and penalty must be paid. The stamping
+// window runs from the document date for documents signed in Singapore and
+// from the date the document was received in Singapore otherwise.
+func (s *StampDutyService) ApplyLatePenalty(breakdown *models.EStampDutyBreakdown, signedInSingapore bool, receivingDate string, submittedOn time.Time) (time.Time, error) {
+	documentDate, err := time.Parse("2006-01-02", breakdown.DocumentDate)
+	if err != nil {
+		return time.Time{}, DutyFieldErrors{{Field: "dateOfDocument", Message: "Date must be in YYYY-MM-DD format"}}
+	}
+	submittedOn = time.Date(submittedOn.Year(), submittedOn.Month(), submittedOn.Day(), 0, 0, 0, 0, time.UTC)
+
+	windowStart := documentDate
+	windowDays := stampingWindowInSingaporeDays
+	if !signedInSingapore {
+		windowDays = stampingWindowOverseasDays
+		if strings.TrimSpace(receivingDate) != "" {
+			windowStart, err = s.parseDateField("receivingDateOfDocument", receivingDate)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if windowStart.Before(documentDate) {
+				return time.Time{}, DutyFieldErrors{{Field: "receivingDateOfDocument", Message: "Document cannot be received before it is dated"}}
+			}
+		}
+	}
+	deadline := windowStart.AddDate(0, 0, windowDays)
+
+	penalty := &models.EStampPenalty{
+		StampingDeadline: deadline.Format("2006-01-02"),
+	}
+	breakdown.Penalty = penalty
+
+	paymentDue := submittedOn.AddDate(0, 0, paymentGraceDays)
+	if deadline.After(paymentDue) {
+		paymentDue = deadline
+	}
+
+	if !submittedOn.After(deadline) {
+		return paymentDue, nil
+	}
+	penalty.DaysLate = int(submittedOn.Sub(deadline).Hours() / 24)
+
+	// No penalty arises where no duty is payable
+	if breakdown.TotalDuty <= 0 {
+		return paymentDue, nil
+	}
+
+	for _, tier := range latePenaltyTiers {
+		if tier.MaxMonthsLate > 0 && submittedOn.After(utils.AddMonths(deadline, tier.MaxMonthsLate)) {
+			continue
+		}
+		penalty.Tier = tier.Tier
+		penalty.Amount = math.Max(tier.Minimum, math.Floor(roundCents(breakdown.TotalDuty*tier.Multiple)))
+		break
+	}
+
+	return paymentDue, nil
+}
+
+// leaseRent returns the total rent over the lease and the lease term in
+// years. Each rental period is charged on the higher of the rent and the
+// market rent; rent amounts are monthly when IsMonthlyRentPayable is set and
+// annual otherwise.
+func (s *StampDutyService) leaseRent(field string, rental models.AssessmentRentalData) (float64, float64, DutyFieldErrors) {
+	if len(rental.RentalDetails) == 0 {
+		if rental.AverageRentAmount > 0 {
+			return rental.TotalGrossRentAmount, rental.TotalGrossRentAmount / rental.AverageRentAmount, nil
+		}
+		return rental.TotalGrossRentAmount, 1, nil
+	}
+
+	periodsPerYear := 1.0
+	if rental.IsMonthlyRentPayable {
+		periodsPerYear = 12
+	}
+
+	var fieldErrors DutyFieldErrors
+	var totalRent float64
+	var leaseStart, leaseEnd time.Time
+
+	for j, detail := range rental.RentalDetails {
+		detailField := fmt.Sprintf("%s.rentalDetails[%d]", field, j)
+
+		start, err := time.Parse("2006-01-02", strings.TrimSpace(detail.StartPeriodOfLease))
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: detailField + ".startPeriodOfLease", Message: "Date must be in YYYY-MM-DD format"})
+			continue
+		}
+		end, err := time.Parse("2006-01-02", strings.TrimSpace(detail.EndPeriodOfLease))
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: detailField + ".endPeriodOfLease", Message: "Date must be in YYYY-MM-DD format"})
+			continue
+		}
+		if end.Before(start) {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: detailField + ".endPeriodOfLease", Message: "End of lease period must not be before its start"})
+			continue
+		}
+
+		rent := math.Max(detail.RentAmount, detail.MarketRentalValue)
+		totalRent += rent * periodsPerYear * leasePeriodYears(start, end)
+
+		if leaseStart.IsZero() || start.Before(leaseStart) {
+			leaseStart = start
+		}
+		if end.After(leaseEnd) {
+			leaseEnd = end
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return 0, 0, fieldErrors
+	}
+	return totalRent, leasePeriodYears(leaseStart, leaseEnd), nil
+}
+
+// premiumBySchedule splits a lease premium into its residential and
+// non-residential parts, using the component amounts when given and the
+// type of the first property otherwise
+func (s *StampDutyService) premiumBySchedule(rental models.AssessmentRentalData, assets models.AssetsData) (float64, float64) {
+	premium := rental.PremiumConsiderationAmount
+	split := rental.ResidentialComponentAmount + rental.NonResidentialComponentAmount
+	if split > 0 {
+		residential := roundCents(premium * rental.ResidentialComponentAmount / split)
+		return residential, premium - residential
+	}
+
+	if len(assets.Properties) > 0 && (assets.Properties[0].PropertyType == PropertyTypeNonResidential || assets.Properties[0].PropertyType == PropertyTypeIndustrial) {
+		return 0, premium
+	}
+	return premium, 0
+}
+
+// CalculateResidentialSSD computes Seller's Stamp Duty on a residential
+// property under the regime in force when it was acquired
+func (s *StampDutyService) CalculateResidentialSSD(value float64, acquisitionDate, disposalDate time.Time) SSDResult {
+	return s.calculateSSD(residentialSSDRuleTables, value, acquisitionDate, disposalDate)
+}
+
+// CalculateIndustrialSSD computes Seller's Stamp Duty on an industrial
+// property under the regime in force when it was acquired
+func (s *StampDutyService) CalculateIndustrialSSD(value float64, acquisitionDate, disposalDate time.Time) SSDResult {
+	return s.calculateSSD(industrialSSDRuleTables, value, acquisitionDate, disposalDate)
+}
+
+// calculateSSD picks the regime for the acquisition date and the band for
+// the exact holding period. A property disposed of on an anniversary falls
+// in the band ending on that anniversary.
+func (s *StampDutyService) calculateSSD(tables []ssdRuleTable, value float64, acquisitionDate, disposalDate time.Time) SSDResult {
+	years, months, days := utils.HoldingPeriod(acquisitionDate, disposalDate)
+	result := SSDResult{
+		HoldingPeriod: models.SSDHoldingPeriod{Years: years, Months: months, Days: days},
+		RuleVersion:   SSDRuleVersionNone,
+	}
+
+	if acquisitionDate.Before(tables[0].AcquiredFrom) {
+		return result
+	}
+
+	table := tables[0]
+	for _, candidate := range tables {
+		if !acquisitionDate.Before(candidate.AcquiredFrom) {
+			table = candidate
+		}
+	}
+	result.RuleVersion = table.Version
+
+	for _, band := range table.Bands {
+		if years < band.MaxYears || (years == band.MaxYears && months == 0 && days == 0) {
+			result.Rate = band.Rate
+			break
+		}
+	}
+
+	result.Duty = math.Floor(roundCents(value * result.Rate))
+	return result
+}
+
+// absdComponent resolves the ABSD profile of every buyer, or of the
+// beneficial owners where the property is bought on trust, and charges the
+// residential value at the highest applicable rate. It reports false when
+// no ABSD regime applies on the document date or no buyers are given.
+func (s *StampDutyService) absdComponent(documentDate time.Time, residential float64, req *models.SalePurchaseBuyersRequest) (models.EStampDutyComponent, bool) {
+	if documentDate.Before(absdRateTables[0].EffectiveFrom) {
+		return models.EStampDutyComponent{}, false
+	}
+	table := s.absdRateTableFor(documentDate)
+
+	if req.HasIntentToHoldThePropInTrustForBeneficialOwner {
+		return s.trustABSDComponent(table, residential, req), true
+	}
+
+	if len(req.BuyerTransferee) == 0 {
+		return models.EStampDutyComponent{}, false
+	}
+	return s.highestABSDComponent(table, residential, PartyRoleBuyer, req.BuyerTransferee, s.propertiesOwnedByBuyer(req)), true
+}
+
+// trustABSDComponent charges ABSD on property bought on trust. Property held
+// for beneficiaries who cannot be identified is charged at the trust rate,
+// or the entity rate before a trust rate applied; otherwise ABSD follows the
+// profiles of the beneficial owners as if they had bought it themselves.
+func (s *StampDutyService) trustABSDComponent(table absdRateTable, residential float64, req *models.SalePurchaseBuyersRequest) models.EStampDutyComponent {
+	for _, beneficiary := range req.Beneficiary {
+		if !beneficiary.BeneficiaryIsUnidentifiable {
+			continue
+		}
+
+		profile := ABSDProfileTrust
+		if _, ok := table.Rates[profile]; !ok {
+			profile = ABSDProfileEntity
+		}
+		rate := s.absdRate(table, profile, 0)
+		return models.EStampDutyComponent{
+			DutyType:       "ABSD",
+			Schedule:       ScheduleResidential,
+			DutiableAmount: residential,
+			Rate:           rate,
+			Profile:        profile,
+			PartySequence:  beneficiary.Sequence,
+			PartyName:      beneficiary.FTDescription,
+			Duty:           math.Floor(roundCents(residential * rate)),
+			Remarks:        fmt.Sprintf("Held on trust for unidentifiable beneficiary %d (%s rate)", beneficiary.Sequence, profile),
+		}
+	}
+
+	return s.highestABSDComponent(table, residential, PartyRoleBeneficiary, req.Beneficiary, s.propertiesOwnedByBuyer(req))
+}
+
+// highestABSDComponent charges the residential value at the highest rate
+// applicable to any of the parties, given the properties each already owns
+func (s *StampDutyService) highestABSDComponent(table absdRateTable, residential float64, role string, parties []models.SalePurchasePartyData, owned map[int]int) models.EStampDutyComponent {
+	var component models.EStampDutyComponent
+	for i, party := range parties {
+		profile := s.ABSDProfile(party)
+		count := owned[party.Sequence]
+		rate := s.absdRate(table, profile, count)
+
+		if i == 0 || rate > component.Rate {
+			component = models.EStampDutyComponent{
+				DutyType:       "ABSD",
+				Schedule:       ScheduleResidential,
+				DutiableAmount: residential,
+				Rate:           rate,
+				Profile:        profile,
+				PartySequence:  party.Sequence,
+				PartyName:      party.FTTaxEntityName,
+				Remarks:        fmt.Sprintf("Rate driven by %s %d (%s, %s property)", role, party.Sequence, profile, ordinal(count+1)),
+			}
+		}
+	}
+
+	component.Duty = math.Floor(roundCents(residential * component.Rate))
+	return component
+}
+
+// validateTrust checks that a purchase on trust names its trustees and
+// beneficiaries and gives the reasons for holding the property on trust
+func (s *StampDutyService) validateTrust(req *models.SalePurchaseBuyersRequest) DutyFieldErrors {
+	if !req.HasIntentToHoldThePropInTrustForBeneficialOwner {
+		return nil
+	}
+
+	var fieldErrors DutyFieldErrors
+	if len(req.Trustee) == 0 {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "trustee", Message: "At least one trustee is required when the property is held on trust"})
+	}
+	if len(req.Beneficiary) == 0 {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "beneficiary", Message: "At least one beneficiary is required when the property is held on trust"})
+	}
+	for i, beneficiary := range req.Beneficiary {
+		field := fmt.Sprintf("beneficiary[%d]", i)
+		if beneficiary.BeneficiaryIsUnidentifiable {
+			if strings.TrimSpace(beneficiary.FTDescription) == "" {
+				fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + ".ftDescription", Message: "Unidentifiable beneficiaries must be described"})
+			}
+		} else if strings.TrimSpace(beneficiary.TaxEntityIDNo) == "" {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + ".taxEntityIdNo", Message: "Identifiable beneficiaries must be identified"})
+		}
+	}
+
+	reasons := req.TrusteeBeneficiaryDetails.Reasons
+	if !reasons.IsReasonBeneficiaryIsMinor && !reasons.IsReasonBeneficiaryIsNotLegalEntity && !reasons.IsReasonEstatePlanning &&
+		!reasons.IsReasonPursuantToNomineeArrangement && !reasons.IsReasonOthers {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "trusteeBeneficiaryDetails.reasons", Message: "At least one reason for holding the property on trust is required"})
+	}
+	if reasons.IsReasonOthers && strings.TrimSpace(reasons.FTReasonsOthersValue) == "" {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: "trusteeBeneficiaryDetails.reasons.ftReasonsOthersValue", Message: "Other reasons must be described"})
+	}
+
+	return fieldErrors
+}
+
+// ABSDProfile classifies a buyer for ABSD purposes. Individuals are
+// classified by their stated nationality, which validateABSDNationality
+// requires before ABSD is charged.
+func (s *StampDutyService) ABSDProfile(buyer models.SalePurchasePartyData) string {
+	if buyer.TypeOfProfile == TypeOfProfileEntity {
+		return ABSDProfileEntity
+	}
+
+	nationality := strings.ToUpper(strings.TrimSpace(buyer.CountryOfNationality))
+	switch {
+	case nationality == "":
+		return ABSDProfileForeigner
+	case nationality == "SG" || nationality == "SGP" || nationality == "SINGAPORE":
+		return ABSDProfileCitizen
+	case absdFTACountries[nationality]:
+		return ABSDProfileCitizen
+	case buyer.TaxEntityIDType == TaxEntityIDTypeNRIC:
+		// An NRIC issued to a foreign national is held by a permanent resident
+		return ABSDProfilePR
+	default:
+		return ABSDProfileForeigner
+	}
+}
+
+// validateABSDNationality checks that every individual whose profile sets
+// the ABSD rate states a nationality: the buyers, or the identifiable
+// beneficiaries when the property is bought on trust
+func validateABSDNationality(req *models.SalePurchaseBuyersRequest) DutyFieldErrors {
+	field, parties := "buyerTransferee", req.BuyerTransferee
+	if req.HasIntentToHoldThePropInTrustForBeneficialOwner {
+		field, parties = "beneficiary", req.Beneficiary
+	}
+
+	var fieldErrors DutyFieldErrors
+	for i, party := range parties {
+		if party.TypeOfProfile == TypeOfProfileEntity || party.BeneficiaryIsUnidentifiable {
+			continue
+		}
+		if strings.TrimSpace(party.CountryOfNationality) == "" {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{
+				Field:   fmt.Sprintf("%s[%d].countryOfNationality", field, i),
+				Message: "Nationality is required to determine the ABSD rate",
+			})
+		}
+	}
+	return fieldErrors
+}
+
+// absdRate returns the rate for a profile given the number of residential
+// properties the buyer already owns
+func (s *StampDutyService) absdRate(table absdRateTable, profile string, owned int) float64 {
+	rates := table.Rates[profile]
+	if override, ok := s.absdRateOverrides[profile]; ok && table.Version == absdRateTables[len(absdRateTables)-1].Version {
+		rates = override
+	}
+	if len(rates) == 0 {
+		return 0
+	}
+	if owned >= len(rates) {
+		return rates[len(rates)-1]
+	}
+	return rates[owned]
+}
+
+// propertiesOwnedByBuyer maps buyer sequences to the number of residential
+// properties already owned. Married couples are assessed together, so each
+// spouse takes the higher count of the two.
+func (s *StampDutyService) propertiesOwnedByBuyer(req *models.SalePurchaseBuyersRequest) map[int]int {
+	owned := make(map[int]int)
+	for _, propertyBuyer := range req.PropertyBuyers {
+		sequence := propertyBuyer.AssessmentPartiesSequence
+		if sequence == 0 {
+			sequence = propertyBuyer.Sequence
+		}
+		if propertyBuyer.NoOfProperties > owned[sequence] {
+			owned[sequence] = propertyBuyer.NoOfProperties
+		}
+	}
+
+	if req.MaritalStatus.MarriedCouple == 1 {
+		var highest int
+		for _, count := range owned {
+			highest = max(highest, count)
+		}
+		for sequence := range owned {
+			owned[sequence] = highest
+		}
+		for _, buyer := range req.BuyerTransferee {
+			owned[buyer.Sequence] = highest
+		}
+	}
+
+	return owned
+}
+
+// absdRateTableFor returns the ABSD schedule in force on the given date
+func (s *StampDutyService) absdRateTableFor(date time.Time) absdRateTable {
+	table := absdRateTables[0]
+	for _, candidate := range absdRateTables {
+		if !date.Before(candidate.EffectiveFrom) {
+			table = candidate
+		}
+	}
+	return table
+}
+
+// bsdRateTableFor returns the BSD schedule in force on the given date
+func (s *StampDutyService) bsdRateTableFor(date time.Time) bsdRateTable {
+	table := bsdRateTables[0]
+	for _, candidate := range bsdRateTables {
+		if !date.Before(candidate.EffectiveFrom) {
+			table = candidate
+		}
+	}
+	return table
+}
+
+// assetValue is the dutiable value of the share transferred in one property
+// or land, apportioned between the residential and non-residential schedules
+type assetValue struct {
+	AssetType      string
+	Sequence       int
+	Residential    float64
+	NonResidential float64
+}
+
+// buyingValuesBySchedule apportions the dutiable value of the share
+// transferred in each property and land between the schedules it is
+// charged under
+func (s *StampDutyService) buyingValuesBySchedule(assets models.SalePurchaseAssetsData) ([]assetValue, DutyFieldErrors) {
+	var values []assetValue
+	var fieldErrors DutyFieldErrors
+
+	for i, property := range assets.Properties {
+		fraction, errs := s.propertyFraction(fmt.Sprintf("assets.properties[%d]", i), property)
+		if len(errs) > 0 {
+			fieldErrors = append(fieldErrors, errs...)
+			continue
+		}
+
+		residential, nonResidential := property.BuyingPriceMarketValueResidential, property.BuyingPriceMarketValueNonResidential
+		if residential == 0 && nonResidential == 0 {
+			for _, unit := range property.LevelUnits {
+				residential += unit.BuyingPriceMarketValueResidential
+			}
+		}
+		isResidential := property.PropertyType != PropertyTypeNonResidential && property.PropertyType != PropertyTypeIndustrial
+		residential, nonResidential = apportionValue(residential, nonResidential, property.ValuationValue, isResidential)
+
+		values = append(values, assetValue{
+			AssetType:      AssetTypeProperty,
+			Sequence:       property.Sequence,
+			Residential:    roundCents(fraction * residential),
+			NonResidential: roundCents(fraction * nonResidential),
+		})
+	}
+
+	for i, land := range assets.Lands {
+		fraction, errs := s.landFraction(fmt.Sprintf("assets.lands[%d]", i), land)
+		if len(errs) > 0 {
+			fieldErrors = append(fieldErrors, errs...)
+			continue
+		}
+
+		isResidential := landSchedule(land.MasterPlanZoning) == ScheduleResidential
+		residential, nonResidential := apportionValue(land.BuyingPriceMarketValueResidential, land.BuyingPriceMarketValueNonResidential, land.ValuationValue, isResidential)
+
+		values = append(values, assetValue{
+			AssetType:      AssetTypeLand,
+			Sequence:       land.Sequence,
+			Residential:    roundCents(fraction * residential),
+			NonResidential: roundCents(fraction * nonResidential),
+		})
+	}
+
+	return values, fieldErrors
+}
+
+// apportionValue splits the dutiable value of an asset, the higher of its
+// price and its valuation, between the residential and non-residential
+// schedules in proportion to the residential and non-residential prices. An
+// asset without prices is charged wholly under its default schedule.
+func apportionValue(residential, nonResidential, valuation float64, defaultResidential bool) (float64, float64) {
+	total := math.Max(residential+nonResidential, valuation)
+	if residential+nonResidential <= 0 {
+		if defaultResidential {
+			return total, 0
+		}
+		return 0, total
+	}
+
+	residentialPart := roundCents(total * residential / (residential + nonResidential))
+	return residentialPart, total - residentialPart
+}
+
+// landSchedule picks the duty schedule for land from its Master Plan
+// zoning. Land zoned wholly or partly for residential use, or with no
+// zoning given, is residential.
+func landSchedule(zoning int) string {
+	switch zoning {
+	case 0, MasterPlanZoningResidential, MasterPlanZoningCommercialAndResidential, MasterPlanZoningResidentialWithCommercialAtFirst:
+		return ScheduleResidential
+	case MasterPlanZoningBusiness:
+		return ScheduleIndustrial
+	default:
+		return ScheduleNonResidential
+	}
+}
+
+// ownershipShare is one co-owner's share of a property or land
+type ownershipShare struct {
+	Numerator   int
+	Denominator int
+}
+
+// fractionTolerance absorbs floating point error when comparing fractions
+const fractionTolerance = 1e-9
+
+// propertyFraction returns the fraction of a property being transferred
+func (s *StampDutyService) propertyFraction(field string, property models.PropertyData) (float64, DutyFieldErrors) {
+	ownerships := make([]ownershipShare, 0, len(property.PropertyOwnerships))
+	for _, ownership := range property.PropertyOwnerships {
+		ownerships = append(ownerships, ownershipShare{Numerator: ownership.ShareNumerator, Denominator: ownership.ShareDenominator})
+	}
+	return s.transferredFraction(field, fmt.Sprintf("Property %d", property.Sequence), property.ShareOfPropertyTransferred, property.MannerOfHolding,
+		property.FractionNumerator, property.FractionDenominator, "propertyOwnerships", ownerships)
+}
+
+// landFraction returns the fraction of a piece of land being transferred
+func (s *StampDutyService) landFraction(field string, land models.LandData) (float64, DutyFieldErrors) {
+	ownerships := make([]ownershipShare, 0, len(land.LandOwnership))
+	for _, ownership := range land.LandOwnership {
+		ownerships = append(ownerships, ownershipShare{Numerator: ownership.ShareNumerator, Denominator: ownership.ShareDenominator})
+	}
+	return s.transferredFraction(field, fmt.Sprintf("Land %d", land.Sequence), land.ShareOfLandTransferred, land.MannerOfHolding,
+		land.FractionNumerator, land.FractionDenominator, "landOwnership", ownerships)
+}
+
+// transferredFraction works out the fraction of an asset being transferred
+// from the stated fraction and the co-owners' shares. Joint tenants hold the
+// asset together in equal undivided shares, so their shares do not change
+// the fraction; tenants in common hold distinct shares that together make up
+// the fraction transferred, and stand in for it when no fraction is stated.
+// Errors are reported against the asset's field path and named by label.
+func (s *StampDutyService) transferredFraction(field, label string, shareTransferred, mannerOfHolding, numerator, denominator int, ownershipField string, ownerships []ownershipShare) (float64, DutyFieldErrors) {
+	var fieldErrors DutyFieldErrors
+
+	if mannerOfHolding != 0 && mannerOfHolding != MannerOfHoldingJointTenancy && mannerOfHolding != MannerOfHoldingTenancyInCommon {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + ".mannerOfHolding", Message: fmt.Sprintf("%s: unknown manner of holding %d", label, mannerOfHolding)})
+	}
+
+	fraction := 1.0
+	stated := numerator != 0 || denominator != 0
+	if stated {
+		if denominator <= 0 || numerator <= 0 || numerator > denominator {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + ".fractionNumerator", Message: fmt.Sprintf("%s: fraction transferred must be greater than 0 and at most 1", label)})
+		} else {
+			fraction = float64(numerator) / float64(denominator)
+		}
+	}
+
+	var total float64
+	var first ownershipShare
+	unequal := false
+	for j, ownership := range ownerships {
+		if ownership.Denominator <= 0 || ownership.Numerator <= 0 || ownership.Numerator > ownership.Denominator {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: fmt.Sprintf("%s.%s[%d].shareNumerator", field, ownershipField, j), Message: fmt.Sprintf("%s: ownership share must be greater than 0 and at most 1", label)})
+			continue
+		}
+		total += float64(ownership.Numerator) / float64(ownership.Denominator)
+		if j == 0 {
+			first = ownership
+		} else if ownership.Numerator*first.Denominator != first.Numerator*ownership.Denominator {
+			unequal = true
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return 0, fieldErrors
+	}
+	if total > 1+fractionTolerance {
+		return 0, DutyFieldErrors{{Field: field + "." + ownershipField, Message: fmt.Sprintf("%s: ownership shares add up to more than the whole", label)}}
+	}
+
+	switch {
+	case mannerOfHolding == MannerOfHoldingJointTenancy && unequal:
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + "." + ownershipField, Message: fmt.Sprintf("%s: joint tenants must hold equal shares", label)})
+	case mannerOfHolding == MannerOfHoldingTenancyInCommon && len(ownerships) > 0:
+		if !stated {
+			fraction = total
+		} else if math.Abs(total-fraction) > fractionTolerance {
+			fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + "." + ownershipField, Message: fmt.Sprintf("%s: ownership shares add up to %.4f but the fraction transferred is %d/%d", label, total, numerator, denominator)})
+		}
+	}
+
+	if shareTransferred == ShareTransferredPart && !stated && !(mannerOfHolding == MannerOfHoldingTenancyInCommon && len(ownerships) > 0) {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + ".fractionNumerator", Message: fmt.Sprintf("%s: fraction transferred is required when part of the asset is transferred", label)})
+	}
+	if shareTransferred == ShareTransferredWhole && fraction < 1-fractionTolerance {
+		fieldErrors = append(fieldErrors, models.EStampFieldError{Field: field + ".fractionNumerator", Message: fmt.Sprintf("%s: fraction transferred must be 1 when the whole asset is transferred", label)})
+	}
+
+	if len(fieldErrors) > 0 {
+		return 0, fieldErrors
+	}
+	return fraction, nil
+}
+
+// bandedComponent applies marginal bands to an amount. Duty is rounded down
+// to the nearest dollar.
+func (s *StampDutyService) bandedComponent(dutyType, schedule string, amount float64, bands []dutyBand) models.EStampDutyComponent {
+	component := models.EStampDutyComponent{
+		DutyType:       dutyType,
+		Schedule:       schedule,
+		DutiableAmount: amount,
+	}
+
+	var lower, total float64
+	for _, band := range bands {
+		if amount <= lower {
+			break
+		}
+
+		taxable := amount - lower
+		var upper float64
+		if band.Width > 0 {
+			upper = lower + band.Width
+			taxable = math.Min(taxable, band.Width)
+		}
+
+		duty := taxable * band.Rate
+		component.Bands = append(component.Bands, models.EStampDutyBand{
+			From:          lower,
+			To:            upper,
+			Rate:          band.Rate,
+			TaxableAmount: taxable,
+			Duty:          roundCents(duty),
+		})
+		total += duty
+
+		if band.Width == 0 {
+			break
+		}
+		lower = upper
+	}
+
+	component.Duty = math.Floor(roundCents(total))
+	return component
+}
+
+// leasePeriodYears measures a lease period in years, counting the end date
+// as the last day of the lease
+func leasePeriodYears(start, end time.Time) float64 {
+	years, months, days := utils.HoldingPeriod(start, end.AddDate(0, 0, 1))
+	return float64(years) + float64(months)/12 + float64(days)/365
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// joinVersions lists the rule versions used in a calculation
+func joinVersions(versions map[string]bool) string {
+	list := make([]string, 0, len(versions))
+	for version := range versions {
+		list = append(list, version)
+	}
+	sort.Strings(list)
+	return strings.Join(list, "/")
+}
+
+// ordinal formats a property count as 1st, 2nd, 3rd...
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
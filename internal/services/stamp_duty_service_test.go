@@ -0,0 +1,1115 @@
+package services
+
+import (
+	"api-iras/internal/models"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func mustDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("invalid test date %q: %v", value, err)
+	}
+	return date
+}
+
+func TestBandedComponent(t *testing.T) {
+	tests := []struct {
+		name        string
+		date        string
+		residential bool
+		amount      float64
+		wantVersion string
+		wantDuty    float64
+		wantBands   int
+	}{
+		{"1998 residential", "2017-06-01", true, 1000000, "BSD-1998", 24600, 3},
+		{"2018 residential top band", "2023-02-14", true, 2000000, "BSD-2018", 64600, 4},
+		{"2023 residential", "2023-02-15", true, 1000000, "BSD-2023", 24600, 3},
+		{"2023 residential 5% band", "2024-01-10", true, 2000000, "BSD-2023", 69600, 5},
+		{"2023 residential 6% band", "2024-01-10", true, 3500000, "BSD-2023", 149600, 6},
+		{"2018 non-residential", "2022-01-10", false, 2000000, "BSD-2018", 54600, 3},
+		{"2023 non-residential top band", "2024-01-10", false, 2000000, "BSD-2023", 69600, 5},
+		{"duty rounded down", "2024-01-10", true, 100050.50, "BSD-2023", 1000, 1},
+		{"nothing to charge", "2024-01-10", true, 0, "BSD-2023", 0, 0},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := service.bsdRateTableFor(mustDate(t, tt.date))
+			if table.Version != tt.wantVersion {
+				t.Fatalf("rate table = %s, want %s", table.Version, tt.wantVersion)
+			}
+
+			bands := table.NonResidential
+			if tt.residential {
+				bands = table.Residential
+			}
+			component := service.bandedComponent("BSD", ScheduleResidential, tt.amount, bands)
+			if component.Duty != tt.wantDuty {
+				t.Errorf("duty = %.2f, want %.2f", component.Duty, tt.wantDuty)
+			}
+			if len(component.Bands) != tt.wantBands {
+				t.Errorf("charged %d bands, want %d", len(component.Bands), tt.wantBands)
+			}
+		})
+	}
+}
+
+func TestParseABSDRates(t *testing.T) {
+	overrides := parseABSDRates(" SC=0, 0.20,0.30; foreigner=0.6;bad;PR=x;TRUST=;ENTITY=-0.1;")
+
+	if len(overrides) != 2 {
+		t.Fatalf("got overrides %v, want SC and FOREIGNER only", overrides)
+	}
+	if rates := overrides[ABSDProfileCitizen]; len(rates) != 3 || rates[1] != 0.20 || rates[2] != 0.30 {
+		t.Errorf("SC rates = %v", rates)
+	}
+	if rates := overrides[ABSDProfileForeigner]; len(rates) != 1 || rates[0] != 0.6 {
+		t.Errorf("FOREIGNER rates = %v", rates)
+	}
+}
+
+func TestABSDRate(t *testing.T) {
+	service := NewStampDutyService("SC=0,0.25")
+	latest := service.absdRateTableFor(mustDate(t, "2024-01-10"))
+	earlier := service.absdRateTableFor(mustDate(t, "2022-01-10"))
+
+	tests := []struct {
+		name    string
+		table   absdRateTable
+		profile string
+		owned   int
+		want    float64
+	}{
+		{"citizen first property", earlier, ABSDProfileCitizen, 0, 0},
+		{"citizen second property", earlier, ABSDProfileCitizen, 1, 0.17},
+		{"citizen fourth property", earlier, ABSDProfileCitizen, 3, 0.25},
+		{"permanent resident second property", latest, ABSDProfilePR, 1, 0.30},
+		{"foreigner", latest, ABSDProfileForeigner, 0, 0.60},
+		{"trust before a trust rate", earlier, ABSDProfileTrust, 0, 0},
+		{"override on the latest table", latest, ABSDProfileCitizen, 2, 0.25},
+		{"override ignored on earlier tables", earlier, ABSDProfileCitizen, 1, 0.17},
+		{"override of second property", latest, ABSDProfileCitizen, 1, 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.absdRate(tt.table, tt.profile, tt.owned); got != tt.want {
+				t.Errorf("absdRate(%s, %s, %d) = %v, want %v", tt.table.Version, tt.profile, tt.owned, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestABSDProfile(t *testing.T) {
+	tests := []struct {
+		name  string
+		buyer models.SalePurchasePartyData
+		want  string
+	}{
+		{"entity", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileEntity, CountryOfNationality: "SG"}, ABSDProfileEntity},
+		{"citizen", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileIndividual, CountryOfNationality: " sg "}, ABSDProfileCitizen},
+		{"citizen by name", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileIndividual, CountryOfNationality: "Singapore"}, ABSDProfileCitizen},
+		{"free trade agreement national", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileIndividual, CountryOfNationality: "usa"}, ABSDProfileCitizen},
+		{"permanent resident", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeNRIC, CountryOfNationality: "MY"}, ABSDProfilePR},
+		{"foreigner", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeFIN, CountryOfNationality: "MY"}, ABSDProfileForeigner},
+		{"no nationality", models.SalePurchasePartyData{TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeNRIC}, ABSDProfileForeigner},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.ABSDProfile(tt.buyer); got != tt.want {
+				t.Errorf("ABSDProfile = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateBuyersDuty(t *testing.T) {
+	citizen := models.SalePurchasePartyData{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeNRIC, CountryOfNationality: "SG"}
+	spouse := models.SalePurchasePartyData{Sequence: 2, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeNRIC, CountryOfNationality: "SG"}
+	resident := models.SalePurchasePartyData{Sequence: 2, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeNRIC, CountryOfNationality: "MY"}
+	foreigner := models.SalePurchasePartyData{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeFIN, CountryOfNationality: "MY"}
+	company := models.SalePurchasePartyData{Sequence: 1, TypeOfProfile: TypeOfProfileEntity}
+	trustReasons := models.TrusteeBeneficiaryData{Reasons: models.TrusteeReasonsData{IsReasonBeneficiaryIsMinor: true}}
+
+	tests := []struct {
+		name        string
+		req         models.SalePurchaseBuyersRequest
+		wantVersion string
+		wantProfile string
+		wantABSD    float64
+		wantTotal   float64
+		wantFields  []string
+	}{
+		{
+			name:        "citizen first property",
+			req:         models.SalePurchaseBuyersRequest{DateOfDocument: "2024-01-10", PurchasePrice: 1000000, BuyerTransferee: []models.SalePurchasePartyData{citizen}},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfileCitizen,
+			wantTotal:   24600,
+		},
+		{
+			name: "highest rate among joint buyers",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument:  "2024-01-10",
+				PurchasePrice:   1000000,
+				BuyerTransferee: []models.SalePurchasePartyData{citizen, resident},
+				PropertyBuyers:  []models.PropertyBuyerData{{Sequence: 2, NoOfProperties: 1}},
+			},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfilePR,
+			wantABSD:    300000,
+			wantTotal:   324600,
+		},
+		{
+			name: "married couple assessed together",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument:  "2024-01-10",
+				PurchasePrice:   1000000,
+				BuyerTransferee: []models.SalePurchasePartyData{citizen, spouse},
+				PropertyBuyers:  []models.PropertyBuyerData{{Sequence: 9, AssessmentPartiesSequence: 2, NoOfProperties: 1}},
+				MaritalStatus:   models.MaritalStatusData{MarriedCouple: 1},
+			},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfileCitizen,
+			wantABSD:    200000,
+			wantTotal:   224600,
+		},
+		{
+			name:        "foreigner",
+			req:         models.SalePurchaseBuyersRequest{DateOfDocument: "2024-01-10", PurchasePrice: 1000000, BuyerTransferee: []models.SalePurchasePartyData{foreigner}},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfileForeigner,
+			wantABSD:    600000,
+			wantTotal:   624600,
+		},
+		{
+			name:        "entity under an earlier table",
+			req:         models.SalePurchaseBuyersRequest{DateOfDocument: "2019-01-10", PurchasePrice: 1000000, BuyerTransferee: []models.SalePurchasePartyData{company}},
+			wantVersion: "BSD-2018/ABSD-2018",
+			wantProfile: ABSDProfileEntity,
+			wantABSD:    250000,
+			wantTotal:   274600,
+		},
+		{
+			name:        "before ABSD",
+			req:         models.SalePurchaseBuyersRequest{DateOfDocument: "2012-06-01", PurchasePrice: 1000000, BuyerTransferee: []models.SalePurchasePartyData{foreigner}},
+			wantVersion: "BSD-1998",
+			wantTotal:   24600,
+		},
+		{
+			name: "trust for an unidentifiable beneficiary",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument:  "2024-01-10",
+				PurchasePrice:   1000000,
+				BuyerTransferee: []models.SalePurchasePartyData{citizen},
+				HasIntentToHoldThePropInTrustForBeneficialOwner: true,
+				Trustee:                   []models.SalePurchasePartyData{citizen},
+				Beneficiary:               []models.SalePurchasePartyData{{Sequence: 1, BeneficiaryIsUnidentifiable: true, FTDescription: "Future grandchildren"}},
+				TrusteeBeneficiaryDetails: trustReasons,
+			},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfileTrust,
+			wantABSD:    650000,
+			wantTotal:   674600,
+		},
+		{
+			name: "trust before a trust rate",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument:  "2022-01-10",
+				PurchasePrice:   1000000,
+				BuyerTransferee: []models.SalePurchasePartyData{citizen},
+				HasIntentToHoldThePropInTrustForBeneficialOwner: true,
+				Trustee:                   []models.SalePurchasePartyData{citizen},
+				Beneficiary:               []models.SalePurchasePartyData{{Sequence: 1, BeneficiaryIsUnidentifiable: true, FTDescription: "Future grandchildren"}},
+				TrusteeBeneficiaryDetails: trustReasons,
+			},
+			wantVersion: "BSD-2018/ABSD-2021",
+			wantProfile: ABSDProfileEntity,
+			wantABSD:    350000,
+			wantTotal:   374600,
+		},
+		{
+			name: "trust for identifiable beneficiaries",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument:  "2024-01-10",
+				PurchasePrice:   1000000,
+				BuyerTransferee: []models.SalePurchasePartyData{foreigner},
+				HasIntentToHoldThePropInTrustForBeneficialOwner: true,
+				Trustee:                   []models.SalePurchasePartyData{foreigner},
+				Beneficiary:               []models.SalePurchasePartyData{{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDNo: "S1234567D", CountryOfNationality: "SG"}},
+				TrusteeBeneficiaryDetails: trustReasons,
+			},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfileCitizen,
+			wantTotal:   24600,
+		},
+		{
+			name: "incomplete trust",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument: "2024-01-10",
+				PurchasePrice:  1000000,
+				HasIntentToHoldThePropInTrustForBeneficialOwner: true,
+				Beneficiary:               []models.SalePurchasePartyData{{Sequence: 1, BeneficiaryIsUnidentifiable: true}, {Sequence: 2}},
+				TrusteeBeneficiaryDetails: models.TrusteeBeneficiaryData{Reasons: models.TrusteeReasonsData{IsReasonOthers: true}},
+			},
+			wantFields: []string{
+				"trustee",
+				"beneficiary[0].ftDescription",
+				"beneficiary[1].taxEntityIdNo",
+				"trusteeBeneficiaryDetails.reasons.ftReasonsOthersValue",
+			},
+		},
+		{
+			name: "trust rate driven by the highest-rated beneficiary",
+			req: models.SalePurchaseBuyersRequest{
+				DateOfDocument:  "2024-01-10",
+				PurchasePrice:   1000000,
+				BuyerTransferee: []models.SalePurchasePartyData{citizen},
+				HasIntentToHoldThePropInTrustForBeneficialOwner: true,
+				Trustee: []models.SalePurchasePartyData{citizen},
+				Beneficiary: []models.SalePurchasePartyData{
+					{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDNo: "S1234567D", CountryOfNationality: "SG"},
+					{Sequence: 2, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeFIN, TaxEntityIDNo: "F1234567N", CountryOfNationality: "MY"},
+				},
+				TrusteeBeneficiaryDetails: trustReasons,
+			},
+			wantVersion: "BSD-2023/ABSD-2023",
+			wantProfile: ABSDProfileForeigner,
+			wantABSD:    600000,
+			wantTotal:   624600,
+		},
+		{
+			name:       "missing nationality",
+			req:        models.SalePurchaseBuyersRequest{DateOfDocument: "2024-01-10", PurchasePrice: 1000000, BuyerTransferee: []models.SalePurchasePartyData{citizen, {Sequence: 2, TypeOfProfile: TypeOfProfileIndividual}}},
+			wantFields: []string{"buyerTransferee[1].countryOfNationality"},
+		},
+		{
+			name:       "invalid document date",
+			req:        models.SalePurchaseBuyersRequest{DateOfDocument: "10/01/2024", PurchasePrice: 1000000},
+			wantFields: []string{"dateOfDocument"},
+		},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := service.CalculateBuyersDuty(&tt.req)
+
+			if len(tt.wantFields) > 0 {
+				var fieldErrors DutyFieldErrors
+				if !errors.As(err, &fieldErrors) {
+					t.Fatalf("expected field errors, got %v", err)
+				}
+				if len(fieldErrors) != len(tt.wantFields) {
+					t.Fatalf("got field errors %v, want errors on %v", fieldErrors, tt.wantFields)
+				}
+				for i, field := range tt.wantFields {
+					if fieldErrors[i].Field != field {
+						t.Errorf("field error %d is on %s, want %s", i, fieldErrors[i].Field, field)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if breakdown.RateVersion != tt.wantVersion {
+				t.Errorf("rate version = %s, want %s", breakdown.RateVersion, tt.wantVersion)
+			}
+			if breakdown.TotalDuty != tt.wantTotal {
+				t.Errorf("total duty = %.2f, want %.2f", breakdown.TotalDuty, tt.wantTotal)
+			}
+
+			var absd *models.EStampDutyComponent
+			for i := range breakdown.Components {
+				if breakdown.Components[i].DutyType == "ABSD" {
+					absd = &breakdown.Components[i]
+				}
+			}
+			if tt.wantProfile == "" {
+				if absd != nil {
+					t.Errorf("unexpected ABSD component %+v", *absd)
+				}
+				return
+			}
+			if absd == nil {
+				t.Fatal("expected an ABSD component")
+			}
+			if absd.Profile != tt.wantProfile || absd.Duty != tt.wantABSD {
+				t.Errorf("ABSD %s %.2f, want %s %.2f", absd.Profile, absd.Duty, tt.wantProfile, tt.wantABSD)
+			}
+		})
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	tests := map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 112: "112th"}
+	for n, want := range tests {
+		if got := ordinal(n); got != want {
+			t.Errorf("ordinal(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestCalculateResidentialSSD(t *testing.T) {
+	tests := []struct {
+		name        string
+		acquired    string
+		disposed    string
+		wantVersion string
+		wantRate    float64
+	}{
+		{"before SSD", "2010-12-31", "2011-06-01", SSDRuleVersionNone, 0},
+		{"first year", "2020-01-15", "2020-12-01", "SSD-2017", 0.12},
+		{"on the first anniversary", "2020-01-15", "2021-01-15", "SSD-2017", 0.12},
+		{"day after the first anniversary", "2020-01-15", "2021-01-16", "SSD-2017", 0.08},
+		{"held past the last band", "2020-01-15", "2023-06-01", "SSD-2017", 0},
+		{"2011 regime fourth year", "2015-01-15", "2018-06-01", "SSD-2011", 0.04},
+		{"2025 regime", "2025-08-01", "2026-01-01", "SSD-2025", 0.16},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acquired, disposed := mustDate(t, tt.acquired), mustDate(t, tt.disposed)
+			result := service.CalculateResidentialSSD(1000000, acquired, disposed)
+
+			if result.RuleVersion != tt.wantVersion || result.Rate != tt.wantRate {
+				t.Errorf("got %s at %v, want %s at %v", result.RuleVersion, result.Rate, tt.wantVersion, tt.wantRate)
+			}
+			if want := math.Round(1000000 * tt.wantRate); result.Duty != want {
+				t.Errorf("duty = %.2f, want %.2f", result.Duty, want)
+			}
+		})
+	}
+}
+
+func TestCalculateIndustrialSSD(t *testing.T) {
+	tests := []struct {
+		name        string
+		acquired    string
+		disposed    string
+		wantVersion string
+		wantRate    float64
+	}{
+		{"before industrial SSD", "2013-01-11", "2013-06-01", SSDRuleVersionNone, 0},
+		{"first day of the regime", "2013-01-12", "2013-06-01", "ISSD-2013", 0.15},
+		{"on the first anniversary", "2013-01-12", "2014-01-12", "ISSD-2013", 0.15},
+		{"day after the first anniversary", "2013-01-12", "2014-01-13", "ISSD-2013", 0.10},
+		{"acquired on 29 Feb", "2016-02-29", "2017-02-28", "ISSD-2013", 0.15},
+		{"on the third anniversary", "2014-01-01", "2017-01-01", "ISSD-2013", 0.05},
+		{"held past the last band", "2014-01-01", "2017-01-02", "ISSD-2013", 0},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := service.CalculateIndustrialSSD(1000000, mustDate(t, tt.acquired), mustDate(t, tt.disposed))
+
+			if result.RuleVersion != tt.wantVersion || result.Rate != tt.wantRate {
+				t.Errorf("got %s at %v, want %s at %v", result.RuleVersion, result.Rate, tt.wantVersion, tt.wantRate)
+			}
+			if want := math.Round(1000000 * tt.wantRate); result.Duty != want {
+				t.Errorf("duty = %.2f, want %.2f", result.Duty, want)
+			}
+		})
+	}
+}
+
+func TestCalculateTenancyDuty(t *testing.T) {
+	monthly := func(start, end string, rent float64) models.AssessmentRentalData {
+		return models.AssessmentRentalData{
+			IsMonthlyRentPayable: true,
+			RentalDetails:        []models.RentalDetailData{{StartPeriodOfLease: start, EndPeriodOfLease: end, RentAmount: rent}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		rental      models.AssessmentRentalData
+		wantVersion string
+		wantAmount  float64
+		wantDuty    float64
+		wantFields  []string
+	}{
+		{
+			name:        "two-year lease",
+			rental:      monthly("2024-01-01", "2025-12-31", 3000),
+			wantVersion: "LEASE-2014",
+			wantAmount:  72000,
+			wantDuty:    288,
+		},
+		{
+			name:        "part-year lease",
+			rental:      monthly("2024-01-01", "2024-06-30", 3000),
+			wantVersion: "LEASE-2014",
+			wantAmount:  18000,
+			wantDuty:    72,
+		},
+		{
+			name:        "lease longer than four years charged on four years of rent",
+			rental:      monthly("2024-01-01", "2028-12-31", 3000),
+			wantVersion: "LEASE-2014",
+			wantAmount:  144000,
+			wantDuty:    576,
+		},
+		{
+			name: "market rent above the rent paid",
+			rental: models.AssessmentRentalData{
+				IsMonthlyRentPayable: true,
+				RentalDetails:        []models.RentalDetailData{{StartPeriodOfLease: "2024-01-01", EndPeriodOfLease: "2024-12-31", RentAmount: 2000, MarketRentalValue: 3000}},
+			},
+			wantVersion: "LEASE-2014",
+			wantAmount:  36000,
+			wantDuty:    144,
+		},
+		{
+			name: "stepped rent",
+			rental: models.AssessmentRentalData{
+				IsMonthlyRentPayable: true,
+				RentalDetails: []models.RentalDetailData{
+					{StartPeriodOfLease: "2024-01-01", EndPeriodOfLease: "2024-12-31", RentAmount: 3000},
+					{StartPeriodOfLease: "2025-01-01", EndPeriodOfLease: "2025-12-31", RentAmount: 4000},
+				},
+			},
+			wantVersion: "LEASE-2014",
+			wantAmount:  84000,
+			wantDuty:    336,
+		},
+		{
+			name:        "average annual rent of 1000 exempt",
+			rental:      models.AssessmentRentalData{RentalDetails: []models.RentalDetailData{{StartPeriodOfLease: "2024-01-01", EndPeriodOfLease: "2024-12-31", RentAmount: 1000}}},
+			wantVersion: "LEASE-2014",
+			wantAmount:  1000,
+			wantDuty:    0,
+		},
+		{
+			name:        "average annual rent just above the exemption",
+			rental:      models.AssessmentRentalData{RentalDetails: []models.RentalDetailData{{StartPeriodOfLease: "2024-01-01", EndPeriodOfLease: "2024-12-31", RentAmount: 1250}}},
+			wantVersion: "LEASE-2014",
+			wantAmount:  1250,
+			wantDuty:    5,
+		},
+		{
+			name:        "totals without rental details",
+			rental:      models.AssessmentRentalData{TotalGrossRentAmount: 50000, AverageRentAmount: 25000},
+			wantVersion: "LEASE-2014",
+			wantAmount:  50000,
+			wantDuty:    200,
+		},
+		{
+			name: "premium charged at BSD rates",
+			rental: models.AssessmentRentalData{
+				IsPremiumConsiderationMade: true,
+				PremiumConsiderationAmount: 500000,
+				IsMonthlyRentPayable:       true,
+				RentalDetails:              []models.RentalDetailData{{StartPeriodOfLease: "2024-01-01", EndPeriodOfLease: "2024-12-31", RentAmount: 3000}},
+			},
+			wantVersion: "LEASE-2014/BSD-2023",
+			wantAmount:  536000,
+			wantDuty:    9744,
+		},
+		{
+			name: "lease ends before it starts",
+			rental: models.AssessmentRentalData{
+				RentalDetails: []models.RentalDetailData{{StartPeriodOfLease: "2024-01-01", EndPeriodOfLease: "2023-12-31", RentAmount: 3000}},
+			},
+			wantFields: []string{"assessmentRental[0].rentalDetails[0].endPeriodOfLease"},
+		},
+		{
+			name: "invalid lease date",
+			rental: models.AssessmentRentalData{
+				RentalDetails: []models.RentalDetailData{{StartPeriodOfLease: "01/01/2024", EndPeriodOfLease: "2024-12-31", RentAmount: 3000}},
+			},
+			wantFields: []string{"assessmentRental[0].rentalDetails[0].startPeriodOfLease"},
+		},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.StampTenancyAgreementRequest{
+				DateOfDocument:   "2024-01-10",
+				AssessmentRental: []models.AssessmentRentalData{tt.rental},
+			}
+			breakdown, err := service.CalculateTenancyDuty(req)
+
+			if len(tt.wantFields) > 0 {
+				var fieldErrors DutyFieldErrors
+				if !errors.As(err, &fieldErrors) {
+					t.Fatalf("expected field errors, got %v", err)
+				}
+				if len(fieldErrors) != len(tt.wantFields) || fieldErrors[0].Field != tt.wantFields[0] {
+					t.Fatalf("got field errors %v, want errors on %v", fieldErrors, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if breakdown.RateVersion != tt.wantVersion {
+				t.Errorf("rate version = %s, want %s", breakdown.RateVersion, tt.wantVersion)
+			}
+			if breakdown.DutiableAmount != tt.wantAmount {
+				t.Errorf("dutiable amount = %.2f, want %.2f", breakdown.DutiableAmount, tt.wantAmount)
+			}
+			if breakdown.TotalDuty != tt.wantDuty {
+				t.Errorf("total duty = %.2f, want %.2f", breakdown.TotalDuty, tt.wantDuty)
+			}
+		})
+	}
+}
+
+func TestApplyLatePenalty(t *testing.T) {
+	tests := []struct {
+		name              string
+		documentDate      string
+		signedInSingapore bool
+		receivingDate     string
+		submitted         string
+		duty              float64
+		wantDeadline      string
+		wantPaymentDue    string
+		wantTier          string
+		wantPenalty       float64
+		wantErr           bool
+	}{
+		{"stamped on time", "2024-01-01", true, "", "2024-01-10", 100, "2024-01-15", "2024-01-24", "", 0, false},
+		{"up to three months late", "2024-01-01", true, "", "2024-02-15", 100, "2024-01-15", "2024-02-29", "UP_TO_3_MONTHS", 100, false},
+		{"minimum penalty", "2024-01-01", true, "", "2024-02-15", 5, "2024-01-15", "2024-02-29", "UP_TO_3_MONTHS", 10, false},
+		{"over three months late", "2024-01-01", true, "", "2024-06-01", 100, "2024-01-15", "2024-06-15", "OVER_3_MONTHS", 400, false},
+		{"late with no duty", "2024-01-01", true, "", "2024-06-01", 0, "2024-01-15", "2024-06-15", "", 0, false},
+		{"signed overseas", "2024-01-01", false, "2024-02-01", "2024-02-20", 100, "2024-03-02", "2024-03-05", "", 0, false},
+		{"signed overseas without a receiving date", "2024-01-01", false, "", "2024-01-20", 100, "2024-01-31", "2024-02-03", "", 0, false},
+		{"received before it is dated", "2024-01-01", false, "2023-12-01", "2024-01-10", 100, "", "", "", 0, true},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := &models.EStampDutyBreakdown{DocumentDate: tt.documentDate, TotalDuty: tt.duty}
+
+			paymentDue, err := service.ApplyLatePenalty(breakdown, tt.signedInSingapore, tt.receivingDate, mustDate(t, tt.submitted))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if breakdown.Penalty.StampingDeadline != tt.wantDeadline {
+				t.Errorf("stamping deadline = %s, want %s", breakdown.Penalty.StampingDeadline, tt.wantDeadline)
+			}
+			if got := paymentDue.Format("2006-01-02"); got != tt.wantPaymentDue {
+				t.Errorf("payment due = %s, want %s", got, tt.wantPaymentDue)
+			}
+			if breakdown.Penalty.Tier != tt.wantTier || breakdown.Penalty.Amount != tt.wantPenalty {
+				t.Errorf("penalty = %s %.2f, want %s %.2f", breakdown.Penalty.Tier, breakdown.Penalty.Amount, tt.wantTier, tt.wantPenalty)
+			}
+		})
+	}
+}
+
+func TestCalculateShareTransferDuty(t *testing.T) {
+	tests := []struct {
+		name          string
+		consideration float64
+		company       models.TargetCompanyData
+		wantVersion   string
+		wantAmount    float64
+		wantTotal     float64
+		wantFields    []string
+	}{
+		{
+			name:          "net asset value above the consideration",
+			consideration: 100000,
+			company:       models.TargetCompanyData{HasOneClassOfShares: true, NetAssetValue: 10000000, NoOfSharesTransferred: 1000, TotalIssuedShares: 10000},
+			wantVersion:   "SHARE-2014",
+			wantAmount:    1000000,
+			wantTotal:     2000,
+		},
+		{
+			name:          "consideration above the net asset value",
+			consideration: 2000000,
+			company:       models.TargetCompanyData{HasOneClassOfShares: true, NetAssetValue: 10000000, NoOfSharesTransferred: 1000, TotalIssuedShares: 10000},
+			wantVersion:   "SHARE-2014",
+			wantAmount:    2000000,
+			wantTotal:     4000,
+		},
+		{
+			name:          "more than one class of shares",
+			consideration: 1000000,
+			company:       models.TargetCompanyData{NetAssetValue: 10000000, NetAssetValueOfSharesTransferred: 3000000},
+			wantVersion:   "SHARE-2014",
+			wantAmount:    3000000,
+			wantTotal:     6000,
+		},
+		{
+			name:        "market price when there is no consideration or net asset value",
+			company:     models.TargetCompanyData{HasOneClassOfShares: true, TotalMarketPrice: 50000},
+			wantVersion: "SHARE-2014",
+			wantAmount:  50000,
+			wantTotal:   100,
+		},
+		{
+			name:          "minimum duty",
+			consideration: 100,
+			company:       models.TargetCompanyData{HasOneClassOfShares: true},
+			wantVersion:   "SHARE-2014",
+			wantAmount:    100,
+			wantTotal:     1,
+		},
+		{
+			name: "shares of a property-holding entity",
+			company: models.TargetCompanyData{
+				HasOneClassOfShares:      true,
+				NetAssetValue:            8000000,
+				NoOfSharesTransferred:    6000,
+				TotalIssuedShares:        10000,
+				ResidentialPropertyValue: 6000000,
+				TotalTangibleAssets:      10000000,
+				TransfereeEquityInterest: 0.6,
+			},
+			wantVersion: "ABSD-2023/ACD-2017/BSD-2023/SHARE-2014",
+			wantAmount:  4800000,
+			wantTotal:   9600 + 155600 + 2340000,
+		},
+		{
+			name:       "net asset value of the shares transferred missing",
+			company:    models.TargetCompanyData{NetAssetValue: 10000000},
+			wantFields: []string{"targetCompany.netAssetValueOfSharesTransferred"},
+		},
+		{
+			name:       "total issued shares missing",
+			company:    models.TargetCompanyData{HasOneClassOfShares: true, NetAssetValue: 10000000, NoOfSharesTransferred: 1000},
+			wantFields: []string{"targetCompany.totalIssuedShares"},
+		},
+		{
+			name:       "more shares transferred than issued",
+			company:    models.TargetCompanyData{HasOneClassOfShares: true, NetAssetValue: 10000000, NoOfSharesTransferred: 20000, TotalIssuedShares: 10000},
+			wantFields: []string{"targetCompany.noOfSharesTransferred"},
+		},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.ShareTransferRequest{DateOfDocument: "2024-01-10", ConsiderationAmount: tt.consideration, TargetCompany: tt.company}
+			breakdown, err := service.CalculateShareTransferDuty(req)
+
+			if len(tt.wantFields) > 0 {
+				var fieldErrors DutyFieldErrors
+				if !errors.As(err, &fieldErrors) {
+					t.Fatalf("expected field errors, got %v", err)
+				}
+				if len(fieldErrors) != len(tt.wantFields) || fieldErrors[0].Field != tt.wantFields[0] {
+					t.Fatalf("got field errors %v, want errors on %v", fieldErrors, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if breakdown.RateVersion != tt.wantVersion {
+				t.Errorf("rate version = %s, want %s", breakdown.RateVersion, tt.wantVersion)
+			}
+			if breakdown.DutiableAmount != tt.wantAmount {
+				t.Errorf("dutiable amount = %.2f, want %.2f", breakdown.DutiableAmount, tt.wantAmount)
+			}
+			if breakdown.TotalDuty != tt.wantTotal {
+				t.Errorf("total duty = %.2f, want %.2f", breakdown.TotalDuty, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestAdditionalConveyanceDuty(t *testing.T) {
+	holding := models.TargetCompanyData{ResidentialPropertyValue: 6000000, TotalTangibleAssets: 10000000}
+
+	tests := []struct {
+		name     string
+		date     string
+		company  func(company *models.TargetCompanyData)
+		fraction float64
+		wantDuty []float64
+		wantErr  bool
+	}{
+		{
+			name:     "buyer's ACD at BSD and entity ABSD rates",
+			date:     "2024-01-10",
+			company:  func(company *models.TargetCompanyData) { company.TransfereeEquityInterest = 0.6 },
+			fraction: 0.6,
+			wantDuty: []float64{155600, 2340000},
+		},
+		{
+			name: "seller's ACD within three years",
+			date: "2024-01-10",
+			company: func(company *models.TargetCompanyData) {
+				company.TransferorEquityInterest = 0.5
+				company.TransferorDateOfAcquisition = "2022-06-01"
+			},
+			fraction: 0.6,
+			wantDuty: []float64{432000},
+		},
+		{
+			name: "seller's ACD after three years",
+			date: "2024-01-10",
+			company: func(company *models.TargetCompanyData) {
+				company.TransferorEquityInterest = 0.5
+				company.TransferorDateOfAcquisition = "2021-01-10"
+			},
+			fraction: 0.6,
+		},
+		{
+			name:     "not a significant owner",
+			date:     "2024-01-10",
+			company:  func(company *models.TargetCompanyData) { company.TransfereeEquityInterest = 0.49 },
+			fraction: 0.49,
+		},
+		{
+			name: "not a property-holding entity",
+			date: "2024-01-10",
+			company: func(company *models.TargetCompanyData) {
+				company.ResidentialPropertyValue, company.TransfereeEquityInterest = 4000000, 1
+			},
+			fraction: 1,
+		},
+		{
+			name:     "before ACD",
+			date:     "2017-03-10",
+			company:  func(company *models.TargetCompanyData) { company.TransfereeEquityInterest = 1 },
+			fraction: 1,
+		},
+		{
+			name: "invalid acquisition date",
+			date: "2024-01-10",
+			company: func(company *models.TargetCompanyData) {
+				company.TransferorEquityInterest = 0.5
+				company.TransferorDateOfAcquisition = "01/06/2022"
+			},
+			fraction: 0.6,
+			wantErr:  true,
+		},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			company := holding
+			tt.company(&company)
+
+			components, _, err := service.additionalConveyanceDuty(mustDate(t, tt.date), company, tt.fraction)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(components) != len(tt.wantDuty) {
+				t.Fatalf("got %d components, want %d: %+v", len(components), len(tt.wantDuty), components)
+			}
+			for i, want := range tt.wantDuty {
+				if components[i].DutyType != "ACD" || components[i].Duty != want {
+					t.Errorf("component %d = %s %.2f, want ACD %.2f", i, components[i].DutyType, components[i].Duty, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateMortgageDuty(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        models.StampMortgageRequest
+		wantDuty   float64
+		wantFields []string
+	}{
+		{"legal mortgage", models.StampMortgageRequest{AmountOfLoan: 100000}, 400, nil},
+		{"minimum duty", models.StampMortgageRequest{AmountOfLoan: 1000}, 5, nil},
+		{"duty capped", models.StampMortgageRequest{AmountOfLoan: 1000000}, 500, nil},
+		{"equitable mortgage", models.StampMortgageRequest{TypeOfMortgage: MortgageTypeEquitable, AmountOfLoan: 100000}, 400, nil},
+		{"equitable mortgage capped", models.StampMortgageRequest{TypeOfMortgage: MortgageTypeEquitable, AmountOfLoan: 1000000}, 500, nil},
+		{"unknown type", models.StampMortgageRequest{TypeOfMortgage: 9, AmountOfLoan: 100000}, 0, []string{"typeOfMortgage"}},
+		{"no loan", models.StampMortgageRequest{}, 0, []string{"amountOfLoan"}},
+		{"stocks and shares not listed", models.StampMortgageRequest{TypeOfMortgage: MortgageTypeStockShare, AmountOfLoan: 100000}, 0, []string{"assets.stocksShares"}},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.DateOfDocument = "2024-01-10"
+			breakdown, err := service.CalculateMortgageDuty(&tt.req)
+
+			if len(tt.wantFields) > 0 {
+				var fieldErrors DutyFieldErrors
+				if !errors.As(err, &fieldErrors) {
+					t.Fatalf("expected field errors, got %v", err)
+				}
+				if len(fieldErrors) != len(tt.wantFields) || fieldErrors[0].Field != tt.wantFields[0] {
+					t.Fatalf("got field errors %v, want errors on %v", fieldErrors, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if breakdown.TotalDuty != tt.wantDuty {
+				t.Errorf("duty = %.2f, want %.2f", breakdown.TotalDuty, tt.wantDuty)
+			}
+			if len(breakdown.Components) != 1 || breakdown.Components[0].Duty != tt.wantDuty {
+				t.Errorf("unexpected components %+v", breakdown.Components)
+			}
+		})
+	}
+}
+
+func TestCalculateMortgageDutyAllocation(t *testing.T) {
+	req := &models.StampMortgageRequest{
+		DateOfDocument: "2024-01-10",
+		AmountOfLoan:   100000,
+		Assets: models.MortgageAssetsData{
+			Properties: []models.PropertyData{{Sequence: 1, BuyingPriceMarketValueResidential: 200000}, {Sequence: 2, ValuationValue: 100000}},
+			Lands:      []models.LandData{{Sequence: 1, BuyingPriceMarketValueNonResidential: 100000}},
+		},
+	}
+
+	breakdown, err := NewStampDutyService("").CalculateMortgageDuty(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		assetType string
+		loan      float64
+		duty      float64
+	}{
+		{AssetTypeProperty, 50000, 200},
+		{AssetTypeProperty, 25000, 100},
+		{AssetTypeLand, 25000, 100},
+	}
+	if len(breakdown.Components) != len(want) {
+		t.Fatalf("got %d components, want %d", len(breakdown.Components), len(want))
+	}
+	for i, w := range want {
+		component := breakdown.Components[i]
+		if component.AssetType != w.assetType || component.DutiableAmount != w.loan || component.Duty != w.duty {
+			t.Errorf("component %d = %s %.2f/%.2f, want %s %.2f/%.2f", i, component.AssetType, component.DutiableAmount, component.Duty, w.assetType, w.loan, w.duty)
+		}
+	}
+}
+
+func TestTransferredFraction(t *testing.T) {
+	shares := func(pairs ...int) []models.PropertyOwnershipData {
+		var ownerships []models.PropertyOwnershipData
+		for i := 0; i < len(pairs); i += 2 {
+			ownerships = append(ownerships, models.PropertyOwnershipData{Sequence: i/2 + 1, ShareNumerator: pairs[i], ShareDenominator: pairs[i+1]})
+		}
+		return ownerships
+	}
+
+	tests := []struct {
+		name      string
+		property  models.PropertyData
+		want      float64
+		wantField string
+	}{
+		{"whole by default", models.PropertyData{}, 1, ""},
+		{"stated fraction", models.PropertyData{ShareOfPropertyTransferred: ShareTransferredPart, FractionNumerator: 1, FractionDenominator: 2}, 0.5, ""},
+		{"tenants in common without a stated fraction", models.PropertyData{MannerOfHolding: MannerOfHoldingTenancyInCommon, ShareOfPropertyTransferred: ShareTransferredPart, PropertyOwnerships: shares(1, 4, 1, 4)}, 0.5, ""},
+		{"tenants in common matching the stated fraction", models.PropertyData{MannerOfHolding: MannerOfHoldingTenancyInCommon, FractionNumerator: 1, FractionDenominator: 2, PropertyOwnerships: shares(1, 4, 1, 4)}, 0.5, ""},
+		{"tenants in common making up the whole", models.PropertyData{MannerOfHolding: MannerOfHoldingTenancyInCommon, ShareOfPropertyTransferred: ShareTransferredWhole, PropertyOwnerships: shares(1, 3, 1, 3, 1, 3)}, 1, ""},
+		{"joint tenants in equal shares", models.PropertyData{MannerOfHolding: MannerOfHoldingJointTenancy, ShareOfPropertyTransferred: ShareTransferredWhole, PropertyOwnerships: shares(1, 2, 1, 2)}, 1, ""},
+		{"part without a fraction", models.PropertyData{ShareOfPropertyTransferred: ShareTransferredPart}, 0, "assets.properties[0].fractionNumerator"},
+		{"whole with a fraction below 1", models.PropertyData{ShareOfPropertyTransferred: ShareTransferredWhole, FractionNumerator: 1, FractionDenominator: 2}, 0, "assets.properties[0].fractionNumerator"},
+		{"fraction above 1", models.PropertyData{FractionNumerator: 3, FractionDenominator: 2}, 0, "assets.properties[0].fractionNumerator"},
+		{"fraction without a denominator", models.PropertyData{FractionNumerator: 1}, 0, "assets.properties[0].fractionNumerator"},
+		{"tenants in common not matching the stated fraction", models.PropertyData{MannerOfHolding: MannerOfHoldingTenancyInCommon, FractionNumerator: 1, FractionDenominator: 3, PropertyOwnerships: shares(1, 4, 1, 4)}, 0, "assets.properties[0].propertyOwnerships"},
+		{"joint tenants in unequal shares", models.PropertyData{MannerOfHolding: MannerOfHoldingJointTenancy, PropertyOwnerships: shares(1, 2, 1, 4)}, 0, "assets.properties[0].propertyOwnerships"},
+		{"shares above the whole", models.PropertyData{MannerOfHolding: MannerOfHoldingTenancyInCommon, PropertyOwnerships: shares(3, 4, 1, 2)}, 0, "assets.properties[0].propertyOwnerships"},
+		{"empty share", models.PropertyData{MannerOfHolding: MannerOfHoldingTenancyInCommon, PropertyOwnerships: shares(0, 2)}, 0, "assets.properties[0].propertyOwnerships[0].shareNumerator"},
+		{"unknown manner of holding", models.PropertyData{MannerOfHolding: 9}, 0, "assets.properties[0].mannerOfHolding"},
+	}
+
+	service := NewStampDutyService("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fraction, fieldErrors := service.propertyFraction("assets.properties[0]", tt.property)
+			if tt.wantField != "" {
+				if len(fieldErrors) != 1 || fieldErrors[0].Field != tt.wantField {
+					t.Fatalf("got field errors %v, want an error on %s", fieldErrors, tt.wantField)
+				}
+				return
+			}
+			if len(fieldErrors) > 0 {
+				t.Fatalf("unexpected field errors: %v", fieldErrors)
+			}
+			if math.Abs(fraction-tt.want) > fractionTolerance {
+				t.Errorf("fraction = %v, want %v", fraction, tt.want)
+			}
+		})
+	}
+}
+
+func TestLandFraction(t *testing.T) {
+	service := NewStampDutyService("")
+
+	land := models.LandData{
+		Sequence:        1,
+		MannerOfHolding: MannerOfHoldingTenancyInCommon,
+		LandOwnership:   []models.LandOwnershipData{{Sequence: 1, ShareNumerator: 1, ShareDenominator: 3}},
+	}
+	if fraction, fieldErrors := service.landFraction("assets.lands[0]", land); len(fieldErrors) > 0 || math.Abs(fraction-1.0/3) > fractionTolerance {
+		t.Errorf("landFraction = %v, %v, want 1/3", fraction, fieldErrors)
+	}
+
+	land.LandOwnership = append(land.LandOwnership, models.LandOwnershipData{Sequence: 2, ShareNumerator: 3, ShareDenominator: 4})
+	if _, fieldErrors := service.landFraction("assets.lands[0]", land); len(fieldErrors) != 1 || fieldErrors[0].Field != "assets.lands[0].landOwnership" {
+		t.Errorf("got field errors %v, want an error on assets.lands[0].landOwnership", fieldErrors)
+	}
+}
+
+func TestCalculateBuyersDutyOnShareTransferred(t *testing.T) {
+	req := &models.SalePurchaseBuyersRequest{
+		DateOfDocument: "2024-01-10",
+		PurchasePrice:  2000000,
+		BuyerTransferee: []models.SalePurchasePartyData{
+			{Sequence: 1, TypeOfProfile: TypeOfProfileIndividual, TaxEntityIDType: TaxEntityIDTypeFIN, CountryOfNationality: "MY"},
+		},
+		Assets: models.SalePurchaseAssetsData{
+			Properties: []models.PropertyData{{
+				Sequence:                          1,
+				PropertyType:                      PropertyTypeResidential,
+				BuyingPriceMarketValueResidential: 2000000,
+				ShareOfPropertyTransferred:        ShareTransferredPart,
+				FractionNumerator:                 1,
+				FractionDenominator:               2,
+			}},
+		},
+	}
+
+	breakdown, err := NewStampDutyService("").CalculateBuyersDuty(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if breakdown.DutiableAmount != 1000000 {
+		t.Errorf("dutiable amount = %.2f, want 1000000", breakdown.DutiableAmount)
+	}
+	if breakdown.TotalDuty != 24600+600000 {
+		t.Errorf("total duty = %.2f, want %.2f", breakdown.TotalDuty, 24600.0+600000)
+	}
+
+	req.Assets.Properties[0].FractionNumerator = 0
+	var fieldErrors DutyFieldErrors
+	if _, err := NewStampDutyService("").CalculateBuyersDuty(req); !errors.As(err, &fieldErrors) || fieldErrors[0].Field != "assets.properties[0].fractionNumerator" {
+		t.Errorf("expected a fraction error, got %v", err)
+	}
+}
+
+func TestApportionValue(t *testing.T) {
+	tests := []struct {
+		name                        string
+		residential, nonResidential float64
+		valuation                   float64
+		defaultResidential          bool
+		wantResidential, wantNonRes float64
+	}{
+		{"split by price", 600000, 400000, 0, true, 600000, 400000},
+		{"valuation above the price", 600000, 400000, 1500000, true, 900000, 600000},
+		{"valuation below the price", 600000, 400000, 500000, true, 600000, 400000},
+		{"residential price only", 800000, 0, 1000000, false, 1000000, 0},
+		{"no prices on a residential asset", 0, 0, 1000000, true, 1000000, 0},
+		{"no prices on a non-residential asset", 0, 0, 1000000, false, 0, 1000000},
+		{"split rounded to cents", 100, 200, 1000, true, 333.33, 666.67},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			residential, nonResidential := apportionValue(tt.residential, tt.nonResidential, tt.valuation, tt.defaultResidential)
+			if residential != tt.wantResidential || math.Abs(nonResidential-tt.wantNonRes) > 0.001 {
+				t.Errorf("apportionValue = %.2f/%.2f, want %.2f/%.2f", residential, nonResidential, tt.wantResidential, tt.wantNonRes)
+			}
+		})
+	}
+}
+
+func TestLandSchedule(t *testing.T) {
+	tests := map[int]string{
+		0:                                        ScheduleResidential,
+		MasterPlanZoningResidential:              ScheduleResidential,
+		MasterPlanZoningCommercialAndResidential: ScheduleResidential,
+		MasterPlanZoningResidentialWithCommercialAtFirst: ScheduleResidential,
+		MasterPlanZoningCommercial:                       ScheduleNonResidential,
+		MasterPlanZoningBusiness:                         ScheduleIndustrial,
+		MasterPlanZoningOthers:                           ScheduleNonResidential,
+	}
+	for zoning, want := range tests {
+		if got := landSchedule(zoning); got != want {
+			t.Errorf("landSchedule(%d) = %s, want %s", zoning, got, want)
+		}
+	}
+}
+
+func TestCalculateBuyersDutyOnMixedUseProperty(t *testing.T) {
+	req := &models.SalePurchaseBuyersRequest{
+		DateOfDocument: "2024-01-10",
+		PurchasePrice:  1000000,
+		BuyerTransferee: []models.SalePurchasePartyData{
+			{Sequence: 1, TypeOfProfile: TypeOfProfileEntity},
+		},
+		Assets: models.SalePurchaseAssetsData{
+			Properties: []models.PropertyData{{
+				Sequence:                             1,
+				PropertyType:                         PropertyTypeResidential,
+				BuyingPriceMarketValueResidential:    600000,
+				BuyingPriceMarketValueNonResidential: 400000,
+			}},
+		},
+	}
+
+	breakdown, err := NewStampDutyService("").CalculateBuyersDuty(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		dutyType string
+		schedule string
+		amount   float64
+		duty     float64
+	}{
+		{"BSD", ScheduleResidential, 600000, 12600},
+		{"BSD", ScheduleNonResidential, 400000, 6600},
+		{"ABSD", ScheduleResidential, 600000, 390000},
+	}
+	if len(breakdown.Components) != len(want) {
+		t.Fatalf("got %d components, want %d: %+v", len(breakdown.Components), len(want), breakdown.Components)
+	}
+	for i, w := range want {
+		component := breakdown.Components[i]
+		if component.DutyType != w.dutyType || component.Schedule != w.schedule || component.DutiableAmount != w.amount || component.Duty != w.duty {
+			t.Errorf("component %d = %s %s %.2f/%.2f, want %s %s %.2f/%.2f", i, component.DutyType, component.Schedule, component.DutiableAmount, component.Duty, w.dutyType, w.schedule, w.amount, w.duty)
+		}
+	}
+	if breakdown.Components[0].Remarks == "" || breakdown.Components[1].Remarks == "" {
+		t.Error("mixed-use parts should be listed in the remarks")
+	}
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page geometry in PDF points
+const (
+	pdfPageWidth   = 595.0
+	pdfPageHeight  = 842.0
+	pdfMargin      = 50.0
+	pdfLineHeight  = 14.0
+	pdfMaxLineChar = 95
+)
+
+// PDFDocument builds a simple text-only PDF using the standard Helvetica
+// fonts. Content streams are left uncompressed so the text can be read back
+// from the generated file.
+type PDFDocument struct {
+	infoKeys   []string
+	infoValues map[string]string
+	pages      []*bytes.Buffer
+	y          float64
+}
+
+// NewPDFDocument creates an empty PDF document
+func NewPDFDocument() *PDFDocument {
+	return &PDFDocument{infoValues: make(map[string]string)}
+}
+
+// SetInfo sets an entry in the document information dictionary
+func (d *PDFDocument) SetInfo(key, value string) {
+	if _, ok := d.infoValues[key]; !ok {
+		d.infoKeys = append(d.infoKeys, key)
+	}
+	d.infoValues[key] = value
+}
+
+// Title writes a large bold line
+func (d *PDFDocument) Title(text string) {
+	d.writeLine("F2", 16, text)
+	d.Space()
+}
+
+// Heading writes a bold section heading
+func (d *PDFDocument) Heading(text string) {
+	d.Space()
+	d.writeLine("F2", 12, text)
+}
+
+// Text writes a line of body text, wrapping long lines
+func (d *PDFDocument) Text(text string) {
+	for _, line := range wrapPDFText(text, pdfMaxLineChar) {
+		d.writeLine("F1", 10, line)
+	}
+}
+
+// Field writes a "label: value" line of body text
+func (d *PDFDocument) Field(label, value string) {
+	d.Text(label + ": " + value)
+}
+
+// Space leaves a blank line
+func (d *PDFDocument) Space() {
+	d.y -= pdfLineHeight / 2
+}
+
+// Bytes renders the document
+func (d *PDFDocument) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	writeObject := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// Objects 1-5 are the catalog, page tree, fonts and information
+	// dictionary; each page then takes a page object and a content stream
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	var info strings.Builder
+	info.WriteString("<<")
+	for _, key := range d.infoKeys {
+		fmt.Fprintf(&info, " /%s (%s)", key, escapePDFText(d.infoValues[key]))
+	}
+	info.WriteString(" >>")
+	writeObject(info.String())
+
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+i*2))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	return out.Bytes()
+}
+
+func (d *PDFDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+func (d *PDFDocument) writeLine(font string, size float64, text string) {
+	if len(d.pages) == 0 || d.y-pdfLineHeight < pdfMargin {
+		d.newPage()
+	}
+	d.y -= pdfLineHeight
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.0f Tf %.0f %.0f Td (%s) Tj ET\n", font, size, pdfMargin, d.y, escapePDFText(text))
+}
+
+// escapePDFText escapes a PDF literal string, replacing characters outside
+// printable ASCII
+func escapePDFText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// wrapPDFText splits text into lines of at most width characters, breaking
+// at spaces where possible
+func wrapPDFText(text string, width int) []string {
+	var lines []string
+	for len(text) > width {
+		cut := strings.LastIndex(text[:width], " ")
+		if cut <= 0 {
+			cut = width
+		}
+		lines = append(lines, text[:cut])
+		text = strings.TrimLeft(text[cut:], " ")
+	}
+	return append(lines, text)
+}
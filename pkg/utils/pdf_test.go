@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEscapePDFText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Stamp Certificate", "Stamp Certificate"},
+		{"parentheses", "Buyer (joint)", `Buyer \(joint\)`},
+		{"backslash", `a\b`, `a\\b`},
+		{"control characters", "line\nbreak\t", "line?break?"},
+		{"non-ASCII", "Café", "Caf?"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapePDFText(tt.text); got != tt.want {
+				t.Errorf("escapePDFText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapPDFText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "short line", 20, []string{"short line"}},
+		{"exact width", "0123456789", 10, []string{"0123456789"}},
+		{"breaks at space", "the quick brown fox", 10, []string{"the quick", "brown fox"}},
+		{"drops the space at the break", "aaaa bbbb", 6, []string{"aaaa", "bbbb"}},
+		{"no spaces", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"empty", "", 10, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapPDFText(tt.text, tt.width)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("wrapPDFText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+			for _, line := range got {
+				if len(line) > tt.width {
+					t.Errorf("line %q is longer than %d characters", line, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestPDFDocumentBytes(t *testing.T) {
+	doc := NewPDFDocument()
+	doc.SetInfo("Title", "Stamp Certificate")
+	doc.SetInfo("DocRefNo", "SP123456789012")
+	doc.SetInfo("DocRefNo", "SP210987654321")
+	doc.Title("Stamp Certificate")
+	doc.Field("Document Reference No", "SP210987654321")
+	doc.Text("Payer (Buyer)")
+
+	pdf := doc.Bytes()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Errorf("missing PDF header")
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Errorf("missing PDF trailer")
+	}
+	for _, want := range []string{
+		"/Title (Stamp Certificate) /DocRefNo (SP210987654321)",
+		"(Document Reference No: SP210987654321) Tj",
+		`(Payer \(Buyer\)) Tj`,
+		"/Count 1",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF does not contain %q", want)
+		}
+	}
+	if bytes.Contains(pdf, []byte("SP123456789012")) {
+		t.Errorf("PDF still contains the replaced information entry")
+	}
+
+	checkPDFCrossReference(t, pdf)
+}
+
+func TestPDFDocumentPages(t *testing.T) {
+	empty := NewPDFDocument().Bytes()
+	if !bytes.Contains(empty, []byte("/Count 1")) {
+		t.Errorf("an empty document should render a single blank page")
+	}
+	checkPDFCrossReference(t, empty)
+
+	doc := NewPDFDocument()
+	for i := 0; i < 120; i++ {
+		doc.Text(fmt.Sprintf("Line %d", i))
+	}
+	pdf := doc.Bytes()
+
+	// 120 lines of 14pt on an A4 page with 50pt margins need three pages
+	if !bytes.Contains(pdf, []byte("/Count 3")) {
+		t.Errorf("expected the text to run onto three pages")
+	}
+	if !bytes.Contains(pdf, []byte("(Line 119) Tj")) {
+		t.Errorf("last line is missing")
+	}
+	checkPDFCrossReference(t, pdf)
+}
+
+// checkPDFCrossReference checks that every cross-reference entry points at
+// the start of its object and that startxref points at the table
+func checkPDFCrossReference(t *testing.T, pdf []byte) {
+	t.Helper()
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if match == nil {
+		t.Fatal("missing startxref")
+	}
+	xrefOffset, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(pdf[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the cross-reference table", xrefOffset)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(pdf[xrefOffset:], -1)
+	if len(entries) == 0 {
+		t.Fatal("cross-reference table has no entries")
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("cross-reference entry %d points at %q, want %q", i+1, pdf[offset:offset+len(want)], want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Singapore tax entity identifiers. NRIC/FIN and UEN carry a check
+// character that is verified here; GST registration numbers issued outside
+// the UEN scheme and property tax references have no published check
+// character, so only their format is checked.
+
+var (
+	nricPattern           = regexp.MustCompile(`^[STFGM]\d{7}[A-Z]$`)
+	businessUENPattern    = regexp.MustCompile(`^\d{8}[A-Z]$`)
+	localCompanyPattern   = regexp.MustCompile(`^\d{9}[A-Z]$`)
+	otherUENPattern       = regexp.MustCompile(`^[RST]\d{2}[A-Z]{2}\d{4}[A-Z]$`)
+	gstNumberPattern      = regexp.MustCompile(`^M[0-9A-Z]\d{7}[0-9A-Z]$|^M[0-9A-Z]-\d{7}-[0-9A-Z]$`)
+	propertyTaxRefPattern = regexp.MustCompile(`^\d{7}[A-Z]$|^\d{10}[A-Z]$`)
+
+	nricWeights = []int{2, 7, 6, 5, 4, 3, 2}
+
+	// Check letters indexed by the checksum remainder, per prefix series
+	nricCheckLetters = map[byte]string{
+		'S': "JZIHGFEDCBA",
+		'T': "JZIHGFEDCBA",
+		'F': "XWUTRQPNMLK",
+		'G': "XWUTRQPNMLK",
+		'M': "XWUTRQPNJLK",
+	}
+	// Offsets added to the weighted sum for the later series of each prefix
+	nricOffsets = map[byte]int{'T': 4, 'G': 4, 'M': 3}
+
+	businessUENWeights      = []int{10, 4, 9, 3, 8, 2, 7, 1}
+	businessUENCheckLetters = "XMKECAWLJDB"
+
+	localCompanyWeights      = []int{10, 8, 6, 4, 9, 7, 5, 3, 1}
+	localCompanyCheckLetters = "ZKCMDNERGWH"
+
+	otherUENWeights  = []int{4, 3, 5, 3, 10, 2, 2, 5, 7}
+	otherUENAlphabet = "ABCDEFGHJKLMNPQRSTUVWX0123456789"
+
+	// otherUENEntityTypes are the entity type codes registered under the
+	// TyyPQnnnnC scheme, e.g. LP for limited partnerships and CS for societies
+	otherUENEntityTypes = map[string]bool{
+		"LP": true, "LL": true, "FC": true, "PF": true, "RF": true, "MQ": true, "MM": true, "NB": true,
+		"CC": true, "CS": true, "MB": true, "FM": true, "GS": true, "GA": true, "GB": true, "DP": true,
+		"CP": true, "NR": true, "CM": true, "CD": true, "MD": true, "HS": true, "VH": true, "CH": true,
+		"MH": true, "CL": true, "XL": true, "CX": true, "RP": true, "TU": true, "TC": true, "FB": true,
+		"FN": true, "PA": true, "PB": true, "SS": true, "MC": true, "SM": true,
+	}
+
+	// taxIDTagLabels name the identifier each validator tag accepts, for
+	// field error messages
+	taxIDTagLabels = map[string]string{
+		"nric":           "NRIC",
+		"fin":            "FIN",
+		"nricfin":        "NRIC or FIN",
+		"uen":            "UEN",
+		"taxrefid":       "UEN, NRIC or FIN",
+		"gstno":          "GST registration number",
+		"gstregid":       "GST registration number, UEN, NRIC or FIN",
+		"propertytaxref": "property tax reference",
+	}
+)
+
+// NormalizeTaxID trims and upper-cases an identifier before it is checked
+// or looked up
+func NormalizeTaxID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+// IsValidNRIC checks an NRIC issued to a citizen or permanent resident
+// (S and T series)
+func IsValidNRIC(id string) bool {
+	id = NormalizeTaxID(id)
+	return len(id) == 9 && (id[0] == 'S' || id[0] == 'T') && validNRICChecksum(id)
+}
+
+// IsValidFIN checks a foreign identification number (F, G and M series)
+func IsValidFIN(id string) bool {
+	id = NormalizeTaxID(id)
+	return len(id) == 9 && (id[0] == 'F' || id[0] == 'G' || id[0] == 'M') && validNRICChecksum(id)
+}
+
+// IsValidNRICOrFIN checks an identity number issued to an individual
+func IsValidNRICOrFIN(id string) bool {
+	return IsValidNRIC(id) || IsValidFIN(id)
+}
+
+// validNRICChecksum verifies the check letter of a normalized NRIC or FIN
+func validNRICChecksum(id string) bool {
+	if !nricPattern.MatchString(id) {
+		return false
+	}
+
+	prefix := id[0]
+	sum := nricOffsets[prefix]
+	for i, weight := range nricWeights {
+		sum += int(id[i+1]-'0') * weight
+	}
+	return id[8] == nricCheckLetters[prefix][sum%11]
+}
+
+// IsValidBusinessUEN checks a UEN issued to a business registered with ACRA
+// (nnnnnnnnC)
+func IsValidBusinessUEN(id string) bool {
+	id = NormalizeTaxID(id)
+	if !businessUENPattern.MatchString(id) {
+		return false
+	}
+	return id[8] == businessUENCheckLetters[weightedDigitSum(id[:8], businessUENWeights)%11]
+}
+
+// IsValidLocalCompanyUEN checks a UEN issued to a local company registered
+// with ACRA (yyyynnnnnC)
+func IsValidLocalCompanyUEN(id string) bool {
+	id = NormalizeTaxID(id)
+	if !localCompanyPattern.MatchString(id) {
+		return false
+	}
+	return id[9] == localCompanyCheckLetters[weightedDigitSum(id[:9], localCompanyWeights)%11]
+}
+
+// IsValidOtherUEN checks a UEN issued to any other entity (TyyPQnnnnC),
+// where R, S and T stand for the 1800s, 1900s and 2000s and PQ is the
+// entity type
+func IsValidOtherUEN(id string) bool {
+	id = NormalizeTaxID(id)
+	if !otherUENPattern.MatchString(id) || !otherUENEntityTypes[id[3:5]] {
+		return false
+	}
+
+	sum := 0
+	for i, weight := range otherUENWeights {
+		sum += strings.IndexByte(otherUENAlphabet, id[i]) * weight
+	}
+	remainder := ((sum-5)%11 + 11) % 11
+	return id[9] == otherUENAlphabet[remainder]
+}
+
+// IsValidUEN checks a Unique Entity Number in any of its formats
+func IsValidUEN(id string) bool {
+	return IsValidBusinessUEN(id) || IsValidLocalCompanyUEN(id) || IsValidOtherUEN(id)
+}
+
+// IsValidTaxRefID checks the tax reference of an organization or
+// individual, which is its UEN or NRIC/FIN
+func IsValidTaxRefID(id string) bool {
+	return IsValidUEN(id) || IsValidNRICOrFIN(id)
+}
+
+// IsValidGSTNumber checks a GST registration number. Entities with a UEN are
+// registered under it; others are issued an M-prefixed number, written with
+// or without dashes (MR8500001X or MR-8500001-X).
+func IsValidGSTNumber(id string) bool {
+	return IsValidUEN(id) || gstNumberPattern.MatchString(NormalizeTaxID(id))
+}
+
+// IsValidGSTRegID checks an identifier GST registration can be searched by:
+// the GST registration number, UEN or NRIC/FIN
+func IsValidGSTRegID(id string) bool {
+	return IsValidGSTNumber(id) || IsValidNRICOrFIN(id)
+}
+
+// IsValidPropertyTaxRef checks the format of a property tax reference
+func IsValidPropertyTaxRef(id string) bool {
+	return propertyTaxRefPattern.MatchString(NormalizeTaxID(id))
+}
+
+// TaxIDLabel names the identifier a tax ID validator tag accepts
+func TaxIDLabel(tag string) (string, bool) {
+	label, ok := taxIDTagLabels[tag]
+	return label, ok
+}
+
+// RegisterTaxIDValidations adds the tax ID tags (nric, fin, nricfin, uen,
+// taxrefid, gstno, gstregid and propertytaxref) to a validator
+func RegisterTaxIDValidations(validate *validator.Validate) error {
+	checks := map[string]func(string) bool{
+		"nric":           IsValidNRIC,
+		"fin":            IsValidFIN,
+		"nricfin":        IsValidNRICOrFIN,
+		"uen":            IsValidUEN,
+		"taxrefid":       IsValidTaxRefID,
+		"gstno":          IsValidGSTNumber,
+		"gstregid":       IsValidGSTRegID,
+		"propertytaxref": IsValidPropertyTaxRef,
+	}
+
+	for tag, check := range checks {
+		err := validate.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return check(fl.Field().String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// weightedDigitSum multiplies each digit by its weight and adds the results
+func weightedDigitSum(digits string, weights []int) int {
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+	return sum
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestTaxIDChecks(t *testing.T) {
+	tests := []struct {
+		id           string
+		nric         bool
+		fin          bool
+		businessUEN  bool
+		localCompany bool
+		otherUEN     bool
+		gstNumber    bool
+	}{
+		{id: "S1234567D", nric: true},
+		{id: "T0123456G", nric: true},
+		{id: " s1234567d ", nric: true},
+		{id: "S1234567A"},
+		{id: "F1234567N", fin: true},
+		{id: "G1234567X", fin: true},
+		{id: "M1234567K", fin: true},
+		{id: "F1234567D"},
+		{id: "A1234567D"},
+		{id: "53312345C", businessUEN: true, gstNumber: true},
+		{id: "12345678M", businessUEN: true, gstNumber: true},
+		{id: "53312345D"},
+		{id: "201912345R", localCompany: true, gstNumber: true},
+		{id: "199901234D", localCompany: true, gstNumber: true},
+		{id: "201912345X"},
+		{id: "T08LL1234H", otherUEN: true, gstNumber: true},
+		{id: "S99SS0001F", otherUEN: true, gstNumber: true},
+		{id: "T08LL1234A"},
+		{id: "T08ZZ1234H"},
+		{id: "M90012345X", gstNumber: true},
+		{id: "M2-0012345-6", gstNumber: true},
+		{id: "MR-8500001-X", gstNumber: true},
+		{id: "MR8500001X", gstNumber: true},
+		{id: "mr-8500001-x", gstNumber: true},
+		{id: "M2-012345-6"},
+		{id: "MR-8500001X"},
+		{id: "MR--8500001-X"},
+		{id: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			checks := []struct {
+				name  string
+				check func(string) bool
+				want  bool
+			}{
+				{"IsValidNRIC", IsValidNRIC, tt.nric},
+				{"IsValidFIN", IsValidFIN, tt.fin},
+				{"IsValidNRICOrFIN", IsValidNRICOrFIN, tt.nric || tt.fin},
+				{"IsValidBusinessUEN", IsValidBusinessUEN, tt.businessUEN},
+				{"IsValidLocalCompanyUEN", IsValidLocalCompanyUEN, tt.localCompany},
+				{"IsValidOtherUEN", IsValidOtherUEN, tt.otherUEN},
+				{"IsValidUEN", IsValidUEN, tt.businessUEN || tt.localCompany || tt.otherUEN},
+				{"IsValidTaxRefID", IsValidTaxRefID, tt.businessUEN || tt.localCompany || tt.otherUEN || tt.nric || tt.fin},
+				{"IsValidGSTNumber", IsValidGSTNumber, tt.gstNumber},
+				{"IsValidGSTRegID", IsValidGSTRegID, tt.gstNumber || tt.nric || tt.fin},
+			}
+			for _, c := range checks {
+				if got := c.check(tt.id); got != c.want {
+					t.Errorf("%s(%q) = %v, want %v", c.name, tt.id, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsValidPropertyTaxRef(t *testing.T) {
+	tests := map[string]bool{
+		"1234567A":    true,
+		"1234567890b": true,
+		"123456A":     false,
+		"12345678A":   false,
+		"1234567":     false,
+	}
+	for ref, want := range tests {
+		if got := IsValidPropertyTaxRef(ref); got != want {
+			t.Errorf("IsValidPropertyTaxRef(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
+
+func TestRegisterTaxIDValidations(t *testing.T) {
+	validate := validator.New()
+	if err := RegisterTaxIDValidations(validate); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type party struct {
+		IDNo  string `validate:"taxrefid"`
+		GSTNo string `validate:"omitempty,gstno"`
+	}
+
+	tests := []struct {
+		name    string
+		party   party
+		wantTag string
+	}{
+		{"valid", party{IDNo: "S1234567D", GSTNo: "M90012345X"}, ""},
+		{"optional GST number", party{IDNo: "201912345R"}, ""},
+		{"invalid tax reference", party{IDNo: "201912345X"}, "taxrefid"},
+		{"invalid GST number", party{IDNo: "53312345C", GSTNo: "X90012345X"}, "gstno"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.party)
+			if tt.wantTag == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			validationErrors, ok := err.(validator.ValidationErrors)
+			if !ok || len(validationErrors) != 1 {
+				t.Fatalf("expected one validation error, got %v", err)
+			}
+			if tag := validationErrors[0].Tag(); tag != tt.wantTag {
+				t.Errorf("failed on %s, want %s", tag, tt.wantTag)
+			}
+			if _, ok := TaxIDLabel(tt.wantTag); !ok {
+				t.Errorf("no label for tag %s", tt.wantTag)
+			}
+		})
+	}
+}
@@ -45,6 +45,38 @@ func FormatDateTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
 }
 
+// AddMonths adds calendar months to a date, clamping to the last day of the
+// target month instead of overflowing (31 Aug + 6 months = 28/29 Feb)
+func AddMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	target := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := target.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(target.Year(), target.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// HoldingPeriod returns the whole years, months and days elapsed between two
+// dates using anniversary arithmetic. Anniversaries that fall on a day the
+// month does not have (e.g. 29 Feb) are taken as the month's last day.
+func HoldingPeriod(from, to time.Time) (years, months, days int) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return 0, 0, 0
+	}
+
+	totalMonths := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if AddMonths(from, totalMonths).After(to) {
+		totalMonths--
+	}
+
+	anniversary := AddMonths(from, totalMonths)
+	days = int(to.Sub(anniversary).Hours() / 24)
+	return totalMonths / 12, totalMonths % 12, days
+}
+
 // CleanString removes extra spaces and converts to lowercase
 func CleanString(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		date   string
+		months int
+		want   string
+	}{
+		{"2024-01-15", 0, "2024-01-15"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2023-01-31", 1, "2023-02-28"},
+		{"2023-08-31", 6, "2024-02-29"},
+		{"2024-12-31", 2, "2025-02-28"},
+		{"2024-02-29", 12, "2025-02-28"},
+		{"2024-02-29", 48, "2028-02-29"},
+		{"2024-03-31", -1, "2024-02-29"},
+		{"2024-01-31", -2, "2023-11-30"},
+	}
+
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := AddMonths(date, tt.months).Format("2006-01-02"); got != tt.want {
+			t.Errorf("AddMonths(%s, %d) = %s, want %s", tt.date, tt.months, got, tt.want)
+		}
+	}
+}
+
+func TestHoldingPeriod(t *testing.T) {
+	tests := []struct {
+		name                string
+		from, to            string
+		years, months, days int
+	}{
+		{"same day", "2020-01-15", "2020-01-15", 0, 0, 0},
+		{"disposed before acquired", "2021-01-15", "2020-01-15", 0, 0, 0},
+		{"exact anniversary", "2020-01-15", "2021-01-15", 1, 0, 0},
+		{"day before the anniversary", "2020-01-15", "2021-01-14", 0, 11, 30},
+		{"day after the anniversary", "2020-01-15", "2021-01-16", 1, 0, 1},
+		{"29 Feb anniversary in a common year", "2024-02-29", "2025-02-28", 1, 0, 0},
+		{"day after a 29 Feb anniversary", "2024-02-29", "2025-03-01", 1, 0, 1},
+		{"29 Feb to the next leap day", "2024-02-29", "2028-02-29", 4, 0, 0},
+		{"month end into February", "2024-01-31", "2024-02-29", 0, 1, 0},
+		{"month end past February", "2024-01-31", "2024-03-30", 0, 1, 30},
+		{"31 Aug to 29 Feb", "2023-08-31", "2024-02-29", 0, 6, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := time.Parse("2006-01-02", tt.from)
+			to, _ := time.Parse("2006-01-02", tt.to)
+			years, months, days := HoldingPeriod(from, to)
+			if years != tt.years || months != tt.months || days != tt.days {
+				t.Errorf("HoldingPeriod(%s, %s) = %dy %dm %dd, want %dy %dm %dd", tt.from, tt.to, years, months, days, tt.years, tt.months, tt.days)
+			}
+		})
+	}
+}
+
+func TestHoldingPeriodIgnoresTimeOfDay(t *testing.T) {
+	from := time.Date(2020, time.January, 15, 23, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.January, 15, 1, 0, 0, 0, time.UTC)
+	if years, months, days := HoldingPeriod(from, to); years != 1 || months != 0 || days != 0 {
+		t.Errorf("HoldingPeriod = %dy %dm %dd, want 1y 0m 0d", years, months, days)
+	}
+}